@@ -22,22 +22,60 @@ func main() {
 
 	log := setupLogger(cfg.Env)
 
-	application := app.New(context.Background(), log, cfg.GRPC.Port, *cfg, cfg.TokenTTL)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	application := app.New(ctx, log, cfg.GRPC.Port, *cfg, cfg.TokenTTL)
 
 	go application.GRPCSrv.MustRun()
 
+	if application.HTTPSrv != nil {
+		go application.HTTPSrv.MustRun()
+	}
+
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
 
-	sign := <-stop
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+
+	var sign os.Signal
+	for sign == nil {
+		select {
+		case <-reload:
+			reloadKeys(log, application, cfg.Path)
+		case sign = <-stop:
+		}
+	}
 
 	log.Info("stopping application", slog.String("signal", sign.String()))
 
+	if application.HTTPSrv != nil {
+		application.HTTPSrv.Stop()
+	}
+
 	application.GRPCSrv.Stop()
+	application.Auth.Stop() // waits for notifications still being delivered
 
 	log.Info("application stopped")
 }
 
+// reloadKeys re-reads signing keys from the config file, the servers keep running.
+func reloadKeys(log *slog.Logger, application *app.App, configPath string) {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		log.Error("failed to reload config", "", err.Error())
+		return
+	}
+
+	if err := application.ReloadKeys(*cfg); err != nil {
+		log.Error("failed to reload signing keys", "", err.Error())
+		return
+	}
+
+	log.Info("signing keys reloaded")
+}
+
 func setupLogger(env string) *slog.Logger {
 	var log *slog.Logger
 
@@ -0,0 +1,79 @@
+// Command breach manages the local corpus of breached passwords.
+//
+//	breach build -corpus ./pwned-passwords -out ./breached.bloom -fp 0.001
+//
+// build turns a corpus in the Have I Been Pwned format, a directory of range files
+// or a single file of HASH:COUNT lines, into a compact bloom filter the service can load
+// with password.breach.path.
+package main
+
+import (
+	"auth/internal/breach"
+	"flag"
+	"fmt"
+	"os"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	switch os.Args[1] {
+	case "build":
+		if err := build(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	default:
+		usage()
+	}
+}
+
+func build(args []string) error {
+	flags := flag.NewFlagSet("build", flag.ExitOnError)
+
+	corpus := flags.String("corpus", "", "directory of range files or a file of HASH:COUNT lines")
+	out := flags.String("out", "", "path of the filter file to write")
+	fpRate := flags.Float64("fp", 0.001, "false positive rate of the filter")
+
+	_ = flags.Parse(args)
+
+	if *corpus == "" || *out == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	if *fpRate <= 0 || *fpRate >= 1 {
+		return fmt.Errorf("false positive rate must be between 0 and 1, got %v", *fpRate)
+	}
+
+	filter, err := breach.Build(*corpus, *fpRate)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+
+	size, err := filter.WriteTo(file)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	fmt.Printf("filter of %d bytes written to %s\n", size, *out)
+
+	return nil
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: breach build -corpus <path> -out <path> [-fp <rate>]")
+	os.Exit(2)
+}
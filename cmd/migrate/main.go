@@ -0,0 +1,128 @@
+// Command migrate manages the database schema of the service.
+//
+//	migrate -config ./config.yaml up
+//	migrate -config ./config.yaml down -steps 1
+//	migrate -config ./config.yaml status
+//
+// up applies the pending migrations and prints what they report, down reverts the last applied ones
+// and status lists every migration with the time it was applied. The database is taken from the config
+// of the service, which can apply the migrations by itself on start with db.migrate_on_start.
+package main
+
+import (
+	"auth/internal/config"
+	"auth/internal/migrate"
+	"auth/internal/storage"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func main() {
+	cfg := config.MustLoad()
+
+	if flag.NArg() < 1 {
+		usage()
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	store, err := storage.NewStorage(*cfg)
+	if err != nil {
+		fail(err)
+	}
+
+	migrator, err := store.Migrator()
+	if err != nil {
+		fail(err)
+	}
+
+	switch flag.Arg(0) {
+	case "up":
+		err = up(ctx, migrator)
+	case "down":
+		err = down(ctx, migrator, flag.Args()[1:])
+	case "status":
+		err = status(ctx, migrator)
+	default:
+		usage()
+	}
+
+	if err != nil {
+		fail(err)
+	}
+}
+
+func up(ctx context.Context, migrator *migrate.Migrator) error {
+	applied, err := migrator.Up(ctx)
+	for _, migration := range applied {
+		fmt.Printf("applied %d_%s\n", migration.Version, migration.Name)
+
+		for _, note := range migration.Notes {
+			fmt.Printf("  note: %s\n", note)
+		}
+	}
+
+	if err == nil && len(applied) == 0 {
+		fmt.Println("schema is up to date")
+	}
+
+	return err
+}
+
+func down(ctx context.Context, migrator *migrate.Migrator, args []string) error {
+	flags := flag.NewFlagSet("down", flag.ExitOnError)
+
+	steps := flags.Int("steps", 1, "number of migrations to revert")
+
+	_ = flags.Parse(args)
+
+	if *steps < 1 {
+		return fmt.Errorf("steps must be positive, got %d", *steps)
+	}
+
+	reverted, err := migrator.Down(ctx, *steps)
+	for _, migration := range reverted {
+		fmt.Printf("reverted %d_%s\n", migration.Version, migration.Name)
+	}
+
+	return err
+}
+
+func status(ctx context.Context, migrator *migrate.Migrator) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range statuses {
+		name := s.Name
+		if name == "" {
+			name = "(unknown to this build)"
+		}
+
+		applied := "pending"
+		if !s.AppliedAt.IsZero() {
+			applied = "applied " + s.AppliedAt.Format(time.RFC3339)
+		}
+
+		fmt.Printf("%04d_%s\t%s\n", s.Version, name, applied)
+	}
+
+	return nil
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: migrate [-config <path>] up | down [-steps <n>] | status")
+	os.Exit(2)
+}
@@ -2,16 +2,43 @@ package app
 
 import (
 	grpcapp "auth/internal/app/grpc"
+	httpapp "auth/internal/app/http"
+	"auth/internal/breach"
 	"auth/internal/config"
+	"auth/internal/jwt"
+	"auth/internal/notify"
+	"auth/internal/password"
+	"auth/internal/secretbox"
 	"auth/internal/services/auth"
 	"auth/internal/storage"
+	"auth/internal/storage/cache"
+	"auth/internal/storage/memory"
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"time"
+
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+const envProd = "prod"
+
+const (
+	throttleStoreMemory   = "memory"
+	throttleStorePostgres = "postgres"
+)
+
+const (
+	notifierLog  = "log"
+	notifierFile = "file"
 )
 
 type App struct {
 	GRPCSrv *grpcapp.App
+	HTTPSrv *httpapp.App // nil when the JWKS endpoint is disabled
+	Auth    *auth.Auth
+	keys    *jwt.KeyRing
 }
 
 func New(ctx context.Context,
@@ -26,11 +53,386 @@ func New(ctx context.Context,
 		panic(err)
 	}
 
-	authService := auth.NewAuth(log, newStorage, newStorage, tokenTTL)
+	if cfg.DBConfig.MigrateOnStart {
+		mustMigrate(ctx, log, newStorage)
+	}
+
+	keys := mustLoadKeyRing(log, cfg)
+
+	revocations := cache.NewRevocations(newStorage, cfg.Revocation.CacheTTL)
+	go cleanupRevocations(ctx, log, newStorage, revocations, cfg.Revocation.CleanupInterval)
+	go cleanupExpiredTokens(ctx, log, newStorage, cfg.CleanupInterval)
+
+	attempts := mustNewAttemptStore(ctx, log, newStorage, cfg.LoginThrottle)
+
+	hasher, err := password.New(cfg.Password)
+	if err != nil {
+		panic(err)
+	}
+
+	var breaches auth.BreachChecker
+	if cfg.Password.Breach.Enabled {
+		breaches, err = breach.Open(cfg.Password.Breach.Path)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	notifier := mustNewNotifier(log, cfg.Notifier)
+	secrets := mustNewSecretBox(log, cfg)
+	webAuthn := mustNewWebAuthn(cfg.WebAuthn)
+
+	authService := auth.NewAuth(log,
+		auth.Deps{
+			UserProvider:        newStorage,
+			UserSaver:           newStorage,
+			RefreshTokens:       newStorage,
+			Revoker:             revocations,
+			AppProvider:         newStorage,
+			RoleProvider:        newStorage,
+			Attempts:            attempts,
+			Hasher:              hasher,
+			Breaches:            breaches,
+			OneTimeTokens:       newStorage,
+			Notifier:            notifier,
+			MFA:                 newStorage,
+			Secrets:             secrets,
+			WebAuthnCredentials: newStorage,
+			WebAuthn:            webAuthn,
+			Keys:                keys,
+		},
+		auth.Options{
+			TokenTTL:             tokenTTL,
+			RefreshTokenTTL:      cfg.RefreshTokenTTL,
+			ResetTokenTTL:        cfg.PasswordReset.TokenTTL,
+			VerificationTokenTTL: cfg.Email.TokenTTL,
+			MFATokenTTL:          cfg.MFA.TokenTTL,
+			Throttle: auth.Throttle{
+				Window:           cfg.LoginThrottle.Window,
+				FreeAttempts:     cfg.LoginThrottle.FreeAttempts,
+				BaseDelay:        cfg.LoginThrottle.BaseDelay,
+				MaxDelay:         cfg.LoginThrottle.MaxDelay,
+				LockoutThreshold: cfg.LoginThrottle.LockoutThreshold,
+				LockoutDuration:  cfg.LoginThrottle.LockoutDuration,
+			},
+			PasswordPolicy:       password.NewPolicy(cfg.Password.Policy),
+			RequireVerifiedEmail: cfg.Email.RequireVerified,
+			MFAIssuer:            cfg.MFA.Issuer,
+			Issuer:               cfg.JWT.Issuer,
+			Audience:             cfg.JWT.Audience,
+		},
+	)
 
-	grpcServer := grpcapp.NewApp(log, grpcPort, authService)
+	grpcServer := grpcapp.NewApp(log, grpcPort, authService, authService)
+
+	var httpServer *httpapp.App
+	if cfg.HTTP.Port != 0 {
+		httpServer = httpapp.NewApp(log, cfg.HTTP.Port, cfg.HTTP.Timeout, keys)
+	}
 
 	return &App{
 		GRPCSrv: grpcServer,
+		HTTPSrv: httpServer,
+		Auth:    authService,
+		keys:    keys,
+	}
+}
+
+// ReloadKeys re-reads the signing keys from config and swaps them without restarting the servers.
+// The current keys are kept if the new ones can't be loaded.
+func (a *App) ReloadKeys(cfg config.Config) error {
+	keys, err := loadKeyRing(cfg)
+	if err != nil {
+		return err
+	}
+
+	a.keys.Replace(keys)
+
+	return nil
+}
+
+// mustLoadKeyRing loads the token signing keys from config.
+// In non-prod environments a missing active key is replaced with a random one.
+func mustLoadKeyRing(log *slog.Logger, cfg config.Config) *jwt.KeyRing {
+	keys, err := loadKeyRing(cfg)
+	if err == nil {
+		return keys
+	}
+
+	if cfg.Env == envProd || !errors.Is(err, jwt.ErrKeyMissing) || len(cfg.JWT.RetiredKeys) > 0 {
+		panic(err)
+	}
+
+	log.Warn("signing key is not configured, using a random one")
+
+	key, err := jwt.GenerateKey(cfg.JWT.SigningKey.ID)
+	if err != nil {
+		panic(err)
+	}
+
+	return jwt.NewKeyRing(key)
+}
+
+// loadKeyRing loads the token signing keys from config.
+// In prod every key must be present and strong, otherwise the service refuses to start.
+func loadKeyRing(cfg config.Config) (*jwt.KeyRing, error) {
+	keys, err := jwt.LoadKeyRing(cfg.JWT)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Env == envProd {
+		for _, key := range keys.Keys() {
+			if err := key.Validate(); err != nil {
+				return nil, fmt.Errorf("invalid signing key %q: %w", key.ID, err)
+			}
+		}
+	}
+
+	return keys, nil
+}
+
+// cleanupRevocations periodically drops revocations of tokens that have expired anyway,
+// until ctx is done. It does nothing with a non-positive interval, which time.NewTicker rejects.
+func cleanupRevocations(ctx context.Context,
+	log *slog.Logger,
+	storage *storage.Storage,
+	revocations *cache.Revocations,
+	interval time.Duration,
+) {
+	const op = "app.cleanupRevocations"
+
+	log = log.With(slog.String("op", op))
+
+	if interval <= 0 {
+		log.Warn("revocation cleanup is disabled, its interval is not positive", slog.Duration("interval", interval))
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			revocations.Prune(now)
+
+			deleted, err := storage.DeleteExpiredRevokedTokens(ctx, now)
+			if err != nil {
+				log.Error("failed to delete expired revoked tokens", "", err.Error())
+				continue
+			}
+
+			log.Debug("expired revoked tokens deleted", slog.Int64("count", deleted))
+		}
+	}
+}
+
+// cleanupExpiredTokens periodically deletes refresh tokens, one-time tokens and WebAuthn sessions
+// that have expired, until ctx is done. A non-positive interval disables the cleanup
+// instead of crashing the service in time.NewTicker.
+func cleanupExpiredTokens(ctx context.Context,
+	log *slog.Logger,
+	storage *storage.Storage,
+	interval time.Duration,
+) {
+	const op = "app.cleanupExpiredTokens"
+
+	log = log.With(slog.String("op", op))
+
+	if interval <= 0 {
+		log.Warn("expired token cleanup is disabled, its interval is not positive", slog.Duration("interval", interval))
+		return
+	}
+
+	deleters := []struct {
+		name          string
+		deleteExpired func(ctx context.Context, now time.Time) (int64, error)
+	}{
+		{name: "refresh tokens", deleteExpired: storage.DeleteExpiredRefreshTokens},
+		{name: "one-time tokens", deleteExpired: storage.DeleteExpiredOneTimeTokens},
+		{name: "webauthn sessions", deleteExpired: storage.DeleteExpiredWebAuthnSessions},
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			for _, d := range deleters {
+				deleted, err := d.deleteExpired(ctx, now)
+				if err != nil {
+					log.Error("failed to delete expired "+d.name, "", err.Error())
+					continue
+				}
+
+				log.Debug("expired "+d.name+" deleted", slog.Int64("count", deleted))
+			}
+		}
+	}
+}
+
+// mustNewSecretBox returns the box encrypting TOTP secrets with the key from config.
+// In non-prod environments a missing key is replaced with a random one,
+// so secrets enrolled before a restart can't be read after it.
+func mustNewSecretBox(log *slog.Logger, cfg config.Config) *secretbox.Box {
+	key, err := secretbox.ParseKey(cfg.MFA.EncryptionKey)
+	if err != nil {
+		if cfg.Env == envProd || !errors.Is(err, secretbox.ErrKeyMissing) {
+			panic(err)
+		}
+
+		log.Warn("mfa encryption key is not configured, using a random one")
+
+		key, err = secretbox.GenerateKey()
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	box, err := secretbox.New(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return box
+}
+
+// mustNewWebAuthn returns the WebAuthn relying party described in config, nil if WebAuthn is disabled.
+func mustNewWebAuthn(cfg config.WebAuthnConfig) *webauthn.WebAuthn {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	timeout := webauthn.TimeoutConfig{Enforce: true, Timeout: cfg.Timeout, TimeoutUVD: cfg.Timeout}
+
+	webAuthn, err := webauthn.New(&webauthn.Config{
+		RPID:          cfg.RPID,
+		RPDisplayName: cfg.RPDisplayName,
+		RPOrigins:     cfg.RPOrigins,
+		Timeouts: webauthn.TimeoutsConfig{
+			Login:        timeout,
+			Registration: timeout,
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return webAuthn
+}
+
+// mustNewNotifier returns the notifier chosen in config.
+func mustNewNotifier(log *slog.Logger, cfg config.NotifierConfig) auth.Notifier {
+	switch cfg.Type {
+	case notifierLog:
+		return notify.NewLog(log)
+	case notifierFile:
+		if cfg.Path == "" {
+			panic("notifier path is empty")
+		}
+
+		return notify.NewFile(cfg.Path)
+	default:
+		panic(fmt.Sprintf("unknown notifier type %q", cfg.Type))
+	}
+}
+
+// mustMigrate applies the pending schema migrations. Instances starting at once wait for each other,
+// so the migrations are applied only once.
+func mustMigrate(ctx context.Context, log *slog.Logger, storage *storage.Storage) {
+	const op = "app.mustMigrate"
+
+	log = log.With(slog.String("op", op))
+
+	migrator, err := storage.Migrator()
+	if err != nil {
+		panic(err)
+	}
+
+	applied, err := migrator.Up(ctx)
+	for _, migration := range applied {
+		log.Info("migration applied", slog.Int64("version", migration.Version), slog.String("name", migration.Name))
+
+		for _, note := range migration.Notes {
+			log.Warn("migration note", slog.Int64("version", migration.Version), slog.String("note", note))
+		}
+	}
+
+	if err != nil {
+		panic(err)
+	}
+
+	if len(applied) == 0 {
+		log.Debug("schema is up to date")
+	}
+}
+
+// mustNewAttemptStore returns the store of failed logins chosen in config
+// and starts dropping its stale counters until ctx is done.
+func mustNewAttemptStore(ctx context.Context,
+	log *slog.Logger,
+	storage *storage.Storage,
+	cfg config.ThrottleConfig,
+) auth.AttemptStore {
+	// A counter is stale once it neither counts towards the window nor holds a delay.
+	ttl := max(cfg.Window, cfg.MaxDelay, cfg.LockoutDuration)
+
+	switch cfg.Store {
+	case throttleStoreMemory:
+		attempts := memory.NewAttempts()
+		go cleanupLoginAttempts(ctx, log, ttl, func(_ context.Context, before time.Time) (int64, error) {
+			attempts.Prune(before)
+			return 0, nil
+		})
+
+		return attempts
+	case throttleStorePostgres:
+		go cleanupLoginAttempts(ctx, log, ttl, storage.DeleteStaleLoginAttempts)
+
+		return storage
+	default:
+		panic(fmt.Sprintf("unknown login throttle store %q", cfg.Store))
+	}
+}
+
+// cleanupLoginAttempts periodically deletes the counters of failed logins older than ttl,
+// until ctx is done. A zero ttl, with every throttle duration unset, leaves nothing to wait for
+// and the counters are kept.
+func cleanupLoginAttempts(ctx context.Context,
+	log *slog.Logger,
+	ttl time.Duration,
+	deleteStale func(ctx context.Context, before time.Time) (int64, error),
+) {
+	const op = "app.cleanupLoginAttempts"
+
+	log = log.With(slog.String("op", op))
+
+	if ttl <= 0 {
+		log.Warn("login attempts cleanup is disabled, the throttle durations are not positive", slog.Duration("ttl", ttl))
+		return
+	}
+
+	ticker := time.NewTicker(ttl)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			deleted, err := deleteStale(ctx, now.Add(-ttl))
+			if err != nil {
+				log.Error("failed to delete stale login attempts", "", err.Error())
+				continue
+			}
+
+			log.Debug("stale login attempts deleted", slog.Int64("count", deleted))
+		}
 	}
 }
@@ -2,6 +2,8 @@ package grpcapp
 
 import (
 	authgRPC "auth/internal/grpc/auth"
+	"auth/internal/grpc/authz"
+	"auth/internal/grpc/requestid"
 	"fmt"
 	"google.golang.org/grpc"
 	"log/slog"
@@ -19,8 +21,18 @@ type App struct {
 
 func NewApp(log *slog.Logger,
 	port int,
-	authService authgRPC.Auth) *App {
-	grpcServer := grpc.NewServer()
+	authService authgRPC.Auth,
+	tokenValidator authz.TokenValidator) *App {
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			requestid.UnaryServerInterceptor(),
+			authz.UnaryServerInterceptor(tokenValidator, authgRPC.Policies),
+		),
+		grpc.ChainStreamInterceptor(
+			requestid.StreamServerInterceptor(),
+			authz.StreamServerInterceptor(tokenValidator, authgRPC.Policies),
+		),
+	)
 
 	authgRPC.Register(grpcServer, authService)
 
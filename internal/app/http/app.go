@@ -0,0 +1,99 @@
+package httpapp
+
+import (
+	"auth/internal/jwt"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+// JWKSPath is the well-known location of the public key set (RFC 8414).
+const JWKSPath = "/.well-known/jwks.json"
+
+// KeySet provides the public keys that verifiers use to check token signatures.
+type KeySet interface {
+	JWKS() jwt.JWKS
+}
+
+// App is an HTTP server publishing the JWKS document, so that other services can verify tokens
+// holding only public keys.
+type App struct {
+	log        *slog.Logger
+	httpServer *http.Server
+	port       int
+}
+
+func NewApp(log *slog.Logger,
+	port int,
+	timeout time.Duration,
+	keys KeySet) *App {
+	mux := http.NewServeMux()
+	mux.HandleFunc(JWKSPath, jwksHandler(log, keys))
+
+	return &App{
+		log: log,
+		httpServer: &http.Server{
+			Handler:           mux,
+			ReadHeaderTimeout: timeout,
+			WriteTimeout:      timeout,
+		},
+		port: port,
+	}
+}
+
+func (a *App) MustRun() {
+	if err := a.Run(); err != nil {
+		panic(err)
+	}
+}
+
+func (a *App) Run() error {
+	const op = "httpapp.Run"
+	log := a.log.With(slog.String("op", op),
+		slog.Int("port", a.port))
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", a.port))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("HTTP server is running", slog.String("addr", listener.Addr().String()))
+
+	if err := a.httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (a *App) Stop() {
+	const op = "httpapp.Stop"
+
+	a.log.With(slog.String("op", op)).
+		Info("stopping HTTP server", slog.Int("port", a.port))
+
+	if err := a.httpServer.Shutdown(context.Background()); err != nil {
+		a.log.Error("failed to stop HTTP server", "", err.Error())
+	}
+}
+
+func jwksHandler(log *slog.Logger, keys KeySet) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+
+		if err := json.NewEncoder(w).Encode(keys.JWKS()); err != nil {
+			log.Error("failed to write JWKS", "", err.Error())
+		}
+	}
+}
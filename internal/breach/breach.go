@@ -0,0 +1,110 @@
+// Package breach checks passwords against a local copy of known breach corpora
+// in the Have I Been Pwned format, without any network access.
+//
+// The corpus is either a directory of range files, one per 5 hex character prefix of the SHA-1 hash
+// named like 5BAA6.txt with SUFFIX:COUNT lines, or a bloom filter built from such a corpus
+// by the breach command.
+package breach
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	prefixLength = 5
+	hashLength   = sha1.Size * 2
+)
+
+var ErrMalformedCorpus = errors.New("malformed breach corpus")
+
+// Checker reports whether a password appears in the corpus.
+type Checker interface {
+	IsBreached(password string) (bool, error)
+}
+
+// Open opens the corpus at path: a directory of range files or a bloom filter file.
+func Open(path string) (Checker, error) {
+	const op = "breach.Open"
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if info.IsDir() {
+		return &RangeDir{dir: path}, nil
+	}
+
+	filter, err := LoadFilter(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return filter, nil
+}
+
+// hash returns the upper case hex SHA-1 hash of the password, the form the corpus uses.
+func hash(password string) string {
+	sum := sha1.Sum([]byte(password))
+
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// parseLine parses a HASH:COUNT line of the corpus.
+// Lines of range files hold only the hash suffix, the prefix is added from the file name.
+// Zero counts are padding the corpus may be distributed with.
+func parseLine(line, prefix string) (hash string, count int, err error) {
+	hash, countStr, ok := strings.Cut(strings.TrimSpace(line), ":")
+	if !ok {
+		return "", 0, fmt.Errorf("%w: line %q", ErrMalformedCorpus, line)
+	}
+
+	hash = strings.ToUpper(prefix + hash)
+	if len(hash) != hashLength {
+		return "", 0, fmt.Errorf("%w: hash %q", ErrMalformedCorpus, hash)
+	}
+
+	if _, err := hex.DecodeString(hash); err != nil {
+		return "", 0, fmt.Errorf("%w: hash %q", ErrMalformedCorpus, hash)
+	}
+
+	count, err = strconv.Atoi(countStr)
+	if err != nil {
+		return "", 0, fmt.Errorf("%w: count %q", ErrMalformedCorpus, countStr)
+	}
+
+	return hash, count, nil
+}
+
+// scan calls fn for every breached hash read from r, skipping padding.
+func scan(r io.Reader, prefix string, fn func(hash string) error) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		hash, count, err := parseLine(scanner.Text(), prefix)
+		if err != nil {
+			return err
+		}
+
+		if count == 0 {
+			continue
+		}
+
+		if err := fn(hash); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
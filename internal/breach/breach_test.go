@@ -0,0 +1,112 @@
+package breach
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeRangeDir writes the range files of the corpus, the passwords with zero counts are padding.
+func writeRangeDir(t *testing.T, counts map[string]int) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	files := make(map[string]*bytes.Buffer)
+	for password, count := range counts {
+		hash := hash(password)
+
+		prefix := hash[:prefixLength]
+		if files[prefix] == nil {
+			files[prefix] = &bytes.Buffer{}
+		}
+
+		fmt.Fprintf(files[prefix], "%s:%d\r\n", hash[prefixLength:], count)
+	}
+
+	for prefix, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, prefix+".txt"), content.Bytes(), 0o600))
+	}
+
+	return dir
+}
+
+func Test_Checkers(t *testing.T) {
+	dir := writeRangeDir(t, map[string]int{
+		"password": 9659365,
+		"123456":   42,
+		"padding":  0,
+	})
+
+	filter, err := Build(dir, 0.0001)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	_, err = filter.WriteTo(&buf)
+	require.NoError(t, err)
+
+	filterFile := filepath.Join(t.TempDir(), "breached.bloom")
+	require.NoError(t, os.WriteFile(filterFile, buf.Bytes(), 0o600))
+
+	fromDir, err := Open(dir)
+	require.NoError(t, err)
+	assert.IsType(t, &RangeDir{}, fromDir)
+
+	fromFilter, err := Open(filterFile)
+	require.NoError(t, err)
+	assert.IsType(t, &Filter{}, fromFilter)
+
+	tests := []struct {
+		password         string
+		expectedBreached bool
+	}{
+		{password: "password", expectedBreached: true},
+		{password: "123456", expectedBreached: true},
+		{password: "padding"},
+		{password: "correct horse battery staple"},
+	}
+
+	for name, checker := range map[string]Checker{"range dir": fromDir, "filter": fromFilter} {
+		for _, tc := range tests {
+			t.Run(name+"/"+tc.password, func(t *testing.T) {
+				breached, err := checker.IsBreached(tc.password)
+
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedBreached, breached)
+			})
+		}
+	}
+}
+
+func Test_Build_FullHashFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "pwned-passwords.txt")
+	content := fmt.Sprintf("%s:10\n%s:3\n", hash("password"), hash("qwerty"))
+	require.NoError(t, os.WriteFile(file, []byte(content), 0o600))
+
+	filter, err := Build(file, 0.0001)
+	require.NoError(t, err)
+
+	breached, _ := filter.IsBreached("qwerty")
+	assert.True(t, breached)
+
+	breached, _ = filter.IsBreached("correct horse battery staple")
+	assert.False(t, breached)
+}
+
+func Test_Build_Malformed(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "pwned-passwords.txt")
+	require.NoError(t, os.WriteFile(file, []byte("not a hash\n"), 0o600))
+
+	_, err := Build(file, 0.001)
+	assert.ErrorIs(t, err, ErrMalformedCorpus)
+}
+
+func Test_ReadFilter_Malformed(t *testing.T) {
+	_, err := ReadFilter(bytes.NewReader([]byte("not a filter at all")))
+	assert.ErrorIs(t, err, ErrMalformedCorpus)
+}
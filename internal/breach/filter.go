@@ -0,0 +1,227 @@
+package breach
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// filterMagic starts every bloom filter file, the last byte is the format version.
+var filterMagic = [4]byte{'B', 'R', 'F', 1}
+
+// maxHashes bounds the number of hash functions of a filter read from a file.
+const maxHashes = 64
+
+// Filter is a bloom filter of breached SHA-1 hashes.
+// A breached password is always reported, others are reported by mistake with the rate
+// the filter was built for.
+type Filter struct {
+	hashes uint32
+	bits   uint64
+	words  []uint64
+}
+
+// NewFilter returns an empty filter sized for n hashes and the false positive rate.
+func NewFilter(n uint64, falsePositiveRate float64) *Filter {
+	n = max(n, 1)
+
+	bits := uint64(math.Ceil(-float64(n) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	bits = max(bits, 64)
+	hashes := uint32(max(math.Round(float64(bits)/float64(n)*math.Ln2), 1))
+
+	return &Filter{
+		hashes: min(hashes, maxHashes),
+		bits:   bits,
+		words:  make([]uint64, (bits+63)/64),
+	}
+}
+
+// Build builds a filter from the corpus at path: a directory of range files
+// or a single file of full HASH:COUNT lines.
+func Build(path string, falsePositiveRate float64) (*Filter, error) {
+	const op = "breach.Build"
+
+	var n uint64
+	if err := walkCorpus(path, func(string) error {
+		n++
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	filter := NewFilter(n, falsePositiveRate)
+	if err := walkCorpus(path, filter.addHash); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return filter, nil
+}
+
+// LoadFilter reads a filter written by Filter.WriteTo.
+func LoadFilter(path string) (*Filter, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ReadFilter(bufio.NewReader(file))
+}
+
+// ReadFilter reads a filter written by Filter.WriteTo.
+func ReadFilter(r io.Reader) (*Filter, error) {
+	var header struct {
+		Magic  [4]byte
+		Hashes uint32
+		Bits   uint64
+	}
+
+	if err := binary.Read(r, binary.BigEndian, &header); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedCorpus, err)
+	}
+
+	if header.Magic != filterMagic {
+		return nil, fmt.Errorf("%w: not a filter file", ErrMalformedCorpus)
+	}
+
+	if header.Hashes == 0 || header.Hashes > maxHashes || header.Bits == 0 {
+		return nil, fmt.Errorf("%w: invalid filter parameters", ErrMalformedCorpus)
+	}
+
+	filter := &Filter{
+		hashes: header.Hashes,
+		bits:   header.Bits,
+		words:  make([]uint64, (header.Bits+63)/64),
+	}
+
+	if err := binary.Read(r, binary.BigEndian, filter.words); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedCorpus, err)
+	}
+
+	return filter, nil
+}
+
+// WriteTo writes the filter in the format LoadFilter reads.
+func (f *Filter) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+
+	header := struct {
+		Magic  [4]byte
+		Hashes uint32
+		Bits   uint64
+	}{filterMagic, f.hashes, f.bits}
+
+	if err := binary.Write(bw, binary.BigEndian, header); err != nil {
+		return 0, err
+	}
+
+	if err := binary.Write(bw, binary.BigEndian, f.words); err != nil {
+		return 0, err
+	}
+
+	if err := bw.Flush(); err != nil {
+		return 0, err
+	}
+
+	return int64(binary.Size(header) + binary.Size(f.words)), nil
+}
+
+func (f *Filter) IsBreached(password string) (bool, error) {
+	return f.containsHash(hash(password)), nil
+}
+
+func (f *Filter) addHash(hash string) error {
+	for _, bit := range f.positions(hash) {
+		f.words[bit/64] |= 1 << (bit % 64)
+	}
+
+	return nil
+}
+
+func (f *Filter) containsHash(hash string) bool {
+	for _, bit := range f.positions(hash) {
+		if f.words[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// positions returns the bits of the hash. SHA-1 is uniform already, so its halves serve
+// as the two hashes of enhanced double hashing. Plain double hashing would put the bits
+// of different hashes on the same progressions when the size of the filter is a power of two.
+func (f *Filter) positions(hash string) []uint64 {
+	sum, _ := hex.DecodeString(hash)
+
+	h1 := binary.BigEndian.Uint64(sum[0:8])
+	h2 := binary.BigEndian.Uint64(sum[8:16])
+
+	positions := make([]uint64, f.hashes)
+	for i := range positions {
+		positions[i] = h1 % f.bits
+		h1 += h2
+		h2 += uint64(i)
+	}
+
+	return positions
+}
+
+// walkCorpus calls fn for every breached hash of the corpus at path.
+func walkCorpus(path string, fn func(hash string) error) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		return walkFile(path, "", fn)
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		prefix, ok := strings.CutSuffix(entry.Name(), ".txt")
+		if entry.IsDir() || !ok || len(prefix) != prefixLength {
+			continue
+		}
+
+		if _, err := hex.DecodeString(prefix + "0"); err != nil {
+			continue
+		}
+
+		if err := walkFile(filepath.Join(path, entry.Name()), prefix, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func walkFile(path, prefix string, fn func(hash string) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := scan(file, prefix, fn); err != nil {
+		if errors.Is(err, ErrMalformedCorpus) {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package breach
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// errFound stops scanning a range file once the hash is found.
+var errFound = errors.New("found")
+
+// RangeDir checks passwords against a directory of range files.
+// Only the file of the hash prefix is read, nothing is kept in memory.
+type RangeDir struct {
+	dir string
+}
+
+func (r *RangeDir) IsBreached(password string) (bool, error) {
+	const op = "breach.RangeDir.IsBreached"
+
+	hash := hash(password)
+	prefix := hash[:prefixLength]
+
+	file, err := os.Open(filepath.Join(r.dir, prefix+".txt"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	defer file.Close()
+
+	err = scan(file, prefix, func(breached string) error {
+		if breached == hash {
+			return errFound
+		}
+
+		return nil
+	})
+
+	switch {
+	case errors.Is(err, errFound):
+		return true, nil
+	case err != nil:
+		return false, fmt.Errorf("%s: %w", op, err)
+	default:
+		return false, nil
+	}
+}
@@ -0,0 +1,89 @@
+// Package canonical computes the forms identifiers are compared in,
+// so that names differing only in case, width or look-alike characters are treated as one.
+package canonical
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+//go:embed confusables.txt
+var confusablesFile []byte
+
+// confusables map characters to the prototypes they are confused with.
+var confusables = parseConfusables(confusablesFile)
+
+// Username returns the canonical form of a username: NFKC normalization, case folding
+// and the confusable skeleton of Unicode TR39. Case is folded before the skeleton is taken,
+// so that case variants are always the same account, a capital I then reads as i, not as l.
+// The skeleton is folded once more, since prototypes such as "O" for "0" are upper case.
+// Usernames with equal canonical forms are the same account, the display form is kept separately.
+func Username(username string) string {
+	folder := cases.Fold()
+
+	return folder.String(Skeleton(folder.String(norm.NFKC.String(username))))
+}
+
+// Skeleton returns the TR39 skeleton of s: its NFD with every character replaced
+// by its prototype, normalized to NFD again.
+func Skeleton(s string) string {
+	var b strings.Builder
+
+	for _, r := range norm.NFD.String(s) {
+		if prototype, ok := confusables[r]; ok {
+			b.WriteString(prototype)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+
+	return norm.NFD.String(b.String())
+}
+
+// parseConfusables reads the "source ; target ; type # comment" lines of confusables.txt,
+// source being a code point and target a sequence of them, all in hex.
+func parseConfusables(file []byte) map[rune]string {
+	confusables := make(map[rune]string)
+
+	scanner := bufio.NewScanner(bytes.NewReader(file))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		line = strings.TrimSpace(strings.TrimPrefix(line, "\uFEFF"))
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Split(line, ";")
+		if len(fields) < 2 {
+			continue
+		}
+
+		source, err := strconv.ParseUint(strings.TrimSpace(fields[0]), 16, 32)
+		if err != nil {
+			continue
+		}
+
+		var target strings.Builder
+		for _, code := range strings.Fields(fields[1]) {
+			r, err := strconv.ParseUint(code, 16, 32)
+			if err != nil {
+				target.Reset()
+				break
+			}
+
+			target.WriteRune(rune(r))
+		}
+
+		if target.Len() > 0 {
+			confusables[rune(source)] = target.String()
+		}
+	}
+
+	return confusables
+}
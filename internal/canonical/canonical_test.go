@@ -0,0 +1,63 @@
+package canonical
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsername(t *testing.T) {
+	tests := []struct {
+		nameTest string
+		a        string
+		b        string
+		same     bool
+	}{
+		{nameTest: "Case", a: "Alice", b: "alice", same: true},
+		{nameTest: "Upper case", a: "ALICE", b: "alice", same: true},
+		{nameTest: "Upper case with a confusable", a: "MATVEY", b: "matvey", same: true},
+		{nameTest: "Capital I reads as i", a: "DMITRY", b: "dmitry", same: true},
+		{nameTest: "Capital O for zero", a: "B0B", b: "BOB", same: true},
+		{nameTest: "Zero for o", a: "B0B", b: "bob", same: true},
+		{nameTest: "Full width", a: "\uFF41\uFF4C\uFF49\uFF43\uFF45", b: "alice", same: true},
+		{nameTest: "Cyrillic a", a: "\u0430lice", b: "alice", same: true},
+		{nameTest: "Cyrillic capital A", a: "\u0410lice", b: "alice", same: true},
+		{nameTest: "Greek omicron", a: "b\u03BFb", b: "bob", same: true},
+		{nameTest: "Digit one for l", a: "a1ice", b: "alice", same: true},
+		{nameTest: "Digit zero for o", a: "b0b", b: "bob", same: true},
+		{nameTest: "rn for m", a: "rnatvey", b: "matvey", same: true},
+		{nameTest: "Decomposed accent", a: "jose\u0301", b: "jos\u00E9", same: true},
+		{nameTest: "Different names", a: "alice", b: "alicia"},
+		{nameTest: "Accent matters", a: "jose", b: "jos\u00E9"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nameTest, func(t *testing.T) {
+			if tc.same {
+				assert.Equal(t, Username(tc.a), Username(tc.b))
+			} else {
+				assert.NotEqual(t, Username(tc.a), Username(tc.b))
+			}
+		})
+	}
+}
+
+func TestUsername_Idempotent(t *testing.T) {
+	for _, username := range []string{"Alice", "ALICE", "MatveyTabby", "MATVEY", "b0b", "B0B", "\u0410lice", "jose\u0301"} {
+		assert.Equal(t, Username(username), Username(Username(username)), username)
+	}
+}
+
+func Test_parseConfusables(t *testing.T) {
+	file := []byte("\uFEFF# comment\n" +
+		"\n" +
+		"0430 ;\t0061 ;\tMA\t# ( \u0430 → a ) CYRILLIC SMALL LETTER A → LATIN SMALL LETTER A\n" +
+		"006D ;\t0072 006E ;\tMA\t# ( m → rn )\n" +
+		"zzzz ;\t0061 ;\tMA\n" +
+		"0431 ;\tzzzz ;\tMA\n")
+
+	assert.Equal(t, map[rune]string{
+		'\u0430': "a",
+		'm':      "rn",
+	}, parseConfusables(file))
+}
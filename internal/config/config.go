@@ -8,10 +8,23 @@ import (
 )
 
 type Config struct {
-	Env      string        `yaml:"env"  env-default:"local"`
-	GRPC     GRPCConfig    `yaml:"grpc" env-required:"true"`
-	DBConfig DBConfig      `yaml:"db" env-required:"true"`
-	TokenTTL time.Duration `yaml:"token_ttl" env-default:"1h"`
+	Path            string           `yaml:"-"`
+	Env             string           `yaml:"env"  env-default:"local"`
+	GRPC            GRPCConfig       `yaml:"grpc" env-required:"true"`
+	HTTP            HTTPConfig       `yaml:"http"`
+	DBConfig        DBConfig         `yaml:"db" env-required:"true"`
+	TokenTTL        time.Duration    `yaml:"token_ttl" env-default:"1h"`
+	RefreshTokenTTL time.Duration    `yaml:"refresh_token_ttl"`                 // zero disables refresh tokens, the gRPC API can't return them yet
+	CleanupInterval time.Duration    `yaml:"cleanup_interval" env-default:"1h"` // how often expired refresh and one-time tokens are deleted
+	JWT             JWTConfig        `yaml:"jwt"`
+	Revocation      RevocationConfig `yaml:"revocation"`
+	LoginThrottle   ThrottleConfig   `yaml:"login_throttle"`
+	Password        PasswordConfig   `yaml:"password"`
+	PasswordReset   ResetConfig      `yaml:"password_reset"`
+	Email           EmailConfig      `yaml:"email"`
+	MFA             MFAConfig        `yaml:"mfa"`
+	WebAuthn        WebAuthnConfig   `yaml:"webauthn"`
+	Notifier        NotifierConfig   `yaml:"notifier"`
 }
 
 type GRPCConfig struct {
@@ -19,13 +32,154 @@ type GRPCConfig struct {
 	Timeout time.Duration `yaml:"timeout"`
 }
 
+// JWTConfig describes the issued tokens and the signing keys.
+// New tokens are signed with SigningKey, RetiredKeys only verify tokens issued before a rotation.
+// Audience is the default one, apps may override it. It is also the audience
+// the service itself accepts, tokens issued to other apps are not valid for its own methods.
+type JWTConfig struct {
+	Issuer      string      `yaml:"issuer" env:"JWT_ISSUER" env-default:"auth"`
+	Audience    []string    `yaml:"audience" env:"JWT_AUDIENCE" env-default:"auth"`
+	SigningKey  KeyConfig   `yaml:"signing_key"`
+	RetiredKeys []KeyConfig `yaml:"retired_keys"`
+}
+
+// KeyConfig describes where to take a signing key from.
+// For HS256 File has priority over the inline secret,
+// for RS256, ES256 and EdDSA File must point to a PEM encoded private key.
+// File is read from JWT_KEY_FILE, JWT_SECRET_FILE is its older name and still works when JWT_KEY_FILE is unset.
+type KeyConfig struct {
+	ID        string    `yaml:"id" env:"JWT_KEY_ID"`
+	Algorithm string    `yaml:"algorithm" env:"JWT_ALGORITHM" env-default:"HS256"`
+	Secret    string    `yaml:"secret" env:"JWT_SECRET"`
+	File      string    `yaml:"file" env:"JWT_KEY_FILE,JWT_SECRET_FILE"`
+	NotBefore time.Time `yaml:"not_before"`
+	NotAfter  time.Time `yaml:"not_after"`
+}
+
+// RevocationConfig configures the list of revoked tokens.
+// CacheTTL bounds the delay before a revocation made by another instance is noticed.
+type RevocationConfig struct {
+	CacheTTL        time.Duration `yaml:"cache_ttl" env-default:"10s"`
+	CleanupInterval time.Duration `yaml:"cleanup_interval" env-default:"1h"`
+}
+
+// ThrottleConfig configures throttling of failed logins, counted per username and per client address.
+// The first FreeAttempts failures within Window cost nothing, each next one doubles the delay
+// before another attempt from BaseDelay up to MaxDelay, LockoutThreshold failures lock for LockoutDuration.
+// Store is "memory" for a single instance or "postgres" to share the counters between instances.
+type ThrottleConfig struct {
+	Store            string        `yaml:"store" env-default:"memory"`
+	Window           time.Duration `yaml:"window" env-default:"15m"`
+	FreeAttempts     int           `yaml:"free_attempts" env-default:"3"`
+	BaseDelay        time.Duration `yaml:"base_delay" env-default:"1s"`
+	MaxDelay         time.Duration `yaml:"max_delay" env-default:"1m"`
+	LockoutThreshold int           `yaml:"lockout_threshold" env-default:"10"`
+	LockoutDuration  time.Duration `yaml:"lockout_duration" env-default:"15m"`
+}
+
+// PasswordConfig configures hashing of passwords. New hashes are created with Algorithm,
+// "bcrypt" or "argon2id", stored hashes of another algorithm or with other parameters
+// are replaced on the next successful login.
+type PasswordConfig struct {
+	Algorithm string               `yaml:"algorithm" env-default:"bcrypt"`
+	Bcrypt    BcryptConfig         `yaml:"bcrypt"`
+	Argon2id  Argon2idConfig       `yaml:"argon2id"`
+	Policy    PasswordPolicyConfig `yaml:"policy"`
+	Breach    BreachConfig         `yaml:"breach"`
+}
+
+type BcryptConfig struct {
+	Cost int `yaml:"cost" env-default:"10"`
+}
+
+// Argon2idConfig are the Argon2id parameters, Memory is in KiB.
+type Argon2idConfig struct {
+	Memory      uint32 `yaml:"memory" env-default:"65536"`
+	Iterations  uint32 `yaml:"iterations" env-default:"3"`
+	Parallelism uint8  `yaml:"parallelism" env-default:"2"`
+	SaltLength  uint32 `yaml:"salt_length" env-default:"16"`
+	KeyLength   uint32 `yaml:"key_length" env-default:"32"`
+}
+
+// PasswordPolicyConfig is the set of rules new passwords must satisfy, zero values disable the rules.
+// MaxBytes should stay at most 72 with bcrypt, which ignores the rest of a password.
+// MinCharacterClasses counts lowercase letters, uppercase letters, digits and symbols.
+type PasswordPolicyConfig struct {
+	MinLength           int  `yaml:"min_length" env-default:"8"`
+	MaxLength           int  `yaml:"max_length" env-default:"64"`
+	MaxBytes            int  `yaml:"max_bytes" env-default:"72"`
+	MinCharacterClasses int  `yaml:"min_character_classes"`
+	Denylist            bool `yaml:"denylist" env-default:"true"`
+	ForbidUsername      bool `yaml:"forbid_username" env-default:"true"`
+}
+
+// BreachConfig enables rejecting new passwords found in known breach corpora.
+// Path is a directory of Have I Been Pwned range files or a bloom filter built from them
+// with the breach command.
+type BreachConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Path    string `yaml:"path"`
+}
+
+// ResetConfig configures password resets, TokenTTL is the lifetime of a reset token.
+type ResetConfig struct {
+	TokenTTL time.Duration `yaml:"token_ttl" env-default:"15m"`
+}
+
+// EmailConfig configures verification of user emails, TokenTTL is the lifetime of a verification token.
+// With RequireVerified set users can't log in until their email is verified,
+// so registration requires an email, passed in the x-email metadata of the Register call.
+type EmailConfig struct {
+	TokenTTL        time.Duration `yaml:"token_ttl" env-default:"24h"`
+	RequireVerified bool          `yaml:"require_verified" env-default:"false"`
+}
+
+// MFAConfig configures two-factor authentication.
+// Issuer is the name authenticator apps show next to the codes, TokenTTL is the time a user has
+// to enter the second factor after the password. EncryptionKey is the base64 encoded 32-byte key
+// TOTP secrets are encrypted with at rest.
+type MFAConfig struct {
+	Issuer        string        `yaml:"issuer" env-default:"Auth"`
+	TokenTTL      time.Duration `yaml:"token_ttl" env-default:"5m"`
+	EncryptionKey string        `yaml:"encryption_key" env:"MFA_ENCRYPTION_KEY"`
+}
+
+// WebAuthnConfig configures login with passkeys and security keys.
+// RPID is the domain credentials are bound to, RPOrigins are the origins of the pages allowed
+// to run the ceremonies, Timeout is the time a user has to finish one.
+type WebAuthnConfig struct {
+	Enabled       bool          `yaml:"enabled" env-default:"false"`
+	RPID          string        `yaml:"rp_id"`
+	RPDisplayName string        `yaml:"rp_display_name" env-default:"Auth"`
+	RPOrigins     []string      `yaml:"rp_origins"`
+	Timeout       time.Duration `yaml:"timeout" env-default:"5m"`
+}
+
+// NotifierConfig configures delivery of one-time tokens to users.
+// Type is "log" to write them to the log or "file" to append them to the file at Path,
+// both are meant for local development and tests only.
+type NotifierConfig struct {
+	Type string `yaml:"type" env-default:"log"`
+	Path string `yaml:"path"`
+}
+
+// HTTPConfig configures the optional HTTP server publishing the JWKS document.
+// The server is not started when Port is zero.
+type HTTPConfig struct {
+	Port    int           `yaml:"port"`
+	Timeout time.Duration `yaml:"timeout" env-default:"5s"`
+}
+
+// DBConfig describes the database connection. With MigrateOnStart the service applies
+// pending schema migrations before serving, otherwise they are applied with the migrate command.
 type DBConfig struct {
-	Host     string `yaml:"host"`
-	Port     string `yaml:"port"`
-	Username string `yaml:"username"`
-	Password string `yaml:"password"`
-	DBName   string `yaml:"dbname"`
-	SSLMode  string `yaml:"sslmode"`
+	Host           string `yaml:"host"`
+	Port           string `yaml:"port"`
+	Username       string `yaml:"username"`
+	Password       string `yaml:"password"`
+	DBName         string `yaml:"dbname"`
+	SSLMode        string `yaml:"sslmode"`
+	MigrateOnStart bool   `yaml:"migrate_on_start" env:"DB_MIGRATE_ON_START" env-default:"false"`
 }
 
 func MustLoad() *Config {
@@ -43,13 +197,25 @@ func MustLoadByPath(configPath string) *Config {
 		panic("config path does not exist:" + configPath)
 	}
 
+	cfg, err := Load(configPath)
+	if err != nil {
+		panic("config file is empty:" + configPath)
+	}
+
+	return cfg
+}
+
+// Load reads the config from the file, it is used to reload the config of a running service.
+func Load(configPath string) (*Config, error) {
 	var cfg Config
 
 	if err := cleanenv.ReadConfig(configPath, &cfg); err != nil {
-		panic("config file is empty:" + configPath)
+		return nil, err
 	}
 
-	return &cfg
+	cfg.Path = configPath
+
+	return &cfg, nil
 }
 
 func fetchConfigPath() string {
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// App is a client service that authenticates its users through this one.
+// Empty Secret means tokens are signed with the keys of the service,
+// empty Audience and zero TokenTTL fall back to the service defaults.
+type App struct {
+	ID       int
+	Name     string
+	Secret   []byte
+	Audience []string
+	TokenTTL time.Duration
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// LoginAttempts counts the failed logins under one key, a username or a client address,
+// since the first failure of the current window.
+type LoginAttempts struct {
+	Failures    int
+	LastFailure time.Time
+}
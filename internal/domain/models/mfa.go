@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// TOTP is the two-factor authentication of a user with time-based one-time passwords.
+// Secret is encrypted. Until the user confirms the enrollment with a first code EnabledAt is zero
+// and the secret is not asked for on login. LastUsedStep is the period of the last accepted code,
+// codes of it and earlier periods are rejected.
+type TOTP struct {
+	UserID       int
+	Secret       []byte
+	EnabledAt    time.Time
+	LastUsedStep int64
+}
+
+// TOTPEnrollment is what a user adds to an authenticator app to enable two-factor authentication,
+// the secret in base32 and the same secret as an otpauth:// URI.
+type TOTPEnrollment struct {
+	Secret string
+	URI    string
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Kinds of notifications.
+const (
+	NotificationPasswordReset     = "password_reset"
+	NotificationEmailVerification = "email_verification"
+)
+
+// Notification is a message with a one-time token delivered to a user out of band.
+// Email is the address to deliver to, empty if the user has none.
+type Notification struct {
+	Kind      string
+	UserID    int
+	Username  string
+	Email     string
+	Token     string
+	ExpiresAt time.Time
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// TokenPair is what a client receives after a successful login or refresh.
+// A login of a user with two-factor authentication gets only MFAToken,
+// which is exchanged for the access and refresh tokens together with a second factor.
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	MFAToken     string
+}
+
+// RefreshToken is a stored opaque refresh token. Only the hash of the token is kept.
+// All tokens obtained from one login share the FamilyID, so reuse of a rotated token
+// revokes the whole chain.
+type RefreshToken struct {
+	ID        int64
+	UserID    int
+	AppID     int
+	FamilyID  string
+	TokenHash []byte
+	ExpiresAt time.Time
+	UsedAt    time.Time // zero if the token has not been exchanged yet
+	Revoked   bool
+}
+
+// OneTimeToken is a stored single-use token confirming an action out of band, like a password reset.
+// Only the hash of the token is kept. Purpose keeps the tokens of different actions apart.
+type OneTimeToken struct {
+	ID        int64
+	UserID    int
+	AppID     int // the app a login is finished for, zero for other purposes
+	Purpose   string
+	TokenHash []byte
+	ExpiresAt time.Time
+	UsedAt    time.Time // zero if the token has not been used yet
+}
@@ -1,8 +1,13 @@
 package models
 
+import "time"
+
 type User struct {
-	ID       int
-	Name     string
-	Username string
-	PassHash []byte
+	ID              int
+	Name            string
+	Username        string
+	PassHash        []byte
+	Email           string    // empty if the user has not added one
+	EmailVerifiedAt time.Time // zero until the email is verified
+	TokenVersion    int       // bumped to revoke every token issued to the user
 }
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// WebAuthnCredential is a public key credential, a passkey or a security key, registered by a user.
+// SignCount is the signature counter the authenticator reported last, a counter that doesn't grow
+// means the credential may have been cloned.
+type WebAuthnCredential struct {
+	ID              int64
+	UserID          int
+	CredentialID    []byte
+	PublicKey       []byte
+	AttestationType string
+	AAGUID          []byte
+	SignCount       uint32
+	Transports      []string
+	CreatedAt       time.Time
+	LastUsedAt      time.Time // zero if the credential has not been used to log in yet
+}
+
+// WebAuthnSession is the state of a WebAuthn ceremony kept between its begin and finish steps.
+// Only the hash of the token handed to the client is kept, Data is the encoded challenge and its options.
+type WebAuthnSession struct {
+	UserID    int
+	Purpose   string
+	TokenHash []byte
+	Data      []byte
+	ExpiresAt time.Time
+}
+
+// WebAuthnCeremony is what a client receives to begin a WebAuthn ceremony: the JSON encoded options
+// for navigator.credentials.create or get, and the token to finish the ceremony with.
+type WebAuthnCeremony struct {
+	SessionToken string
+	Options      []byte
+}
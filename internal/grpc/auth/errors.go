@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"auth/internal/grpc/grpcerr"
+	"auth/internal/password"
+	"auth/internal/services/auth"
+	"auth/internal/storage"
+	"context"
+	"errors"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// mfaTokenKey is the ErrorInfo metadata key carrying the MFA token.
+const mfaTokenKey = "mfa_token"
+
+// errorMapping is the status returned for errors matching err.
+type errorMapping struct {
+	err     error
+	code    codes.Code
+	reason  string
+	message string
+}
+
+// errorMappings translate the service and storage errors into statuses.
+// The first mapping the error matches wins, errors matching none are Internal.
+var errorMappings = []errorMapping{
+	{auth.ErrInvalidCredentials, codes.Unauthenticated, grpcerr.ReasonInvalidCredentials, "invalid credentials"},
+	{auth.ErrInvalidMFACode, codes.Unauthenticated, grpcerr.ReasonInvalidMFACode, "invalid two-factor authentication code"},
+	{auth.ErrInvalidMFAToken, codes.Unauthenticated, grpcerr.ReasonInvalidToken, "invalid mfa token"},
+	{auth.ErrInvalidRefreshToken, codes.Unauthenticated, grpcerr.ReasonInvalidToken, "invalid refresh token"},
+	{auth.ErrInvalidResetToken, codes.Unauthenticated, grpcerr.ReasonInvalidToken, "invalid password reset token"},
+	{auth.ErrInvalidVerificationToken, codes.Unauthenticated, grpcerr.ReasonInvalidToken, "invalid email verification token"},
+	{auth.ErrInvalidWebAuthnSession, codes.Unauthenticated, grpcerr.ReasonInvalidToken, "invalid webauthn session"},
+	{auth.ErrRefreshTokenReused, codes.Unauthenticated, grpcerr.ReasonTokenReused, "refresh token reused"},
+	{auth.ErrTokenRevoked, codes.Unauthenticated, grpcerr.ReasonTokenRevoked, "token is revoked"},
+
+	{auth.ErrUserExists, codes.AlreadyExists, grpcerr.ReasonUserExists, "user already exists"},
+	{storage.ErrUserExists, codes.AlreadyExists, grpcerr.ReasonUserExists, "user already exists"},
+	{auth.ErrEmailExists, codes.AlreadyExists, grpcerr.ReasonEmailExists, "email already exists"},
+	{storage.ErrEmailExists, codes.AlreadyExists, grpcerr.ReasonEmailExists, "email already exists"},
+	{auth.ErrWebAuthnCredentialExists, codes.AlreadyExists, grpcerr.ReasonCredentialExists, "webauthn credential already registered"},
+	{storage.ErrCredentialExists, codes.AlreadyExists, grpcerr.ReasonCredentialExists, "webauthn credential already registered"},
+
+	{auth.ErrEmailNotVerified, codes.FailedPrecondition, grpcerr.ReasonEmailNotVerified, "email is not verified"},
+	{auth.ErrMFAEnabled, codes.FailedPrecondition, grpcerr.ReasonMFAEnabled, "two-factor authentication already enabled"},
+	{auth.ErrMFANotEnrolled, codes.FailedPrecondition, grpcerr.ReasonMFANotEnrolled, "two-factor authentication is not enrolled"},
+	{auth.ErrMFAUnavailable, codes.FailedPrecondition, grpcerr.ReasonFeatureDisabled, "two-factor authentication is not available"},
+	{auth.ErrWebAuthnUnavailable, codes.FailedPrecondition, grpcerr.ReasonFeatureDisabled, "webauthn is not available"},
+
+	{auth.ErrUserNotFound, codes.NotFound, grpcerr.ReasonUserNotFound, "user not found"},
+	{storage.ErrUserNotFound, codes.NotFound, grpcerr.ReasonUserNotFound, "user not found"},
+	{auth.ErrRoleNotFound, codes.NotFound, grpcerr.ReasonRoleNotFound, "role not found"},
+	{storage.ErrRoleNotFound, codes.NotFound, grpcerr.ReasonRoleNotFound, "role not found"},
+
+	{auth.ErrInvalidAppID, codes.InvalidArgument, grpcerr.ReasonInvalidAppID, "invalid app id"},
+	{auth.ErrInvalidEmail, codes.InvalidArgument, grpcerr.ReasonInvalidEmail, "invalid email"},
+	{auth.ErrWeakPassword, codes.InvalidArgument, grpcerr.ReasonWeakPassword, "password does not satisfy the policy"},
+	{auth.ErrSamePassword, codes.InvalidArgument, grpcerr.ReasonSamePassword, "new password must differ from the current one"},
+	{auth.ErrInvalidWebAuthnResponse, codes.InvalidArgument, grpcerr.ReasonInvalidWebAuthnResponse, "invalid webauthn response"},
+
+	{auth.ErrWebAuthnCredentialCloned, codes.PermissionDenied, grpcerr.ReasonCredentialCloned, "webauthn credential may be cloned"},
+}
+
+// toStatus translates an error returned by the service into the status sent to the client.
+// The message of an unknown error is not passed on, it may leak internals.
+func toStatus(ctx context.Context, err error) error {
+	var attemptsErr *auth.AttemptsError
+	if errors.As(err, &attemptsErr) {
+		return tooManyAttempts(ctx, attemptsErr)
+	}
+
+	var policyErr *auth.PasswordPolicyError
+	if errors.As(err, &policyErr) {
+		return weakPassword(ctx, policyErr.Violations)
+	}
+
+	for _, m := range errorMappings {
+		if errors.Is(err, m.err) {
+			return grpcerr.New(ctx, m.code, m.reason, m.message, nil)
+		}
+	}
+
+	return grpcerr.New(ctx, codes.Internal, grpcerr.ReasonInternal, "internal server error", nil)
+}
+
+// invalidArgument builds the InvalidArgument status of a request that failed validation,
+// listing the violations of a validationError as field violations.
+func invalidArgument(ctx context.Context, err error) error {
+	var validationErr *validationError
+	if !errors.As(err, &validationErr) {
+		return grpcerr.New(ctx, codes.InvalidArgument, grpcerr.ReasonInvalidArgument, err.Error(), nil)
+	}
+
+	return grpcerr.New(ctx, codes.InvalidArgument, grpcerr.ReasonInvalidArgument, err.Error(), nil,
+		&errdetails.BadRequest{FieldViolations: validationErr.violations},
+	)
+}
+
+// tooManyAttempts builds the status telling the client when to retry the login.
+// A locked account is FailedPrecondition, retrying right away won't help;
+// a throttled one is ResourceExhausted.
+func tooManyAttempts(ctx context.Context, attemptsErr *auth.AttemptsError) error {
+	// Round up, a client retrying a bit early is throttled again.
+	retryAfter := attemptsErr.RetryAfter.Truncate(time.Second) + time.Second
+	retryInfo := &errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)}
+
+	if attemptsErr.Locked {
+		return grpcerr.New(ctx, codes.FailedPrecondition, grpcerr.ReasonAccountLocked, "account is locked", nil, retryInfo)
+	}
+
+	return grpcerr.New(ctx, codes.ResourceExhausted, grpcerr.ReasonTooManyAttempts, "too many login attempts", nil, retryInfo)
+}
+
+// mfaRequired builds the Unauthenticated status handing the client the MFA token
+// to finish the login with a second factor. LoginResponse has no field for the token yet,
+// the VerifyMFA RPC exchanging it follows once the proto contract defines it.
+func mfaRequired(ctx context.Context, mfaToken string) error {
+	return grpcerr.New(ctx, codes.Unauthenticated, grpcerr.ReasonMFARequired, "second factor required",
+		map[string]string{mfaTokenKey: mfaToken},
+	)
+}
+
+// weakPassword builds the InvalidArgument status listing the broken password rules as field violations.
+func weakPassword(ctx context.Context, violations []password.Violation) error {
+	badRequest := &errdetails.BadRequest{}
+	for _, violation := range violations {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       passwordField,
+			Description: violation.Description,
+		})
+	}
+
+	return grpcerr.New(ctx, codes.InvalidArgument, grpcerr.ReasonWeakPassword, "password does not satisfy the policy", nil, badRequest)
+}
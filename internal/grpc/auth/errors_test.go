@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"auth/internal/grpc/grpcerr"
+	"auth/internal/grpc/requestid"
+	"auth/internal/password"
+	"auth/internal/services/auth"
+	"auth/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func Test_toStatus(t *testing.T) {
+	tests := []struct {
+		nameTest        string
+		err             error
+		expectedCode    codes.Code
+		expectedReason  string
+		expectedMessage string
+	}{
+		{
+			nameTest:        "Invalid credentials",
+			err:             fmt.Errorf("auth.Login: %w", auth.ErrInvalidCredentials),
+			expectedCode:    codes.Unauthenticated,
+			expectedReason:  grpcerr.ReasonInvalidCredentials,
+			expectedMessage: "invalid credentials",
+		},
+		{
+			nameTest:        "Refresh token reused",
+			err:             fmt.Errorf("auth.Refresh: %w", auth.ErrRefreshTokenReused),
+			expectedCode:    codes.Unauthenticated,
+			expectedReason:  grpcerr.ReasonTokenReused,
+			expectedMessage: "refresh token reused",
+		},
+		{
+			nameTest:        "User exists",
+			err:             auth.ErrUserExists,
+			expectedCode:    codes.AlreadyExists,
+			expectedReason:  grpcerr.ReasonUserExists,
+			expectedMessage: "user already exists",
+		},
+		{
+			nameTest:        "Email exists in storage",
+			err:             fmt.Errorf("storage.postgres.SetEmail: %w", storage.ErrEmailExists),
+			expectedCode:    codes.AlreadyExists,
+			expectedReason:  grpcerr.ReasonEmailExists,
+			expectedMessage: "email already exists",
+		},
+		{
+			nameTest:        "Too many attempts",
+			err:             &auth.AttemptsError{RetryAfter: time.Minute},
+			expectedCode:    codes.ResourceExhausted,
+			expectedReason:  grpcerr.ReasonTooManyAttempts,
+			expectedMessage: "too many login attempts",
+		},
+		{
+			nameTest:        "Account locked",
+			err:             &auth.AttemptsError{RetryAfter: time.Minute, Locked: true},
+			expectedCode:    codes.FailedPrecondition,
+			expectedReason:  grpcerr.ReasonAccountLocked,
+			expectedMessage: "account is locked",
+		},
+		{
+			nameTest:        "Email not verified",
+			err:             auth.ErrEmailNotVerified,
+			expectedCode:    codes.FailedPrecondition,
+			expectedReason:  grpcerr.ReasonEmailNotVerified,
+			expectedMessage: "email is not verified",
+		},
+		{
+			nameTest:        "MFA unavailable",
+			err:             auth.ErrMFAUnavailable,
+			expectedCode:    codes.FailedPrecondition,
+			expectedReason:  grpcerr.ReasonFeatureDisabled,
+			expectedMessage: "two-factor authentication is not available",
+		},
+		{
+			nameTest:        "User not found",
+			err:             storage.ErrUserNotFound,
+			expectedCode:    codes.NotFound,
+			expectedReason:  grpcerr.ReasonUserNotFound,
+			expectedMessage: "user not found",
+		},
+		{
+			nameTest:        "Invalid app id",
+			err:             auth.ErrInvalidAppID,
+			expectedCode:    codes.InvalidArgument,
+			expectedReason:  grpcerr.ReasonInvalidAppID,
+			expectedMessage: "invalid app id",
+		},
+		{
+			nameTest: "Weak password",
+			err: &auth.PasswordPolicyError{Violations: []password.Violation{
+				{Rule: password.RuleMinLength, Description: "password must be at least 8 characters long"},
+			}},
+			expectedCode:    codes.InvalidArgument,
+			expectedReason:  grpcerr.ReasonWeakPassword,
+			expectedMessage: "password does not satisfy the policy",
+		},
+		{
+			nameTest:        "Cloned credential",
+			err:             auth.ErrWebAuthnCredentialCloned,
+			expectedCode:    codes.PermissionDenied,
+			expectedReason:  grpcerr.ReasonCredentialCloned,
+			expectedMessage: "webauthn credential may be cloned",
+		},
+		{
+			nameTest:        "Unknown error",
+			err:             errors.New("pq: connection refused"),
+			expectedCode:    codes.Internal,
+			expectedReason:  grpcerr.ReasonInternal,
+			expectedMessage: "internal server error",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nameTest, func(t *testing.T) {
+			err := toStatus(context.Background(), tc.err)
+
+			st := status.Convert(err)
+			assert.Equal(t, tc.expectedCode, st.Code())
+			assert.Equal(t, tc.expectedMessage, st.Message())
+			assert.Equal(t, tc.expectedReason, grpcerr.Reason(err))
+		})
+	}
+}
+
+func Test_toStatus_RequestID(t *testing.T) {
+	ctx := requestid.NewContext(context.Background(), "req-1")
+
+	st := status.Convert(toStatus(ctx, auth.ErrInvalidCredentials))
+
+	var requestInfo *errdetails.RequestInfo
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RequestInfo); ok {
+			requestInfo = info
+		}
+	}
+
+	if assert.NotNil(t, requestInfo) {
+		assert.Equal(t, "req-1", requestInfo.GetRequestId())
+	}
+}
+
+func Test_tooManyAttempts(t *testing.T) {
+	st := status.Convert(tooManyAttempts(context.Background(), &auth.AttemptsError{RetryAfter: 1500 * time.Millisecond}))
+
+	assert.Equal(t, codes.ResourceExhausted, st.Code())
+
+	if assert.Len(t, st.Details(), 2) {
+		retryInfo, ok := st.Details()[1].(*errdetails.RetryInfo)
+		assert.True(t, ok)
+		assert.Equal(t, 2*time.Second, retryInfo.GetRetryDelay().AsDuration())
+	}
+}
+
+func Test_mfaRequired(t *testing.T) {
+	st := status.Convert(mfaRequired(context.Background(), "mfa-token"))
+
+	assert.Equal(t, codes.Unauthenticated, st.Code())
+
+	if assert.Len(t, st.Details(), 1) {
+		info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+		assert.True(t, ok)
+		assert.Equal(t, "MFA_REQUIRED", info.GetReason())
+		assert.Equal(t, "auth", info.GetDomain())
+		assert.Equal(t, "mfa-token", info.GetMetadata()["mfa_token"])
+	}
+}
+
+func Test_weakPassword(t *testing.T) {
+	st := status.Convert(weakPassword(context.Background(), []password.Violation{
+		{Rule: password.RuleMinLength, Description: "password must be at least 8 characters long"},
+		{Rule: password.RuleCommonPassword, Description: "password is too common"},
+	}))
+
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+
+	if assert.Len(t, st.Details(), 2) {
+		badRequest, ok := st.Details()[1].(*errdetails.BadRequest)
+		assert.True(t, ok)
+
+		if assert.Len(t, badRequest.GetFieldViolations(), 2) {
+			assert.Equal(t, "password", badRequest.GetFieldViolations()[0].GetField())
+			assert.Equal(t, "password is too common", badRequest.GetFieldViolations()[1].GetDescription())
+		}
+	}
+}
@@ -1,8 +1,9 @@
-// Code generated by mockery v2.45.1. DO NOT EDIT.
+// Code generated by mockery v2.53.5. DO NOT EDIT.
 
 package mocks
 
 import (
+	models "auth/internal/domain/models"
 	context "context"
 
 	mock "github.com/stretchr/testify/mock"
@@ -21,27 +22,27 @@ func (_m *Auth) EXPECT() *Auth_Expecter {
 	return &Auth_Expecter{mock: &_m.Mock}
 }
 
-// Login provides a mock function with given fields: ctx, username, password
-func (_m *Auth) Login(ctx context.Context, username string, password string) (string, error) {
-	ret := _m.Called(ctx, username, password)
+// Login provides a mock function with given fields: ctx, username, password, appID, clientIP
+func (_m *Auth) Login(ctx context.Context, username string, password string, appID int, clientIP string) (models.TokenPair, error) {
+	ret := _m.Called(ctx, username, password, appID, clientIP)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Login")
 	}
 
-	var r0 string
+	var r0 models.TokenPair
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) (string, error)); ok {
-		return rf(ctx, username, password)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int, string) (models.TokenPair, error)); ok {
+		return rf(ctx, username, password, appID, clientIP)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
-		r0 = rf(ctx, username, password)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int, string) models.TokenPair); ok {
+		r0 = rf(ctx, username, password, appID, clientIP)
 	} else {
-		r0 = ret.Get(0).(string)
+		r0 = ret.Get(0).(models.TokenPair)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
-		r1 = rf(ctx, username, password)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, int, string) error); ok {
+		r1 = rf(ctx, username, password, appID, clientIP)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -58,30 +59,32 @@ type Auth_Login_Call struct {
 //   - ctx context.Context
 //   - username string
 //   - password string
-func (_e *Auth_Expecter) Login(ctx interface{}, username interface{}, password interface{}) *Auth_Login_Call {
-	return &Auth_Login_Call{Call: _e.mock.On("Login", ctx, username, password)}
+//   - appID int
+//   - clientIP string
+func (_e *Auth_Expecter) Login(ctx interface{}, username interface{}, password interface{}, appID interface{}, clientIP interface{}) *Auth_Login_Call {
+	return &Auth_Login_Call{Call: _e.mock.On("Login", ctx, username, password, appID, clientIP)}
 }
 
-func (_c *Auth_Login_Call) Run(run func(ctx context.Context, username string, password string)) *Auth_Login_Call {
+func (_c *Auth_Login_Call) Run(run func(ctx context.Context, username string, password string, appID int, clientIP string)) *Auth_Login_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(string), args[2].(string))
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(int), args[4].(string))
 	})
 	return _c
 }
 
-func (_c *Auth_Login_Call) Return(token string, err error) *Auth_Login_Call {
-	_c.Call.Return(token, err)
+func (_c *Auth_Login_Call) Return(tokens models.TokenPair, err error) *Auth_Login_Call {
+	_c.Call.Return(tokens, err)
 	return _c
 }
 
-func (_c *Auth_Login_Call) RunAndReturn(run func(context.Context, string, string) (string, error)) *Auth_Login_Call {
+func (_c *Auth_Login_Call) RunAndReturn(run func(context.Context, string, string, int, string) (models.TokenPair, error)) *Auth_Login_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// RegisterNewUser provides a mock function with given fields: ctx, name, username, password
-func (_m *Auth) RegisterNewUser(ctx context.Context, name string, username string, password string) (int, error) {
-	ret := _m.Called(ctx, name, username, password)
+// RegisterNewUser provides a mock function with given fields: ctx, name, username, email, password
+func (_m *Auth) RegisterNewUser(ctx context.Context, name string, username string, email string, password string) (int, error) {
+	ret := _m.Called(ctx, name, username, email, password)
 
 	if len(ret) == 0 {
 		panic("no return value specified for RegisterNewUser")
@@ -89,17 +92,17 @@ func (_m *Auth) RegisterNewUser(ctx context.Context, name string, username strin
 
 	var r0 int
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (int, error)); ok {
-		return rf(ctx, name, username, password)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string) (int, error)); ok {
+		return rf(ctx, name, username, email, password)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) int); ok {
-		r0 = rf(ctx, name, username, password)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string) int); ok {
+		r0 = rf(ctx, name, username, email, password)
 	} else {
 		r0 = ret.Get(0).(int)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
-		r1 = rf(ctx, name, username, password)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, string) error); ok {
+		r1 = rf(ctx, name, username, email, password)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -116,14 +119,15 @@ type Auth_RegisterNewUser_Call struct {
 //   - ctx context.Context
 //   - name string
 //   - username string
+//   - email string
 //   - password string
-func (_e *Auth_Expecter) RegisterNewUser(ctx interface{}, name interface{}, username interface{}, password interface{}) *Auth_RegisterNewUser_Call {
-	return &Auth_RegisterNewUser_Call{Call: _e.mock.On("RegisterNewUser", ctx, name, username, password)}
+func (_e *Auth_Expecter) RegisterNewUser(ctx interface{}, name interface{}, username interface{}, email interface{}, password interface{}) *Auth_RegisterNewUser_Call {
+	return &Auth_RegisterNewUser_Call{Call: _e.mock.On("RegisterNewUser", ctx, name, username, email, password)}
 }
 
-func (_c *Auth_RegisterNewUser_Call) Run(run func(ctx context.Context, name string, username string, password string)) *Auth_RegisterNewUser_Call {
+func (_c *Auth_RegisterNewUser_Call) Run(run func(ctx context.Context, name string, username string, email string, password string)) *Auth_RegisterNewUser_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(string))
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(string), args[4].(string))
 	})
 	return _c
 }
@@ -133,7 +137,7 @@ func (_c *Auth_RegisterNewUser_Call) Return(userID int, err error) *Auth_Registe
 	return _c
 }
 
-func (_c *Auth_RegisterNewUser_Call) RunAndReturn(run func(context.Context, string, string, string) (int, error)) *Auth_RegisterNewUser_Call {
+func (_c *Auth_RegisterNewUser_Call) RunAndReturn(run func(context.Context, string, string, string, string) (int, error)) *Auth_RegisterNewUser_Call {
 	_c.Call.Return(run)
 	return _c
 }
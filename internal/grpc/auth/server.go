@@ -1,13 +1,29 @@
 package auth
 
 import (
+	"auth/internal/domain/models"
+	"auth/internal/grpc/authz"
 	"auth/internal/services/auth"
 	"context"
-	"errors"
 	authv1 "github.com/3XBAT/protos/gen/go"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"net"
+	"strconv"
+)
+
+const (
+	// appIDHeader is the metadata key clients pass their app ID in,
+	// LoginRequest has no field for it yet.
+	appIDHeader = "x-app-id"
+	// emailHeader is the metadata key clients pass the email of a new user in,
+	// RegisterRequest has no field for it yet.
+	emailHeader = "x-email"
+	// defaultAppID is the app ID of clients that don't specify one.
+	defaultAppID = 0
+	// passwordField is the name of the password field in requests, used in field violations.
+	passwordField = "password"
 )
 
 // serverAPI is a structure that handles all incoming requests
@@ -16,20 +32,34 @@ type serverAPI struct {
 	auth Auth
 }
 
+// The embedded UnimplementedAuthServer satisfies the interface on its own, so this only catches
+// signature mismatches. Test_serverAPI_implementsAuthServer checks that no RPC falls through to it.
+var _ authv1.AuthServer = (*serverAPI)(nil)
+
 //go:generate go run github.com/vektra/mockery/v2@latest --name=Auth --with-expecter=true
 type Auth interface {
 	Login(ctx context.Context,
 		username string,
 		password string,
-	) (token string, err error)
+		appID int,
+		clientIP string,
+	) (tokens models.TokenPair, err error)
 
 	RegisterNewUser(ctx context.Context,
 		name string,
 		username string,
+		email string,
 		password string,
 	) (userID int, err error)
 }
 
+// Policies declare who may call each RPC of the Auth service.
+// Every RPC must be listed here, the authorization interceptor denies the rest.
+var Policies = authz.Policies{
+	"/auth.Auth/Register": authz.Public(),
+	"/auth.Auth/Login":    authz.Public(),
+}
+
 func Register(gRPC *grpc.Server, auth Auth) {
 	authv1.RegisterAuthServer(gRPC, &serverAPI{auth: auth})
 }
@@ -39,37 +69,38 @@ func (s *serverAPI) Login(ctx context.Context,
 ) (*authv1.LoginResponse, error) {
 
 	if err := validateLogin(in); err != nil {
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		return nil, invalidArgument(ctx, err)
 	}
 
-	token, err := s.auth.Login(ctx, in.GetUsername(), in.GetPassword())
+	appID, err := appIDFromContext(ctx)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
 
+	tokens, err := s.auth.Login(ctx, in.GetUsername(), in.GetPassword(), appID, clientIPFromContext(ctx))
 	if err != nil {
-		if errors.Is(err, auth.ErrInvalidCredentials) {
-			return nil, status.Error(codes.NotFound, "user not found")
-		}
+		return nil, toStatus(ctx, err)
+	}
 
-		return nil, status.Error(codes.Internal, "internal server error")
+	if tokens.MFAToken != "" {
+		return nil, mfaRequired(ctx, tokens.MFAToken)
 	}
+	// LoginResponse has no field for the refresh token yet, it is delivered once the proto contract gets one.
 	return &authv1.LoginResponse{
-		Token: token,
+		Token: tokens.AccessToken,
 	}, nil
 }
 
-func (s *serverAPI) RegisterNewUser(ctx context.Context,
+func (s *serverAPI) Register(ctx context.Context,
 	in *authv1.RegisterRequest,
 ) (*authv1.RegisterResponse, error) {
 	if err := validateRegister(in); err != nil {
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		return nil, invalidArgument(ctx, err)
 	}
 
-	userID, err := s.auth.RegisterNewUser(ctx, in.GetName(), in.GetUsername(), in.GetPassword())
+	userID, err := s.auth.RegisterNewUser(ctx, in.GetName(), in.GetUsername(), emailFromContext(ctx), in.GetPassword())
 	if err != nil {
-		if errors.Is(err, auth.ErrUserExists) {
-			return nil, status.Error(codes.AlreadyExists, "user already exists")
-		}
-
-		return nil, status.Error(codes.Internal, "internal server error")
+		return nil, toStatus(ctx, err)
 	}
 
 	return &authv1.RegisterResponse{
@@ -77,31 +108,52 @@ func (s *serverAPI) RegisterNewUser(ctx context.Context,
 	}, nil
 }
 
-func validateLogin(in *authv1.LoginRequest) error {
-	if in.GetPassword() == "" {
-		return status.Error(codes.InvalidArgument, "password is empty")
+// appIDFromContext returns the app ID passed in the request metadata, defaultAppID if there is none.
+func appIDFromContext(ctx context.Context) (int, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return defaultAppID, nil
+	}
+
+	values := md.Get(appIDHeader)
+	if len(values) == 0 {
+		return defaultAppID, nil
 	}
 
-	if in.GetUsername() == "" {
-		return status.Error(codes.InvalidArgument, "username is empty")
+	appID, err := strconv.Atoi(values[0])
+	if err != nil || appID <= 0 {
+		return 0, auth.ErrInvalidAppID
 	}
 
-	return nil
+	return appID, nil
 }
 
-func validateRegister(in *authv1.RegisterRequest) error {
+// emailFromContext returns the email passed in the request metadata, empty if there is none.
+func emailFromContext(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
 
-	if in.GetUsername() == "" {
-		return status.Error(codes.InvalidArgument, "username is empty")
+	values := md.Get(emailHeader)
+	if len(values) == 0 {
+		return ""
 	}
 
-	if in.GetPassword() == "" {
-		return status.Error(codes.InvalidArgument, "password is empty")
+	return values[0]
+}
+
+// clientIPFromContext returns the address of the peer the request came from, empty if it is unknown.
+func clientIPFromContext(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
 	}
 
-	if in.GetName() == "" {
-		return status.Error(codes.InvalidArgument, "name is empty")
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
 	}
 
-	return nil
+	return host
 }
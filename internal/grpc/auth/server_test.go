@@ -1,17 +1,50 @@
 package auth
 
 import (
+	"auth/internal/domain/models"
+	"auth/internal/password"
 	"auth/internal/services/auth"
 	"context"
 	"fmt"
+	"net"
+	"reflect"
 	"testing"
+	"time"
 
 	"auth/internal/grpc/auth/mocks"
 	authv1 "github.com/3XBAT/protos/gen/go"
 
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
 )
 
+// Test_serverAPI_implementsAuthServer calls every RPC of the generated AuthServer with an empty request
+// and fails if the embedded UnimplementedAuthServer answers it, as it does when a handler is missing
+// or named differently from the generated method.
+func Test_serverAPI_implementsAuthServer(t *testing.T) {
+	server := reflect.ValueOf(&serverAPI{auth: mocks.NewAuth(t)})
+	rpcs := reflect.TypeOf((*authv1.AuthServer)(nil)).Elem()
+
+	for i := 0; i < rpcs.NumMethod(); i++ {
+		rpc := rpcs.Method(i)
+		if !rpc.IsExported() {
+			continue
+		}
+
+		t.Run(rpc.Name, func(t *testing.T) {
+			in := reflect.New(rpc.Type.In(1).Elem())
+
+			out := server.MethodByName(rpc.Name).Call([]reflect.Value{reflect.ValueOf(context.Background()), in})
+
+			err, _ := out[1].Interface().(error)
+			assert.NotEqual(t, codes.Unimplemented, status.Code(err), "%s is served by the Unimplemented stub", rpc.Name)
+		})
+	}
+}
+
 func Test_serverAPI_Register(t *testing.T) {
 	//ErrMock := errors.New("mock error")
 	ctx := context.Background()
@@ -34,7 +67,7 @@ func Test_serverAPI_Register(t *testing.T) {
 			mockService: func(name, username, password string) Auth {
 				s := mocks.NewAuth(t)
 				s.EXPECT().
-					RegisterNewUser(ctx, name, username, password).
+					RegisterNewUser(ctx, name, username, "", password).
 					Return(1, nil).Once()
 				return s
 			},
@@ -118,13 +151,32 @@ func Test_serverAPI_Register(t *testing.T) {
 			mockService: func(name, username, password string) Auth {
 				s := mocks.NewAuth(t)
 
-				s.EXPECT().RegisterNewUser(ctx, name, username, password).
+				s.EXPECT().RegisterNewUser(ctx, name, username, "", password).
 					Return(0, auth.ErrUserExists)
 				return s
 			},
 			expectedResp: nil,
 			expectedErr:  fmt.Errorf("user already exists"),
 		},
+		{
+			nameTest: "Weak password",
+			in: &authv1.RegisterRequest{
+				Name:     "Matvey Tabby",
+				Username: "JohnTravolta",
+				Password: "a1b2c5",
+			},
+			mockService: func(name, username, pass string) Auth {
+				s := mocks.NewAuth(t)
+
+				s.EXPECT().RegisterNewUser(ctx, name, username, "", pass).
+					Return(0, &auth.PasswordPolicyError{Violations: []password.Violation{
+						{Rule: password.RuleMinLength, Description: "password must be at least 8 characters long"},
+					}})
+				return s
+			},
+			expectedResp: nil,
+			expectedErr:  fmt.Errorf("password does not satisfy the policy"),
+		},
 	}
 	for _, tc := range tests {
 		t.Run(tc.nameTest, func(t *testing.T) {
@@ -132,7 +184,7 @@ func Test_serverAPI_Register(t *testing.T) {
 				auth: tc.mockService(tc.in.GetName(), tc.in.GetUsername(), tc.in.GetPassword()),
 			}
 
-			resp, err := s.RegisterNewUser(ctx, tc.in)
+			resp, err := s.Register(ctx, tc.in)
 
 			if err != nil {
 				assert.Equal(t, tc.expectedResp, resp)
@@ -164,8 +216,8 @@ func Test_serverAPI_Login(t *testing.T) {
 			mockService: func(username, password string) Auth {
 				s := mocks.NewAuth(t)
 				s.EXPECT().
-					Login(ctx, username, password).
-					Return("generatedToken", nil).Once()
+					Login(ctx, username, password, defaultAppID, "").
+					Return(models.TokenPair{AccessToken: "generatedToken"}, nil).Once()
 
 				return s
 			},
@@ -207,13 +259,77 @@ func Test_serverAPI_Login(t *testing.T) {
 			mockService: func(username, password string) Auth {
 				s := mocks.NewAuth(t)
 				s.EXPECT().
-					Login(ctx, username, password).
-					Return("", auth.ErrInvalidCredentials).Once()
+					Login(ctx, username, password, defaultAppID, "").
+					Return(models.TokenPair{}, auth.ErrInvalidCredentials).Once()
 
 				return s
 			},
 			expectedResp:   nil,
-			expectedErrStr: "user not found",
+			expectedErrStr: "invalid credentials",
+		},
+		{
+			nameTest: "Unknown app",
+			in: &authv1.LoginRequest{
+				Username: "MatveyTabby",
+				Password: "OOP",
+			},
+			mockService: func(username, password string) Auth {
+				s := mocks.NewAuth(t)
+				s.EXPECT().
+					Login(ctx, username, password, defaultAppID, "").
+					Return(models.TokenPair{}, auth.ErrInvalidAppID).Once()
+				return s
+			},
+			expectedResp:   nil,
+			expectedErrStr: "invalid app id",
+		},
+		{
+			nameTest: "Too many attempts",
+			in: &authv1.LoginRequest{
+				Username: "MatveyTabby",
+				Password: "OOP",
+			},
+			mockService: func(username, password string) Auth {
+				s := mocks.NewAuth(t)
+				s.EXPECT().
+					Login(ctx, username, password, defaultAppID, "").
+					Return(models.TokenPair{}, &auth.AttemptsError{RetryAfter: time.Minute}).Once()
+				return s
+			},
+			expectedResp:   nil,
+			expectedErrStr: "too many login attempts",
+		},
+		{
+			nameTest: "Email not verified",
+			in: &authv1.LoginRequest{
+				Username: "MatveyTabby",
+				Password: "OOP",
+			},
+			mockService: func(username, password string) Auth {
+				s := mocks.NewAuth(t)
+				s.EXPECT().
+					Login(ctx, username, password, defaultAppID, "").
+					Return(models.TokenPair{}, auth.ErrEmailNotVerified).Once()
+				return s
+			},
+			expectedResp:   nil,
+			expectedErrStr: "email is not verified",
+		},
+		{
+			nameTest: "Second factor required",
+			in: &authv1.LoginRequest{
+				Username: "MatveyTabby",
+				Password: "OOP",
+			},
+			mockService: func(username, password string) Auth {
+				s := mocks.NewAuth(t)
+				s.EXPECT().
+					Login(ctx, username, password, defaultAppID, "").
+					Return(models.TokenPair{MFAToken: "mfa-token"}, nil).Once()
+				return s
+			},
+			expectedResp:   nil,
+			expectedErrStr: "second factor required",
 		},
 		{
 			nameTest: "another error during Login",
@@ -224,8 +340,8 @@ func Test_serverAPI_Login(t *testing.T) {
 			mockService: func(username, password string) Auth {
 				s := mocks.NewAuth(t)
 				s.EXPECT().
-					Login(ctx, username, password).
-					Return("", fmt.Errorf("internal server error")).Once()
+					Login(ctx, username, password, defaultAppID, "").
+					Return(models.TokenPair{}, fmt.Errorf("internal server error")).Once()
 				return s
 			},
 			expectedResp:   nil,
@@ -251,3 +367,105 @@ func Test_serverAPI_Login(t *testing.T) {
 		})
 	}
 }
+
+func Test_clientIPFromContext(t *testing.T) {
+	tests := []struct {
+		nameTest   string
+		ctx        context.Context
+		expectedIP string
+	}{
+		{
+			nameTest: "No peer",
+			ctx:      context.Background(),
+		},
+		{
+			nameTest:   "TCP peer",
+			ctx:        peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 5000}}),
+			expectedIP: "10.0.0.1",
+		},
+		{
+			nameTest:   "IPv6 peer",
+			ctx:        peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("::1"), Port: 5000}}),
+			expectedIP: "::1",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nameTest, func(t *testing.T) {
+			assert.Equal(t, tc.expectedIP, clientIPFromContext(tc.ctx))
+		})
+	}
+}
+
+func Test_appIDFromContext(t *testing.T) {
+	tests := []struct {
+		nameTest    string
+		ctx         context.Context
+		expectedID  int
+		expectedErr bool
+	}{
+		{
+			nameTest:   "No metadata",
+			ctx:        context.Background(),
+			expectedID: defaultAppID,
+		},
+		{
+			nameTest:   "App ID passed",
+			ctx:        metadata.NewIncomingContext(context.Background(), metadata.Pairs(appIDHeader, "2")),
+			expectedID: 2,
+		},
+		{
+			nameTest:    "Not a number",
+			ctx:         metadata.NewIncomingContext(context.Background(), metadata.Pairs(appIDHeader, "shop")),
+			expectedErr: true,
+		},
+		{
+			nameTest:    "Negative",
+			ctx:         metadata.NewIncomingContext(context.Background(), metadata.Pairs(appIDHeader, "-1")),
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nameTest, func(t *testing.T) {
+			appID, err := appIDFromContext(tc.ctx)
+
+			if tc.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedID, appID)
+			}
+		})
+	}
+}
+
+func Test_Policies_CoverEveryMethod(t *testing.T) {
+	for _, method := range authv1.Auth_ServiceDesc.Methods {
+		fullMethod := "/" + authv1.Auth_ServiceDesc.ServiceName + "/" + method.MethodName
+
+		_, ok := Policies[fullMethod]
+		assert.Truef(t, ok, "%s has no access policy", fullMethod)
+	}
+
+	for _, stream := range authv1.Auth_ServiceDesc.Streams {
+		fullMethod := "/" + authv1.Auth_ServiceDesc.ServiceName + "/" + stream.StreamName
+
+		_, ok := Policies[fullMethod]
+		assert.Truef(t, ok, "%s has no access policy", fullMethod)
+	}
+}
+
+func Test_serverAPI_Register_Email(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(emailHeader, "matvey@example.com"))
+
+	service := mocks.NewAuth(t)
+	service.EXPECT().RegisterNewUser(ctx, "Matvey", "MatveyTabby", "matvey@example.com", "correct horse").Return(1, nil).Once()
+
+	s := &serverAPI{auth: service}
+
+	resp, err := s.Register(ctx, &authv1.RegisterRequest{Name: "Matvey", Username: "MatveyTabby", Password: "correct horse"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, &authv1.RegisterResponse{UserId: 1}, resp)
+}
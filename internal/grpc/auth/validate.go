@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	authv1 "github.com/3XBAT/protos/gen/go"
+	"golang.org/x/text/unicode/norm"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+// Names of the request fields, used in field violations.
+const (
+	nameField     = "name"
+	usernameField = "username"
+)
+
+// Limits of the request fields, in characters unless said otherwise.
+const (
+	minUsernameLength = 3
+	maxUsernameLength = 32
+	// maxLoginLength admits logging in with an email address instead of the username.
+	maxLoginLength = 254
+	maxNameLength  = 64
+	// maxPasswordBytes only bounds the work of hashing, the password policy limits new passwords further.
+	maxPasswordBytes = 1024
+)
+
+// usernamePunctuation are the characters a username may have besides letters and digits.
+const usernamePunctuation = "._-"
+
+// validationError lists every field of a request that failed validation.
+type validationError struct {
+	violations []*errdetails.BadRequest_FieldViolation
+}
+
+func (e *validationError) Error() string {
+	descriptions := make([]string, 0, len(e.violations))
+	for _, violation := range e.violations {
+		descriptions = append(descriptions, violation.GetDescription())
+	}
+
+	return strings.Join(descriptions, "; ")
+}
+
+// validator collects the violations of a request, so the client learns about all of them at once.
+type validator struct {
+	violations []*errdetails.BadRequest_FieldViolation
+}
+
+func (v *validator) add(field string, format string, args ...any) {
+	v.violations = append(v.violations, &errdetails.BadRequest_FieldViolation{
+		Field:       field,
+		Description: fmt.Sprintf(format, args...),
+	})
+}
+
+// err returns the collected violations as a validationError, nil if there are none.
+func (v *validator) err() error {
+	if len(v.violations) == 0 {
+		return nil
+	}
+
+	return &validationError{violations: v.violations}
+}
+
+// required reports whether the field is set, adding a violation if it is not.
+func (v *validator) required(field, value string) bool {
+	if value == "" {
+		v.add(field, "%s is empty", field)
+		return false
+	}
+
+	return true
+}
+
+// text checks the rules shared by text fields: no surrounding whitespace, no control characters,
+// NFC normalization and a length of at most maxLength characters.
+func (v *validator) text(field, value string, maxLength int) {
+	if strings.TrimSpace(value) != value {
+		v.add(field, "%s must not start or end with whitespace", field)
+	}
+
+	if strings.IndexFunc(value, unicode.IsControl) >= 0 {
+		v.add(field, "%s must not contain control characters", field)
+	}
+
+	if !norm.NFC.IsNormalString(value) {
+		v.add(field, "%s must be in Unicode normalization form C", field)
+	}
+
+	if utf8.RuneCountInString(value) > maxLength {
+		v.add(field, "%s must be at most %d characters long", field, maxLength)
+	}
+}
+
+// username checks a new username: letters, digits and usernamePunctuation,
+// between minUsernameLength and maxUsernameLength characters.
+func (v *validator) username(value string) {
+	if !v.required(usernameField, value) {
+		return
+	}
+
+	v.text(usernameField, value, maxUsernameLength)
+
+	if utf8.RuneCountInString(value) < minUsernameLength {
+		v.add(usernameField, "%s must be at least %d characters long", usernameField, minUsernameLength)
+	}
+
+	// Surrounding whitespace is already reported by text.
+	if strings.IndexFunc(strings.TrimSpace(value), notUsernameRune) >= 0 {
+		v.add(usernameField, "%s may only contain letters, digits and %q", usernameField, usernamePunctuation)
+	}
+}
+
+// notUsernameRune reports whether r is not allowed in usernames.
+// Combining marks are allowed, some scripts can't be written without them.
+func notUsernameRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsMark(r) && !strings.ContainsRune(usernamePunctuation, r)
+}
+
+// password checks a password, its strength is up to the password policy of the service.
+func (v *validator) password(value string) {
+	if !v.required(passwordField, value) {
+		return
+	}
+
+	if len(value) > maxPasswordBytes {
+		v.add(passwordField, "%s must be at most %d bytes long", passwordField, maxPasswordBytes)
+	}
+}
+
+// validateLogin checks a login request. The username may be an email address,
+// and usernames registered before the current rules must still be able to log in,
+// so it is checked more loosely than in validateRegister.
+func validateLogin(in *authv1.LoginRequest) error {
+	var v validator
+
+	if v.required(usernameField, in.GetUsername()) {
+		v.text(usernameField, in.GetUsername(), maxLoginLength)
+	}
+
+	v.password(in.GetPassword())
+
+	return v.err()
+}
+
+func validateRegister(in *authv1.RegisterRequest) error {
+	var v validator
+
+	if v.required(nameField, in.GetName()) {
+		v.text(nameField, in.GetName(), maxNameLength)
+	}
+
+	v.username(in.GetUsername())
+	v.password(in.GetPassword())
+
+	return v.err()
+}
@@ -0,0 +1,217 @@
+package auth
+
+import (
+	"auth/internal/grpc/grpcerr"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	authv1 "github.com/3XBAT/protos/gen/go"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// violation is a field violation expected in a test.
+type violation struct {
+	field       string
+	description string
+}
+
+func violationsOf(err error) []violation {
+	var validationErr *validationError
+	if !errors.As(err, &validationErr) {
+		return nil
+	}
+
+	var violations []violation
+	for _, v := range validationErr.violations {
+		violations = append(violations, violation{field: v.GetField(), description: v.GetDescription()})
+	}
+
+	return violations
+}
+
+func Test_validateRegister(t *testing.T) {
+	tests := []struct {
+		nameTest           string
+		in                 *authv1.RegisterRequest
+		expectedViolations []violation
+	}{
+		{
+			nameTest: "Valid",
+			in:       &authv1.RegisterRequest{Name: "Matvey Tabby", Username: "Matvey_Tabby.1", Password: "OOP"},
+		},
+		{
+			nameTest: "Valid non-Latin username",
+			in:       &authv1.RegisterRequest{Name: "Матвей", Username: "матвей", Password: "OOP"},
+		},
+		{
+			nameTest: "All empty",
+			in:       &authv1.RegisterRequest{},
+			expectedViolations: []violation{
+				{nameField, "name is empty"},
+				{usernameField, "username is empty"},
+				{passwordField, "password is empty"},
+			},
+		},
+		{
+			nameTest: "Name with surrounding whitespace",
+			in:       &authv1.RegisterRequest{Name: " Matvey ", Username: "MatveyTabby", Password: "OOP"},
+			expectedViolations: []violation{
+				{nameField, "name must not start or end with whitespace"},
+			},
+		},
+		{
+			nameTest: "Name too long",
+			in:       &authv1.RegisterRequest{Name: strings.Repeat("я", maxNameLength+1), Username: "MatveyTabby", Password: "OOP"},
+			expectedViolations: []violation{
+				{nameField, "name must be at most 64 characters long"},
+			},
+		},
+		{
+			nameTest: "Name with control characters",
+			in:       &authv1.RegisterRequest{Name: "Matvey\tTabby", Username: "MatveyTabby", Password: "OOP"},
+			expectedViolations: []violation{
+				{nameField, "name must not contain control characters"},
+			},
+		},
+		{
+			nameTest: "Username too short",
+			in:       &authv1.RegisterRequest{Name: "Matvey", Username: "mt", Password: "OOP"},
+			expectedViolations: []violation{
+				{usernameField, "username must be at least 3 characters long"},
+			},
+		},
+		{
+			nameTest: "Username too long",
+			in:       &authv1.RegisterRequest{Name: "Matvey", Username: strings.Repeat("m", maxUsernameLength+1), Password: "OOP"},
+			expectedViolations: []violation{
+				{usernameField, "username must be at most 32 characters long"},
+			},
+		},
+		{
+			nameTest: "Username with disallowed characters",
+			in:       &authv1.RegisterRequest{Name: "Matvey", Username: "matvey tabby!", Password: "OOP"},
+			expectedViolations: []violation{
+				{usernameField, `username may only contain letters, digits and "._-"`},
+			},
+		},
+		{
+			nameTest: "Username with surrounding whitespace",
+			in:       &authv1.RegisterRequest{Name: "Matvey", Username: "MatveyTabby ", Password: "OOP"},
+			expectedViolations: []violation{
+				{usernameField, "username must not start or end with whitespace"},
+			},
+		},
+		{
+			nameTest: "Username not NFC normalized",
+			in:       &authv1.RegisterRequest{Name: "Matvey", Username: "jose\u0301", Password: "OOP"},
+			expectedViolations: []violation{
+				{usernameField, "username must be in Unicode normalization form C"},
+			},
+		},
+		{
+			nameTest: "Password too long",
+			in:       &authv1.RegisterRequest{Name: "Matvey", Username: "MatveyTabby", Password: strings.Repeat("p", maxPasswordBytes+1)},
+			expectedViolations: []violation{
+				{passwordField, "password must be at most 1024 bytes long"},
+			},
+		},
+		{
+			nameTest: "Several fields",
+			in:       &authv1.RegisterRequest{Name: " Matvey", Username: "m!", Password: ""},
+			expectedViolations: []violation{
+				{nameField, "name must not start or end with whitespace"},
+				{usernameField, "username must be at least 3 characters long"},
+				{usernameField, `username may only contain letters, digits and "._-"`},
+				{passwordField, "password is empty"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nameTest, func(t *testing.T) {
+			err := validateRegister(tc.in)
+
+			if tc.expectedViolations == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.Equal(t, tc.expectedViolations, violationsOf(err))
+			}
+		})
+	}
+}
+
+func Test_validateLogin(t *testing.T) {
+	tests := []struct {
+		nameTest           string
+		in                 *authv1.LoginRequest
+		expectedViolations []violation
+	}{
+		{
+			nameTest: "Valid",
+			in:       &authv1.LoginRequest{Username: "MatveyTabby", Password: "OOP"},
+		},
+		{
+			nameTest: "Email as username",
+			in:       &authv1.LoginRequest{Username: "matvey@example.com", Password: "OOP"},
+		},
+		{
+			nameTest: "Short legacy username",
+			in:       &authv1.LoginRequest{Username: "mt", Password: "OOP"},
+		},
+		{
+			nameTest: "Both empty",
+			in:       &authv1.LoginRequest{},
+			expectedViolations: []violation{
+				{usernameField, "username is empty"},
+				{passwordField, "password is empty"},
+			},
+		},
+		{
+			nameTest: "Username too long",
+			in:       &authv1.LoginRequest{Username: strings.Repeat("m", maxLoginLength+1), Password: "OOP"},
+			expectedViolations: []violation{
+				{usernameField, "username must be at most 254 characters long"},
+			},
+		},
+		{
+			nameTest: "Username with surrounding whitespace",
+			in:       &authv1.LoginRequest{Username: " MatveyTabby", Password: "OOP"},
+			expectedViolations: []violation{
+				{usernameField, "username must not start or end with whitespace"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nameTest, func(t *testing.T) {
+			err := validateLogin(tc.in)
+
+			if tc.expectedViolations == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.Equal(t, tc.expectedViolations, violationsOf(err))
+			}
+		})
+	}
+}
+
+func Test_invalidArgument(t *testing.T) {
+	err := invalidArgument(context.Background(), validateRegister(&authv1.RegisterRequest{}))
+
+	st := status.Convert(err)
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+	assert.Equal(t, "name is empty; username is empty; password is empty", st.Message())
+	assert.Equal(t, grpcerr.ReasonInvalidArgument, grpcerr.Reason(err))
+
+	if assert.Len(t, st.Details(), 2) {
+		badRequest, ok := st.Details()[1].(*errdetails.BadRequest)
+		assert.True(t, ok)
+		assert.Len(t, badRequest.GetFieldViolations(), 3)
+	}
+}
@@ -0,0 +1,151 @@
+package authz
+
+import (
+	"auth/internal/grpc/grpcerr"
+	"auth/internal/jwt"
+	"context"
+	"slices"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+// authorizationHeader is the metadata key of the bearer token.
+const authorizationHeader = "authorization"
+
+const bearerPrefix = "bearer "
+
+// TokenValidator must accept only tokens signed with the keys of the service and issued to its audience.
+// Tokens signed with the secret of an app are rejected, whoever holds the secret can put any roles into them.
+//
+//go:generate go run github.com/vektra/mockery/v2@latest --name=TokenValidator --with-expecter=true
+type TokenValidator interface {
+	ValidateToken(ctx context.Context,
+		token string,
+	) (*jwt.Claims, error)
+}
+
+// Policy tells who may call a method.
+type Policy struct {
+	public bool
+	roles  []string
+}
+
+// Public lets anyone call the method, no token is needed.
+func Public() Policy {
+	return Policy{public: true}
+}
+
+// Authenticated requires a valid token.
+func Authenticated() Policy {
+	return Policy{}
+}
+
+// RequireRole requires a valid token carrying any of the roles.
+func RequireRole(roles ...string) Policy {
+	return Policy{roles: roles}
+}
+
+// Policies map full method names ("/package.Service/Method") to their policies.
+// Methods missing from the table are denied, so a new RPC can't be left open by accident.
+type Policies map[string]Policy
+
+type claimsKey struct{}
+
+// ClaimsFromContext returns the claims of the token the request was authenticated with.
+func ClaimsFromContext(ctx context.Context) (*jwt.Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*jwt.Claims)
+	return claims, ok
+}
+
+func UnaryServerInterceptor(validator TokenValidator, policies Policies) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		ctx, err := authorize(ctx, validator, policies, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func StreamServerInterceptor(validator TokenValidator, policies Policies) grpc.StreamServerInterceptor {
+	return func(srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		ctx, err := authorize(ss.Context(), validator, policies, info.FullMethod)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authorize checks the request against the policy of the method
+// and puts the claims of the token into the context.
+func authorize(ctx context.Context, validator TokenValidator, policies Policies, method string) (context.Context, error) {
+	policy, ok := policies[method]
+	if !ok {
+		return nil, grpcerr.New(ctx, codes.PermissionDenied, grpcerr.ReasonPermissionDenied, "method has no access policy", nil)
+	}
+
+	if policy.public {
+		return ctx, nil
+	}
+
+	token, ok := bearerToken(ctx)
+	if !ok {
+		return nil, grpcerr.New(ctx, codes.Unauthenticated, grpcerr.ReasonUnauthenticated, "bearer token is missing", nil)
+	}
+
+	claims, err := validator.ValidateToken(ctx, token)
+	if err != nil {
+		return nil, grpcerr.New(ctx, codes.Unauthenticated, grpcerr.ReasonInvalidToken, "invalid token", nil)
+	}
+
+	if len(policy.roles) > 0 && !slices.ContainsFunc(policy.roles, func(role string) bool {
+		return slices.Contains(claims.Roles, role)
+	}) {
+		return nil, grpcerr.New(ctx, codes.PermissionDenied, grpcerr.ReasonPermissionDenied, "permission denied", nil)
+	}
+
+	return context.WithValue(ctx, claimsKey{}, claims), nil
+}
+
+func bearerToken(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+
+	values := md.Get(authorizationHeader)
+	if len(values) == 0 {
+		return "", false
+	}
+
+	value := values[0]
+	if len(value) <= len(bearerPrefix) || !strings.EqualFold(value[:len(bearerPrefix)], bearerPrefix) {
+		return "", false
+	}
+
+	return strings.TrimSpace(value[len(bearerPrefix):]), true
+}
+
+// serverStream replaces the context of a stream with the authorized one.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
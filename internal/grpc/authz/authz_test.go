@@ -0,0 +1,134 @@
+package authz
+
+import (
+	"auth/internal/grpc/authz/mocks"
+	"auth/internal/jwt"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func Test_UnaryServerInterceptor(t *testing.T) {
+	policies := Policies{
+		"/test.Service/Public":  Public(),
+		"/test.Service/Private": Authenticated(),
+		"/test.Service/Admin":   RequireRole("admin"),
+	}
+
+	withToken := func(token string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs(authorizationHeader, "Bearer "+token))
+	}
+
+	tests := []struct {
+		nameTest      string
+		ctx           context.Context
+		method        string
+		mockValidator func(s *mocks.TokenValidator)
+		expectedCode  codes.Code
+		expectedUID   int64
+	}{
+		{
+			nameTest:     "Public method without token",
+			ctx:          context.Background(),
+			method:       "/test.Service/Public",
+			expectedCode: codes.OK,
+		},
+		{
+			nameTest:     "Method without policy",
+			ctx:          withToken("token"),
+			method:       "/test.Service/Forgotten",
+			expectedCode: codes.PermissionDenied,
+		},
+		{
+			nameTest:     "Missing token",
+			ctx:          context.Background(),
+			method:       "/test.Service/Private",
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			nameTest:     "Not a bearer token",
+			ctx:          metadata.NewIncomingContext(context.Background(), metadata.Pairs(authorizationHeader, "Basic dXNlcjpwYXNz")),
+			method:       "/test.Service/Private",
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			nameTest: "Invalid token",
+			ctx:      withToken("token"),
+			method:   "/test.Service/Private",
+			mockValidator: func(s *mocks.TokenValidator) {
+				s.EXPECT().ValidateToken(withToken("token"), "token").Return(nil, errors.New("token is expired")).Once()
+			},
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			nameTest: "Authenticated",
+			ctx:      withToken("token"),
+			method:   "/test.Service/Private",
+			mockValidator: func(s *mocks.TokenValidator) {
+				s.EXPECT().ValidateToken(withToken("token"), "token").Return(&jwt.Claims{UID: 1}, nil).Once()
+			},
+			expectedCode: codes.OK,
+			expectedUID:  1,
+		},
+		{
+			nameTest: "Missing role",
+			ctx:      withToken("token"),
+			method:   "/test.Service/Admin",
+			mockValidator: func(s *mocks.TokenValidator) {
+				s.EXPECT().ValidateToken(withToken("token"), "token").Return(&jwt.Claims{UID: 1, Roles: []string{"support"}}, nil).Once()
+			},
+			expectedCode: codes.PermissionDenied,
+		},
+		{
+			nameTest: "Has role",
+			ctx:      withToken("token"),
+			method:   "/test.Service/Admin",
+			mockValidator: func(s *mocks.TokenValidator) {
+				s.EXPECT().ValidateToken(withToken("token"), "token").Return(&jwt.Claims{UID: 2, Roles: []string{"admin"}}, nil).Once()
+			},
+			expectedCode: codes.OK,
+			expectedUID:  2,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nameTest, func(t *testing.T) {
+			validator := mocks.NewTokenValidator(t)
+			if tc.mockValidator != nil {
+				tc.mockValidator(validator)
+			}
+
+			interceptor := UnaryServerInterceptor(validator, policies)
+
+			var handlerCtx context.Context
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				handlerCtx = ctx
+				return "response", nil
+			}
+
+			resp, err := interceptor(tc.ctx, "request", &grpc.UnaryServerInfo{FullMethod: tc.method}, handler)
+
+			assert.Equal(t, tc.expectedCode, status.Code(err))
+			if tc.expectedCode != codes.OK {
+				assert.Nil(t, resp)
+				return
+			}
+
+			assert.Equal(t, "response", resp)
+
+			claims, ok := ClaimsFromContext(handlerCtx)
+			if tc.expectedUID == 0 {
+				assert.False(t, ok)
+			} else {
+				assert.True(t, ok)
+				assert.Equal(t, tc.expectedUID, claims.UID)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	jwt "auth/internal/jwt"
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// TokenValidator is an autogenerated mock type for the TokenValidator type
+type TokenValidator struct {
+	mock.Mock
+}
+
+type TokenValidator_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *TokenValidator) EXPECT() *TokenValidator_Expecter {
+	return &TokenValidator_Expecter{mock: &_m.Mock}
+}
+
+// ValidateToken provides a mock function with given fields: ctx, token
+func (_m *TokenValidator) ValidateToken(ctx context.Context, token string) (*jwt.Claims, error) {
+	ret := _m.Called(ctx, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ValidateToken")
+	}
+
+	var r0 *jwt.Claims
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*jwt.Claims, error)); ok {
+		return rf(ctx, token)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *jwt.Claims); ok {
+		r0 = rf(ctx, token)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*jwt.Claims)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TokenValidator_ValidateToken_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ValidateToken'
+type TokenValidator_ValidateToken_Call struct {
+	*mock.Call
+}
+
+// ValidateToken is a helper method to define mock.On call
+//   - ctx context.Context
+//   - token string
+func (_e *TokenValidator_Expecter) ValidateToken(ctx interface{}, token interface{}) *TokenValidator_ValidateToken_Call {
+	return &TokenValidator_ValidateToken_Call{Call: _e.mock.On("ValidateToken", ctx, token)}
+}
+
+func (_c *TokenValidator_ValidateToken_Call) Run(run func(ctx context.Context, token string)) *TokenValidator_ValidateToken_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *TokenValidator_ValidateToken_Call) Return(_a0 *jwt.Claims, _a1 error) *TokenValidator_ValidateToken_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *TokenValidator_ValidateToken_Call) RunAndReturn(run func(context.Context, string) (*jwt.Claims, error)) *TokenValidator_ValidateToken_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewTokenValidator creates a new instance of TokenValidator. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewTokenValidator(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *TokenValidator {
+	mock := &TokenValidator{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,88 @@
+// Package grpcerr builds the error statuses returned to clients.
+// Every status carries an ErrorInfo with a stable reason clients branch on,
+// and a RequestInfo with the ID of the request to find it in the logs.
+package grpcerr
+
+import (
+	"auth/internal/grpc/requestid"
+	"context"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// Domain is the ErrorInfo domain of the errors of the service.
+const Domain = "auth"
+
+// Reasons of errors. They are part of the API: a reason is never renamed or reused for something else.
+const (
+	ReasonInvalidArgument         = "INVALID_ARGUMENT"
+	ReasonInvalidAppID            = "INVALID_APP_ID"
+	ReasonInvalidEmail            = "INVALID_EMAIL"
+	ReasonWeakPassword            = "WEAK_PASSWORD"
+	ReasonSamePassword            = "SAME_PASSWORD"
+	ReasonInvalidCredentials      = "INVALID_CREDENTIALS"
+	ReasonInvalidMFACode          = "INVALID_MFA_CODE"
+	ReasonInvalidToken            = "INVALID_TOKEN"
+	ReasonTokenReused             = "TOKEN_REUSED"
+	ReasonTokenRevoked            = "TOKEN_REVOKED"
+	ReasonUnauthenticated         = "UNAUTHENTICATED"
+	ReasonPermissionDenied        = "PERMISSION_DENIED"
+	ReasonUserExists              = "USER_EXISTS"
+	ReasonEmailExists             = "EMAIL_EXISTS"
+	ReasonCredentialExists        = "CREDENTIAL_EXISTS"
+	ReasonUserNotFound            = "USER_NOT_FOUND"
+	ReasonRoleNotFound            = "ROLE_NOT_FOUND"
+	ReasonTooManyAttempts         = "TOO_MANY_ATTEMPTS"
+	ReasonAccountLocked           = "ACCOUNT_LOCKED"
+	ReasonEmailNotVerified        = "EMAIL_NOT_VERIFIED"
+	ReasonMFARequired             = "MFA_REQUIRED"
+	ReasonMFAEnabled              = "MFA_ENABLED"
+	ReasonMFANotEnrolled          = "MFA_NOT_ENROLLED"
+	ReasonInvalidWebAuthnResponse = "INVALID_WEBAUTHN_RESPONSE"
+	ReasonCredentialCloned        = "CREDENTIAL_CLONED"
+	ReasonFeatureDisabled         = "FEATURE_DISABLED"
+	ReasonInternal                = "INTERNAL"
+)
+
+// New returns an error status with the reason, the metadata and the extra details.
+// The ID of the request is taken from ctx.
+func New(ctx context.Context,
+	code codes.Code,
+	reason string,
+	message string,
+	metadata map[string]string,
+	details ...protoadapt.MessageV1,
+) error {
+	st := status.New(code, message)
+
+	all := []protoadapt.MessageV1{&errdetails.ErrorInfo{
+		Reason:   reason,
+		Domain:   Domain,
+		Metadata: metadata,
+	}}
+
+	if id, ok := requestid.FromContext(ctx); ok {
+		all = append(all, &errdetails.RequestInfo{RequestId: id})
+	}
+
+	withDetails, err := st.WithDetails(append(all, details...)...)
+	if err != nil {
+		return st.Err()
+	}
+
+	return withDetails.Err()
+}
+
+// Reason returns the ErrorInfo reason of an error status, empty if it has none.
+func Reason(err error) string {
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info.GetReason()
+		}
+	}
+
+	return ""
+}
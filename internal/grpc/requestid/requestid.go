@@ -0,0 +1,108 @@
+// Package requestid gives every gRPC request an ID to correlate the errors clients see with the logs.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Header is the metadata key the request ID is read from and returned in.
+const Header = "x-request-id"
+
+// maxLen bounds the length of a request ID accepted from a client.
+const maxLen = 64
+
+type ctxKey struct{}
+
+// FromContext returns the ID of the request being served.
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(ctxKey{}).(string)
+	return id, ok
+}
+
+// NewContext returns a copy of ctx carrying the request ID.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// UnaryServerInterceptor takes the request ID passed by the client or generates one,
+// puts it into the context of the handler and returns it to the client in the response header.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx = withRequestID(ctx)
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is UnaryServerInterceptor for streaming RPCs.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &serverStream{ServerStream: ss, ctx: withRequestID(ss.Context())})
+	}
+}
+
+func withRequestID(ctx context.Context) context.Context {
+	id := fromMetadata(ctx)
+	if id == "" {
+		id = newID()
+	}
+
+	// The header can't be set outside of a real RPC, which only matters in tests.
+	_ = grpc.SetHeader(ctx, metadata.Pairs(Header, id))
+
+	return NewContext(ctx, id)
+}
+
+// fromMetadata returns the request ID passed by the client, empty if there is none or it is unusable.
+func fromMetadata(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	values := md.Get(Header)
+	if len(values) == 0 || !valid(values[0]) {
+		return ""
+	}
+
+	return values[0]
+}
+
+// valid reports whether a client supplied ID is short and printable ASCII, safe to log and echo back.
+func valid(id string) bool {
+	if id == "" || len(id) > maxLen {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// serverStream replaces the context of a stream.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,56 @@
+package requestid
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	tests := []struct {
+		nameTest   string
+		ctx        context.Context
+		expectedID string
+	}{
+		{
+			nameTest:   "ID passed by client",
+			ctx:        metadata.NewIncomingContext(context.Background(), metadata.Pairs(Header, "req-42")),
+			expectedID: "req-42",
+		},
+		{
+			nameTest: "No metadata",
+			ctx:      context.Background(),
+		},
+		{
+			nameTest: "ID too long",
+			ctx:      metadata.NewIncomingContext(context.Background(), metadata.Pairs(Header, strings.Repeat("a", maxLen+1))),
+		},
+		{
+			nameTest: "ID with control characters",
+			ctx:      metadata.NewIncomingContext(context.Background(), metadata.Pairs(Header, "req\n42")),
+		},
+	}
+
+	interceptor := UnaryServerInterceptor()
+
+	for _, tc := range tests {
+		t.Run(tc.nameTest, func(t *testing.T) {
+			var id string
+			_, err := interceptor(tc.ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+				id, _ = FromContext(ctx)
+				return nil, nil
+			})
+			assert.NoError(t, err)
+
+			if tc.expectedID != "" {
+				assert.Equal(t, tc.expectedID, id)
+			} else {
+				assert.Len(t, id, 32, "a new ID is generated")
+			}
+		})
+	}
+}
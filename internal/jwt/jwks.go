@@ -0,0 +1,88 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+)
+
+// JWK is a public key in the JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// EC and OKP
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS is a set of public keys that verifiers use to check token signatures.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewJWKS builds a key set from the public parts of the keys.
+// Symmetric keys are skipped, they must never be published.
+func NewJWKS(keys ...Key) JWKS {
+	set := JWKS{Keys: make([]JWK, 0, len(keys))}
+
+	for _, key := range keys {
+		if jwk, ok := key.JWK(); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+
+	return set
+}
+
+// JWKS returns a key set with the only key.
+func (k Key) JWKS() JWKS {
+	return NewJWKS(k)
+}
+
+// JWK returns the public part of the key, ok is false for symmetric keys.
+func (k Key) JWK() (JWK, bool) {
+	if k.IsSymmetric() {
+		return JWK{}, false
+	}
+
+	jwk := JWK{
+		Kid: k.ID,
+		Use: "sig",
+		Alg: k.SigningMethod().Alg(),
+	}
+
+	switch public := k.Private.Public().(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = encodeBase64(public.N.Bytes())
+		jwk.E = encodeBase64(big.NewInt(int64(public.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (public.Curve.Params().BitSize + 7) / 8
+		jwk.Kty = "EC"
+		jwk.Crv = public.Curve.Params().Name
+		jwk.X = encodeBase64(public.X.FillBytes(make([]byte, size)))
+		jwk.Y = encodeBase64(public.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = encodeBase64(public)
+	default:
+		return JWK{}, false
+	}
+
+	return jwk, true
+}
+
+func encodeBase64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -2,21 +2,77 @@ package jwt
 
 import (
 	"auth/internal/domain/models"
+	"crypto/rand"
+	"encoding/hex"
 	"github.com/golang-jwt/jwt/v5"
+	"strconv"
 	"time"
 )
 
-func NewToken(user models.User, duration time.Duration) (string, error) {
-	token := jwt.New(jwt.SigningMethodHS256)
+// TokenOptions describe a token being issued.
+type TokenOptions struct {
+	TTL      time.Duration
+	Issuer   string
+	Audience []string
+	// SessionID ties the token to the refresh token family it was issued with,
+	// so logging out ends the whole session.
+	SessionID   string
+	Roles       []string
+	Permissions []string
+}
+
+// NewToken signs an access token for the user.
+// Besides uid, username, the token version of the user, roles and permissions it carries the registered claims
+// sub, iat, nbf, exp, jti and, when set in opts, iss and aud.
+// A key outside of its validity window signs nothing, such tokens would never verify.
+func NewToken(user models.User, key Key, opts TokenOptions) (string, error) {
+	now := time.Now()
 
-	claims := token.Claims.(jwt.MapClaims)
-	claims["uid"] = user.ID
-	claims["username"] = user.Username
-	claims["exp"] = time.Now().Add(duration).Unix()
+	if !key.ValidAt(now) {
+		return "", ErrKeyNotValidNow
+	}
 
-	tokenString, err := token.SignedString([]byte("secret"))
+	jti, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+
+	claims := Claims{
+		UID:          int64(user.ID),
+		Username:     user.Username,
+		SessionID:    opts.SessionID,
+		Roles:        opts.Roles,
+		Permissions:  opts.Permissions,
+		TokenVersion: user.TokenVersion,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    opts.Issuer,
+			Subject:   strconv.Itoa(user.ID),
+			Audience:  opts.Audience,
+			ExpiresAt: jwt.NewNumericDate(now.Add(opts.TTL)),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        jti,
+		},
+	}
+
+	token := jwt.NewWithClaims(key.SigningMethod(), claims)
+
+	if key.ID != "" {
+		token.Header["kid"] = key.ID
+	}
+
+	tokenString, err := token.SignedString(key.SigningKey())
 	if err != nil {
 		return "", err
 	}
 	return tokenString, nil
 }
+
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,284 @@
+package jwt
+
+import (
+	"auth/internal/config"
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+	AlgEdDSA = "EdDSA"
+)
+
+// minSecretLen is the minimal length of an HMAC secret accepted in strict mode.
+// HS256 uses a 256-bit hash, so anything shorter weakens the signature.
+const minSecretLen = 32
+
+// minRSABits is the minimal RSA modulus size accepted in strict mode.
+const minRSABits = 2048
+
+var (
+	ErrKeyMissing          = errors.New("signing key is missing")
+	ErrKeyWeak             = errors.New("signing key is too weak")
+	ErrUnsupportedAlg      = errors.New("unsupported signing algorithm")
+	ErrKeyAlgMismatch      = errors.New("private key does not match signing algorithm")
+	ErrPrivateKeyMalformed = errors.New("private key is malformed")
+)
+
+// weakSecrets are well-known defaults that must never be used outside local environments.
+var weakSecrets = []string{
+	"secret",
+	"changeme",
+	"change-me",
+	"password",
+	"qwerty",
+	"jwt-secret",
+	"supersecret",
+}
+
+// Key is a key used to sign and verify tokens.
+// HMAC keys carry Secret, asymmetric keys carry Private.
+// Zero NotBefore and NotAfter mean the key is valid without bounds.
+type Key struct {
+	ID        string
+	Method    jwt.SigningMethod
+	Secret    []byte
+	Private   crypto.Signer
+	NotBefore time.Time
+	NotAfter  time.Time
+}
+
+// LoadKey builds a signing key from config.
+//
+// For HS256 the secret is taken from the file if it is set, otherwise from the inline value
+// (which may also come from the JWT_SECRET environment variable).
+// For RS256, ES256 and EdDSA the file must contain a PEM encoded private key.
+func LoadKey(cfg config.KeyConfig) (Key, error) {
+	const op = "jwt.LoadKey"
+
+	alg := cfg.Algorithm
+	if alg == "" {
+		alg = AlgHS256
+	}
+
+	var (
+		key Key
+		err error
+	)
+
+	switch alg {
+	case AlgHS256:
+		key, err = loadSecret(cfg)
+	case AlgRS256, AlgES256, AlgEdDSA:
+		key, err = loadPrivateKey(cfg.File, alg)
+	default:
+		err = fmt.Errorf("%w: %s", ErrUnsupportedAlg, alg)
+	}
+	if err != nil {
+		return Key{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	key.ID = cfg.ID
+
+	return key, nil
+}
+
+func loadSecret(cfg config.KeyConfig) (Key, error) {
+	secret := []byte(cfg.Secret)
+
+	if cfg.File != "" {
+		data, err := os.ReadFile(cfg.File)
+		if err != nil {
+			return Key{}, err
+		}
+
+		secret = bytes.TrimSpace(data)
+	}
+
+	if len(secret) == 0 {
+		return Key{}, ErrKeyMissing
+	}
+
+	return Key{
+		Method: jwt.SigningMethodHS256,
+		Secret: secret,
+	}, nil
+}
+
+func loadPrivateKey(path string, alg string) (Key, error) {
+	if path == "" {
+		return Key{}, ErrKeyMissing
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Key{}, err
+	}
+
+	private, err := ParsePrivateKeyPEM(data)
+	if err != nil {
+		return Key{}, err
+	}
+
+	return NewAsymmetricKey("", alg, private)
+}
+
+// ParsePrivateKeyPEM parses a PKCS#8, PKCS#1 (RSA) or SEC 1 (EC) private key.
+func ParsePrivateKeyPEM(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrPrivateKeyMalformed
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, ErrPrivateKeyMalformed
+		}
+		return signer, nil
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	return nil, ErrPrivateKeyMalformed
+}
+
+// NewAsymmetricKey checks that the private key fits the algorithm and wraps it into a Key.
+func NewAsymmetricKey(id string, alg string, private crypto.Signer) (Key, error) {
+	var method jwt.SigningMethod
+
+	switch alg {
+	case AlgRS256:
+		if _, ok := private.(*rsa.PrivateKey); !ok {
+			return Key{}, ErrKeyAlgMismatch
+		}
+		method = jwt.SigningMethodRS256
+	case AlgES256:
+		ecKey, ok := private.(*ecdsa.PrivateKey)
+		if !ok || ecKey.Curve != elliptic.P256() {
+			return Key{}, ErrKeyAlgMismatch
+		}
+		method = jwt.SigningMethodES256
+	case AlgEdDSA:
+		if _, ok := private.(ed25519.PrivateKey); !ok {
+			return Key{}, ErrKeyAlgMismatch
+		}
+		method = jwt.SigningMethodEdDSA
+	default:
+		return Key{}, fmt.Errorf("%w: %s", ErrUnsupportedAlg, alg)
+	}
+
+	return Key{
+		ID:      id,
+		Method:  method,
+		Private: private,
+	}, nil
+}
+
+// GenerateKey returns a random HMAC key. It is meant for local environments only,
+// tokens signed with it become invalid after restart.
+func GenerateKey(id string) (Key, error) {
+	const op = "jwt.GenerateKey"
+
+	secret := make([]byte, minSecretLen)
+	if _, err := rand.Read(secret); err != nil {
+		return Key{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return Key{
+		ID:     id,
+		Method: jwt.SigningMethodHS256,
+		Secret: secret,
+	}, nil
+}
+
+// ValidAt reports whether the key may be used at the given moment.
+func (k Key) ValidAt(at time.Time) bool {
+	if !k.NotBefore.IsZero() && at.Before(k.NotBefore) {
+		return false
+	}
+
+	if !k.NotAfter.IsZero() && at.After(k.NotAfter) {
+		return false
+	}
+
+	return true
+}
+
+// IsSymmetric reports whether the same key is used to sign and verify tokens.
+func (k Key) IsSymmetric() bool {
+	return k.Private == nil
+}
+
+// SigningMethod returns the method the key signs with, HS256 if it is not set.
+func (k Key) SigningMethod() jwt.SigningMethod {
+	if k.Method == nil {
+		return jwt.SigningMethodHS256
+	}
+	return k.Method
+}
+
+// SigningKey returns the key in the form expected by the signing method.
+func (k Key) SigningKey() interface{} {
+	if k.IsSymmetric() {
+		return k.Secret
+	}
+	return k.Private
+}
+
+// VerificationKey returns the key in the form expected by the signing method to verify a signature.
+func (k Key) VerificationKey() interface{} {
+	if k.IsSymmetric() {
+		return k.Secret
+	}
+	return k.Private.Public()
+}
+
+// Validate checks that the key is safe to use in production.
+func (k Key) Validate() error {
+	if !k.IsSymmetric() {
+		if rsaKey, ok := k.Private.(*rsa.PrivateKey); ok && rsaKey.N.BitLen() < minRSABits {
+			return ErrKeyWeak
+		}
+		return nil
+	}
+
+	if len(k.Secret) == 0 {
+		return ErrKeyMissing
+	}
+
+	for _, weak := range weakSecrets {
+		if strings.EqualFold(string(k.Secret), weak) {
+			return ErrKeyWeak
+		}
+	}
+
+	if len(k.Secret) < minSecretLen {
+		return ErrKeyWeak
+	}
+
+	return nil
+}
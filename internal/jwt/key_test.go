@@ -0,0 +1,153 @@
+package jwt
+
+import (
+	"auth/internal/config"
+	"auth/internal/domain/models"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_LoadKey(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "key")
+	require.NoError(t, os.WriteFile(keyFile, []byte("from-file\n"), 0o600))
+
+	tests := []struct {
+		nameTest       string
+		cfg            config.KeyConfig
+		expectedSecret string
+		expectedErr    error
+	}{
+		{
+			nameTest:       "Inline secret",
+			cfg:            config.KeyConfig{ID: "k1", Secret: "inline"},
+			expectedSecret: "inline",
+		},
+		{
+			nameTest:       "File has priority",
+			cfg:            config.KeyConfig{ID: "k1", Secret: "inline", File: keyFile},
+			expectedSecret: "from-file",
+		},
+		{
+			nameTest:    "Missing key",
+			cfg:         config.KeyConfig{ID: "k1"},
+			expectedErr: ErrKeyMissing,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nameTest, func(t *testing.T) {
+			key, err := LoadKey(tc.cfg)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.cfg.ID, key.ID)
+			assert.Equal(t, tc.expectedSecret, string(key.Secret))
+		})
+	}
+}
+
+func Test_Key_Validate(t *testing.T) {
+	tests := []struct {
+		nameTest    string
+		secret      string
+		expectedErr error
+	}{
+		{nameTest: "Strong", secret: "0123456789abcdef0123456789abcdef"},
+		{nameTest: "Empty", secret: "", expectedErr: ErrKeyMissing},
+		{nameTest: "Known default", secret: "secret", expectedErr: ErrKeyWeak},
+		{nameTest: "Too short", secret: "0123456789", expectedErr: ErrKeyWeak},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nameTest, func(t *testing.T) {
+			err := Key{Secret: []byte(tc.secret)}.Validate()
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_LoadKey_Asymmetric(t *testing.T) {
+	dir := t.TempDir()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		nameTest    string
+		alg         string
+		private     crypto.Signer
+		expectedKty string
+		expectedErr error
+	}{
+		{nameTest: "RS256", alg: AlgRS256, private: rsaKey, expectedKty: "RSA"},
+		{nameTest: "ES256", alg: AlgES256, private: ecKey, expectedKty: "EC"},
+		{nameTest: "EdDSA", alg: AlgEdDSA, private: edKey, expectedKty: "OKP"},
+		{nameTest: "Mismatch", alg: AlgRS256, private: ecKey, expectedErr: ErrKeyAlgMismatch},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nameTest, func(t *testing.T) {
+			der, err := x509.MarshalPKCS8PrivateKey(tc.private)
+			require.NoError(t, err)
+
+			keyFile := filepath.Join(dir, tc.nameTest+".pem")
+			require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+
+			key, err := LoadKey(config.KeyConfig{ID: tc.nameTest, Algorithm: tc.alg, File: keyFile})
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+
+			token, err := NewToken(models.User{ID: 1, Username: "MatveyTabby"}, key, TokenOptions{TTL: time.Hour})
+			require.NoError(t, err)
+
+			parsed, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
+				return key.VerificationKey(), nil
+			}, jwt.WithValidMethods([]string{tc.alg}))
+			require.NoError(t, err)
+			assert.Equal(t, tc.nameTest, parsed.Header["kid"])
+
+			jwks := key.JWKS()
+			require.Len(t, jwks.Keys, 1)
+			assert.Equal(t, tc.expectedKty, jwks.Keys[0].Kty)
+			assert.Equal(t, tc.alg, jwks.Keys[0].Alg)
+			assert.Equal(t, tc.nameTest, jwks.Keys[0].Kid)
+		})
+	}
+}
+
+func Test_NewJWKS_SkipsSymmetricKeys(t *testing.T) {
+	jwks := NewJWKS(Key{ID: "hmac", Secret: []byte("0123456789abcdef0123456789abcdef")})
+
+	assert.Empty(t, jwks.Keys)
+}
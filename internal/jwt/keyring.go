@@ -0,0 +1,182 @@
+package jwt
+
+import (
+	"auth/internal/config"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrKeyNotFound     = errors.New("signing key not found")
+	ErrKeyNotValidNow  = errors.New("signing key is outside of its validity window")
+	ErrDuplicateKeyID  = errors.New("duplicate signing key id")
+	ErrKeyIDIsRequired = errors.New("key id is required when several keys are configured")
+)
+
+// KeyRing holds the active signing key and the retired keys that are only used for verification.
+// Retired keys keep tokens signed before a rotation valid until they expire.
+// It is safe for concurrent use, the keys can be replaced without restarting the server.
+type KeyRing struct {
+	mu     sync.RWMutex
+	active Key
+	keys   map[string]Key
+}
+
+// NewKeyRing returns a key ring signing with the active key.
+func NewKeyRing(active Key, retired ...Key) *KeyRing {
+	keys := make(map[string]Key, len(retired)+1)
+	for _, key := range retired {
+		keys[key.ID] = key
+	}
+	keys[active.ID] = active
+
+	return &KeyRing{
+		active: active,
+		keys:   keys,
+	}
+}
+
+// LoadKeyRing builds a key ring from config.
+// The active key must be valid at the moment, otherwise the tokens it signs would be rejected right away.
+func LoadKeyRing(cfg config.JWTConfig) (*KeyRing, error) {
+	const op = "jwt.LoadKeyRing"
+
+	active, err := loadKeyWithWindow(cfg.SigningKey)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !active.ValidAt(time.Now()) {
+		return nil, fmt.Errorf("%s: active key %q: %w", op, active.ID, ErrKeyNotValidNow)
+	}
+
+	if len(cfg.RetiredKeys) > 0 && active.ID == "" {
+		return nil, fmt.Errorf("%s: %w", op, ErrKeyIDIsRequired)
+	}
+
+	seen := map[string]struct{}{active.ID: {}}
+	retired := make([]Key, 0, len(cfg.RetiredKeys))
+
+	for _, keyCfg := range cfg.RetiredKeys {
+		key, err := loadKeyWithWindow(keyCfg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if key.ID == "" {
+			return nil, fmt.Errorf("%s: %w", op, ErrKeyIDIsRequired)
+		}
+
+		if _, ok := seen[key.ID]; ok {
+			return nil, fmt.Errorf("%s: %w: %s", op, ErrDuplicateKeyID, key.ID)
+		}
+		seen[key.ID] = struct{}{}
+
+		retired = append(retired, key)
+	}
+
+	return NewKeyRing(active, retired...), nil
+}
+
+func loadKeyWithWindow(cfg config.KeyConfig) (Key, error) {
+	key, err := LoadKey(cfg)
+	if err != nil {
+		return Key{}, err
+	}
+
+	key.NotBefore = cfg.NotBefore
+	key.NotAfter = cfg.NotAfter
+
+	return key, nil
+}
+
+// Active returns the key new tokens are signed with.
+func (r *KeyRing) Active() Key {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.active
+}
+
+// Keys returns the active key followed by the retired ones.
+func (r *KeyRing) Keys() []Key {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := make([]Key, 0, len(r.keys))
+	keys = append(keys, r.active)
+	for id, key := range r.keys {
+		if id != r.active.ID {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// VerificationKey returns the key with the given id if it may be used to verify tokens at the moment.
+// Tokens without a kid are only checked with a key that has no id, which LoadKeyRing allows
+// only when there are no retired keys.
+func (r *KeyRing) VerificationKey(kid string, at time.Time) (Key, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	key, ok := r.keys[kid]
+	if !ok {
+		return Key{}, ErrKeyNotFound
+	}
+
+	if !key.ValidAt(at) {
+		return Key{}, ErrKeyNotValidNow
+	}
+
+	return key, nil
+}
+
+// Keyfunc resolves the verification key by the kid header of the token.
+func (r *KeyRing) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	key, err := r.VerificationKey(kid, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if token.Method.Alg() != key.SigningMethod().Alg() {
+		return nil, jwt.ErrTokenSignatureInvalid
+	}
+
+	return key.VerificationKey(), nil
+}
+
+// Replace swaps the keys with the ones from another ring.
+func (r *KeyRing) Replace(other *KeyRing) {
+	other.mu.RLock()
+	active, keys := other.active, other.keys
+	other.mu.RUnlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.active = active
+	r.keys = keys
+}
+
+// JWKS returns the public parts of every key that is still valid for verification.
+func (r *KeyRing) JWKS() JWKS {
+	now := time.Now()
+
+	keys := r.Keys()
+	valid := keys[:0]
+	for _, key := range keys {
+		if key.ValidAt(now) {
+			valid = append(valid, key)
+		}
+	}
+
+	return NewJWKS(valid...)
+}
@@ -0,0 +1,110 @@
+package jwt
+
+import (
+	"auth/internal/config"
+	"auth/internal/domain/models"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_KeyRing_Rotation(t *testing.T) {
+	user := models.User{ID: 1, Username: "MatveyTabby"}
+
+	oldKey := Key{ID: "old", Secret: []byte("old-signing-key")}
+	newKey := Key{ID: "new", Secret: []byte("new-signing-key")}
+
+	oldToken, err := NewToken(user, oldKey, TokenOptions{TTL: time.Hour})
+	require.NoError(t, err)
+
+	oldKey.NotAfter = time.Now().Add(time.Hour)
+	keys := NewKeyRing(newKey, oldKey)
+
+	newToken, err := NewToken(user, keys.Active(), TokenOptions{TTL: time.Hour})
+	require.NoError(t, err)
+
+	_, err = jwt.Parse(oldToken, keys.Keyfunc)
+	assert.NoError(t, err, "token signed by a retired key must stay valid")
+
+	parsed, err := jwt.Parse(newToken, keys.Keyfunc)
+	require.NoError(t, err)
+	assert.Equal(t, "new", parsed.Header["kid"])
+
+	oldKey.NotAfter = time.Now().Add(-time.Minute)
+	keys.Replace(NewKeyRing(newKey, oldKey))
+
+	_, err = jwt.Parse(oldToken, keys.Keyfunc)
+	assert.ErrorIs(t, err, ErrKeyNotValidNow)
+
+	keys.Replace(NewKeyRing(newKey))
+
+	_, err = jwt.Parse(oldToken, keys.Keyfunc)
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	_, err = NewToken(user, oldKey, TokenOptions{TTL: time.Hour})
+	assert.ErrorIs(t, err, ErrKeyNotValidNow, "expired key must not sign tokens")
+}
+
+func Test_LoadKeyRing(t *testing.T) {
+	tests := []struct {
+		nameTest    string
+		cfg         config.JWTConfig
+		expectedErr error
+	}{
+		{
+			nameTest: "Active and retired",
+			cfg: config.JWTConfig{
+				SigningKey:  config.KeyConfig{ID: "k2", Secret: "new"},
+				RetiredKeys: []config.KeyConfig{{ID: "k1", Secret: "old"}},
+			},
+		},
+		{
+			nameTest: "Duplicate id",
+			cfg: config.JWTConfig{
+				SigningKey:  config.KeyConfig{ID: "k1", Secret: "new"},
+				RetiredKeys: []config.KeyConfig{{ID: "k1", Secret: "old"}},
+			},
+			expectedErr: ErrDuplicateKeyID,
+		},
+		{
+			nameTest: "Retired key without id",
+			cfg: config.JWTConfig{
+				SigningKey:  config.KeyConfig{ID: "k2", Secret: "new"},
+				RetiredKeys: []config.KeyConfig{{Secret: "old"}},
+			},
+			expectedErr: ErrKeyIDIsRequired,
+		},
+		{
+			nameTest: "Expired active key",
+			cfg: config.JWTConfig{
+				SigningKey: config.KeyConfig{ID: "k1", Secret: "old", NotAfter: time.Now().Add(-time.Minute)},
+			},
+			expectedErr: ErrKeyNotValidNow,
+		},
+		{
+			nameTest: "Active key not valid yet",
+			cfg: config.JWTConfig{
+				SigningKey: config.KeyConfig{ID: "k1", Secret: "new", NotBefore: time.Now().Add(time.Hour)},
+			},
+			expectedErr: ErrKeyNotValidNow,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nameTest, func(t *testing.T) {
+			keys, err := LoadKeyRing(tc.cfg)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.cfg.SigningKey.ID, keys.Active().ID)
+			assert.Len(t, keys.Keys(), len(tc.cfg.RetiredKeys)+1)
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package jwt
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrTokenMalformed        = errors.New("token is malformed")
+	ErrTokenExpired          = errors.New("token is expired")
+	ErrTokenNotValidYet      = errors.New("token is not valid yet")
+	ErrTokenSignatureInvalid = errors.New("token signature is invalid")
+	ErrTokenClaimsInvalid    = errors.New("token claims are invalid")
+)
+
+// Claims are the claims of tokens issued by the service.
+type Claims struct {
+	UID         int64    `json:"uid"`
+	Username    string   `json:"username"`
+	SessionID   string   `json:"sid,omitempty"`
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	// TokenVersion is the version of the user the token was issued at, see models.User.
+	TokenVersion int `json:"ver"`
+	jwt.RegisteredClaims
+}
+
+// VerifyOptions are the expectations a token must meet besides a valid signature and lifetime.
+// Empty values are not checked.
+type VerifyOptions struct {
+	Issuer string
+	// Audience lists the accepted audiences, the token must be issued to at least one of them.
+	Audience []string
+}
+
+// Verify checks the signature, exp, nbf, iss and aud of the token and returns its claims.
+// The returned error wraps one of the ErrToken* errors.
+func Verify(tokenString string, keys *KeyRing, opts VerifyOptions) (*Claims, error) {
+	const op = "jwt.Verify"
+
+	parserOpts := []jwt.ParserOption{
+		jwt.WithExpirationRequired(),
+	}
+	if opts.Issuer != "" {
+		parserOpts = append(parserOpts, jwt.WithIssuer(opts.Issuer))
+	}
+
+	var claims Claims
+
+	if _, err := jwt.ParseWithClaims(tokenString, &claims, keys.Keyfunc, parserOpts...); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, classify(err))
+	}
+
+	if len(opts.Audience) > 0 && !issuedTo(claims.Audience, opts.Audience) {
+		return nil, fmt.Errorf("%s: %w: token is not issued to %v", op, ErrTokenClaimsInvalid, opts.Audience)
+	}
+
+	return &claims, nil
+}
+
+// issuedTo reports whether the audience of a token contains any of the accepted ones.
+func issuedTo(audience jwt.ClaimStrings, accepted []string) bool {
+	for _, aud := range audience {
+		for _, want := range accepted {
+			if aud == want {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// classify maps parser errors to the errors of the package, keeping the original for the message.
+func classify(err error) error {
+	var target error
+
+	switch {
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		target = ErrTokenMalformed
+	case errors.Is(err, jwt.ErrTokenExpired):
+		target = ErrTokenExpired
+	case errors.Is(err, jwt.ErrTokenNotValidYet):
+		target = ErrTokenNotValidYet
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid),
+		errors.Is(err, jwt.ErrTokenUnverifiable),
+		errors.Is(err, ErrKeyNotFound),
+		errors.Is(err, ErrKeyNotValidNow):
+		target = ErrTokenSignatureInvalid
+	default:
+		target = ErrTokenClaimsInvalid
+	}
+
+	return fmt.Errorf("%w: %s", target, err.Error())
+}
+
+// KeyID returns the kid header of the token without verifying it.
+// It lets callers pick the keys to verify the token with.
+func KeyID(tokenString string) (string, error) {
+	token, _, err := jwt.NewParser().ParseUnverified(tokenString, &Claims{})
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrTokenMalformed, err.Error())
+	}
+
+	kid, _ := token.Header["kid"].(string)
+
+	return kid, nil
+}
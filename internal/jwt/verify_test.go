@@ -0,0 +1,138 @@
+package jwt
+
+import (
+	"auth/internal/domain/models"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Verify(t *testing.T) {
+	key := Key{ID: "k1", Secret: []byte("test-signing-key")}
+	keys := NewKeyRing(key)
+
+	sign := func(claims jwt.Claims, key Key) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		token.Header["kid"] = key.ID
+		tokenString, err := token.SignedString(key.Secret)
+		require.NoError(t, err)
+		return tokenString
+	}
+
+	valid, err := NewToken(models.User{ID: 7, Username: "MatveyTabby", TokenVersion: 3}, key, TokenOptions{TTL: time.Hour, Issuer: "auth", Audience: []string{"shop"}})
+	require.NoError(t, err)
+
+	tests := []struct {
+		nameTest    string
+		token       string
+		opts        VerifyOptions
+		expectedErr error
+	}{
+		{
+			nameTest: "Success",
+			token:    valid,
+		},
+		{
+			nameTest:    "Malformed",
+			token:       "not.a.token",
+			expectedErr: ErrTokenMalformed,
+		},
+		{
+			nameTest: "Expired",
+			token: sign(jwt.MapClaims{
+				"uid": 7,
+				"exp": time.Now().Add(-time.Minute).Unix(),
+			}, key),
+			expectedErr: ErrTokenExpired,
+		},
+		{
+			nameTest: "Without exp",
+			token: sign(jwt.MapClaims{
+				"uid": 7,
+			}, key),
+			expectedErr: ErrTokenClaimsInvalid,
+		},
+		{
+			nameTest: "Not valid yet",
+			token: sign(jwt.MapClaims{
+				"uid": 7,
+				"exp": time.Now().Add(time.Hour).Unix(),
+				"nbf": time.Now().Add(time.Minute).Unix(),
+			}, key),
+			expectedErr: ErrTokenNotValidYet,
+		},
+		{
+			nameTest:    "Bad signature",
+			token:       sign(jwt.MapClaims{"exp": time.Now().Add(time.Hour).Unix()}, Key{ID: "k1", Secret: []byte("another-key")}),
+			expectedErr: ErrTokenSignatureInvalid,
+		},
+		{
+			nameTest:    "Unknown key",
+			token:       sign(jwt.MapClaims{"exp": time.Now().Add(time.Hour).Unix()}, Key{ID: "k2", Secret: []byte("test-signing-key")}),
+			expectedErr: ErrTokenSignatureInvalid,
+		},
+		{
+			nameTest: "Expected issuer and audience",
+			token:    valid,
+			opts:     VerifyOptions{Issuer: "auth", Audience: []string{"shop"}},
+		},
+		{
+			nameTest: "One of the accepted audiences",
+			token:    valid,
+			opts:     VerifyOptions{Audience: []string{"auth", "shop"}},
+		},
+		{
+			nameTest:    "Wrong issuer",
+			token:       valid,
+			opts:        VerifyOptions{Issuer: "another"},
+			expectedErr: ErrTokenClaimsInvalid,
+		},
+		{
+			nameTest: "Wrong audience",
+			token: sign(jwt.MapClaims{
+				"exp": time.Now().Add(time.Hour).Unix(),
+				"aud": "billing",
+			}, key),
+			opts:        VerifyOptions{Audience: []string{"shop"}},
+			expectedErr: ErrTokenClaimsInvalid,
+		},
+		{
+			nameTest:    "Token of another app",
+			token:       valid,
+			opts:        VerifyOptions{Audience: []string{"auth"}},
+			expectedErr: ErrTokenClaimsInvalid,
+		},
+		{
+			nameTest: "Without audience",
+			token: sign(jwt.MapClaims{
+				"exp": time.Now().Add(time.Hour).Unix(),
+			}, key),
+			opts:        VerifyOptions{Audience: []string{"shop"}},
+			expectedErr: ErrTokenClaimsInvalid,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nameTest, func(t *testing.T) {
+			claims, err := Verify(tc.token, keys, tc.opts)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, claims)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, int64(7), claims.UID)
+			assert.Equal(t, "MatveyTabby", claims.Username)
+			assert.Equal(t, 3, claims.TokenVersion)
+			assert.Equal(t, "7", claims.Subject)
+			assert.NotEmpty(t, claims.ID)
+			assert.NotNil(t, claims.IssuedAt)
+			assert.NotNil(t, claims.NotBefore)
+		})
+	}
+}
@@ -0,0 +1,382 @@
+// Package migrate applies versioned SQL migrations to a Postgres database.
+//
+// Migrations are pairs of files named <version>_<name>.up.sql and <version>_<name>.down.sql.
+// Applied versions are recorded in the schema_migrations table. Every migration runs in its own
+// transaction together with its record, so a failed one leaves no trace. What can't be written in SQL
+// is done by a Step of the migration in the same transaction. An advisory lock lets
+// several instances starting at once migrate the database only once.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// lockID is the key of the advisory lock held while migrating, an arbitrary constant of the service.
+const lockID = 7_203_481_556_013
+
+var (
+	ErrInvalidMigration = errors.New("invalid migration")
+	ErrIrreversible     = errors.New("migration has no down file")
+	ErrUnknownVersion   = errors.New("applied version has no migration")
+)
+
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Step is the part of a migration that can't be written in SQL. It runs in the transaction
+// of the migration right after its up file and returns notes for the operator,
+// such as rows it couldn't migrate as they are.
+type Step func(ctx context.Context, tx *sql.Tx) ([]string, error)
+
+// Migration is a change of the schema and the way to revert it. Down is empty if it can't be reverted.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+	Step    Step     // nil for migrations written in SQL only
+	Notes   []string // reported by Step when the migration was applied
+}
+
+// Status tells whether a migration is applied. Name is empty for versions applied to the database
+// that are unknown to this build, which happens after rolling back to an older release.
+type Status struct {
+	Version   int64
+	Name      string
+	AppliedAt time.Time // zero if the migration is pending
+}
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// New returns a migrator applying the migrations found in the root of fsys.
+// steps are the parts written in Go of the migrations with their versions.
+func New(db *sql.DB, fsys fs.FS, steps map[int64]Step) (*Migrator, error) {
+	const op = "migrate.New"
+
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	for version, step := range steps {
+		i := sort.Search(len(migrations), func(i int) bool { return migrations[i].Version >= version })
+		if i == len(migrations) || migrations[i].Version != version {
+			return nil, fmt.Errorf("%s: %w: step of unknown version %d", op, ErrInvalidMigration, version)
+		}
+
+		migrations[i].Step = step
+	}
+
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Load reads the migrations from the root of fsys in the order of their versions.
+// Files not named like migrations are ignored.
+func Load(fsys fs.FS) ([]Migration, error) {
+	const op = "migrate.Load"
+
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("%s: %w: bad version of %s", op, ErrInvalidMigration, entry.Name())
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("%s: %w: version %d is used by %s and %s",
+				op, ErrInvalidMigration, version, migration.Name, match[2])
+		}
+
+		body, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(body)
+		} else {
+			migration.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("%s: %w: %d_%s has no up file", op, ErrInvalidMigration, migration.Version, migration.Name)
+		}
+
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Up applies the pending migrations in order and returns them.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	const op = "migrate.Up"
+
+	done, err := m.up(ctx, math.MaxInt64)
+	if err != nil {
+		return done, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return done, nil
+}
+
+// UpTo applies the pending migrations up to the version, inclusive, in order and returns them.
+// It lets a step that can't be written in SQL run between two migrations.
+func (m *Migrator) UpTo(ctx context.Context, version int64) ([]Migration, error) {
+	const op = "migrate.UpTo"
+
+	done, err := m.up(ctx, version)
+	if err != nil {
+		return done, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return done, nil
+}
+
+// up applies the pending migrations with versions not above last.
+func (m *Migrator) up(ctx context.Context, last int64) ([]Migration, error) {
+	var done []Migration
+
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if migration.Version > last {
+				break
+			}
+
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+
+			notes, err := inTx(ctx, conn, migration.Up, migration.Step,
+				`INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, now())`,
+				migration.Version, migration.Name,
+			)
+			if err != nil {
+				return fmt.Errorf("%d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			migration.Notes = notes
+
+			done = append(done, migration)
+		}
+
+		return nil
+	})
+
+	return done, err
+}
+
+// Down reverts the last steps applied migrations, newest first, and returns them.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	const op = "migrate.Down"
+
+	var done []Migration
+
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		versions := make([]int64, 0, len(applied))
+		for version := range applied {
+			versions = append(versions, version)
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+		for _, version := range versions[:min(steps, len(versions))] {
+			migration, ok := m.migration(version)
+			if !ok {
+				return fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+			}
+
+			if migration.Down == "" {
+				return fmt.Errorf("%d_%s: %w", migration.Version, migration.Name, ErrIrreversible)
+			}
+
+			_, err := inTx(ctx, conn, migration.Down, nil, `DELETE FROM schema_migrations WHERE version=$1`, migration.Version)
+			if err != nil {
+				return fmt.Errorf("%d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			done = append(done, migration)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return done, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return done, nil
+}
+
+// Status returns the state of every known migration and of the applied versions unknown to this build,
+// in the order of versions.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	const op = "migrate.Status"
+
+	var statuses []Status
+
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			statuses = append(statuses, Status{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				AppliedAt: applied[migration.Version],
+			})
+		}
+
+		for version, appliedAt := range applied {
+			if _, ok := m.migration(version); !ok {
+				statuses = append(statuses, Status{Version: version, AppliedAt: appliedAt})
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+
+	return statuses, nil
+}
+
+func (m *Migrator) migration(version int64) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+
+	return Migration{}, false
+}
+
+// locked runs fn on a connection holding the advisory lock, creating the schema table first.
+// The lock is taken on a single connection, it belongs to the session and not to the pool.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockID); err != nil {
+		return err
+	}
+	// The context may be canceled by now, the lock must be released anyway.
+	defer conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, lockID)
+
+	_, err = conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    BIGINT PRIMARY KEY,
+		name       TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL
+	)`)
+	if err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+// appliedVersions returns the applied versions and the moments they were applied at.
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]time.Time)
+
+	for rows.Next() {
+		var (
+			version   int64
+			appliedAt time.Time
+		)
+
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+// inTx runs the script of a migration, its step if there is one and the statement recording it
+// in one transaction. It returns the notes of the step.
+func inTx(ctx context.Context, conn *sql.Conn, script string, step Step, record string, args ...any) ([]string, error) {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return nil, err
+	}
+
+	var notes []string
+	if step != nil {
+		if notes, err = step(ctx, tx); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return nil, err
+	}
+
+	return notes, tx.Commit()
+}
@@ -0,0 +1,131 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		nameTest           string
+		fsys               fstest.MapFS
+		expectedMigrations []Migration
+		expectedErr        error
+	}{
+		{
+			nameTest: "Ordered by version",
+			fsys: fstest.MapFS{
+				"0002_add_email.up.sql":      {Data: []byte("ALTER TABLE users ADD email TEXT;")},
+				"0002_add_email.down.sql":    {Data: []byte("ALTER TABLE users DROP email;")},
+				"0001_create_users.up.sql":   {Data: []byte("CREATE TABLE users ();")},
+				"0001_create_users.down.sql": {Data: []byte("DROP TABLE users;")},
+				"README.md":                  {Data: []byte("not a migration")},
+			},
+			expectedMigrations: []Migration{
+				{Version: 1, Name: "create_users", Up: "CREATE TABLE users ();", Down: "DROP TABLE users;"},
+				{Version: 2, Name: "add_email", Up: "ALTER TABLE users ADD email TEXT;", Down: "ALTER TABLE users DROP email;"},
+			},
+		},
+		{
+			nameTest: "Irreversible",
+			fsys: fstest.MapFS{
+				"10_seed.up.sql": {Data: []byte("INSERT INTO roles (name) VALUES ('admin');")},
+			},
+			expectedMigrations: []Migration{
+				{Version: 10, Name: "seed", Up: "INSERT INTO roles (name) VALUES ('admin');"},
+			},
+		},
+		{
+			nameTest:           "Empty",
+			fsys:               fstest.MapFS{},
+			expectedMigrations: []Migration{},
+		},
+		{
+			nameTest: "No up file",
+			fsys: fstest.MapFS{
+				"0001_create_users.down.sql": {Data: []byte("DROP TABLE users;")},
+			},
+			expectedErr: ErrInvalidMigration,
+		},
+		{
+			nameTest: "Version used twice",
+			fsys: fstest.MapFS{
+				"0001_create_users.up.sql": {Data: []byte("CREATE TABLE users ();")},
+				"0001_create_apps.up.sql":  {Data: []byte("CREATE TABLE apps ();")},
+			},
+			expectedErr: ErrInvalidMigration,
+		},
+		{
+			nameTest: "Zero version",
+			fsys: fstest.MapFS{
+				"0000_create_users.up.sql": {Data: []byte("CREATE TABLE users ();")},
+			},
+			expectedErr: ErrInvalidMigration,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nameTest, func(t *testing.T) {
+			migrations, err := Load(tc.fsys)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedMigrations, migrations)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0001_create_users.up.sql": {Data: []byte("CREATE TABLE users ();")},
+		"0002_add_email.up.sql":    {Data: []byte("ALTER TABLE users ADD email TEXT;")},
+	}
+	step := func(ctx context.Context, tx *sql.Tx) ([]string, error) { return nil, nil }
+
+	tests := []struct {
+		nameTest      string
+		steps         map[int64]Step
+		expectedSteps []bool
+		expectedErr   error
+	}{
+		{
+			nameTest:      "No steps",
+			expectedSteps: []bool{false, false},
+		},
+		{
+			nameTest:      "Step of a migration",
+			steps:         map[int64]Step{2: step},
+			expectedSteps: []bool{false, true},
+		},
+		{
+			nameTest:    "Step of unknown version",
+			steps:       map[int64]Step{3: step},
+			expectedErr: ErrInvalidMigration,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nameTest, func(t *testing.T) {
+			migrator, err := New(nil, fsys, tc.steps)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+
+			for i, migration := range migrator.migrations {
+				assert.Equal(t, tc.expectedSteps[i], migration.Step != nil, "%d_%s", migration.Version, migration.Name)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+// Package notify delivers notifications with one-time tokens to users.
+//
+// Log and File are meant for local development and tests: the tokens are written out
+// where anyone with access to the machine can read them.
+package notify
+
+import (
+	"auth/internal/domain/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+// Log writes notifications to the log.
+type Log struct {
+	log *slog.Logger
+}
+
+func NewLog(log *slog.Logger) *Log {
+	return &Log{log: log}
+}
+
+func (n *Log) Notify(ctx context.Context, notification models.Notification) error {
+	n.log.InfoContext(ctx, "notification",
+		slog.String("kind", notification.Kind),
+		slog.Int("uid", notification.UserID),
+		slog.String("username", notification.Username),
+		slog.String("email", notification.Email),
+		slog.String("token", notification.Token),
+		slog.Time("expires_at", notification.ExpiresAt),
+	)
+
+	return nil
+}
+
+// File appends notifications to a file, one JSON object per line.
+type File struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFile(path string) *File {
+	return &File{path: path}
+}
+
+func (n *File) Notify(_ context.Context, notification models.Notification) error {
+	const op = "notify.File.Notify"
+
+	line, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	file, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
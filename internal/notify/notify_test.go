@@ -0,0 +1,48 @@
+package notify
+
+import (
+	"auth/internal/domain/models"
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_File_Notify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notifications.jsonl")
+	notifier := NewFile(path)
+
+	sent := []models.Notification{
+		{Kind: models.NotificationPasswordReset, UserID: 1, Username: "MatveyTabby", Token: "first", ExpiresAt: time.Now().UTC()},
+		{Kind: models.NotificationPasswordReset, UserID: 2, Username: "JohnTravolta", Token: "second", ExpiresAt: time.Now().UTC()},
+	}
+
+	for _, notification := range sent {
+		require.NoError(t, notifier.Notify(context.Background(), notification))
+	}
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var received []models.Notification
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var notification models.Notification
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &notification))
+		received = append(received, notification)
+	}
+
+	require.Len(t, received, len(sent))
+	for i := range sent {
+		assert.Equal(t, sent[i].Token, received[i].Token)
+		assert.True(t, sent[i].ExpiresAt.Equal(received[i].ExpiresAt))
+	}
+}
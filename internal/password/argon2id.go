@@ -0,0 +1,112 @@
+package password
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const argon2idPrefix = "$argon2id$"
+
+// Argon2id hashes passwords with Argon2id. Memory is in KiB.
+type Argon2id struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+func (a Argon2id) Hash(password string) ([]byte, error) {
+	salt := make([]byte, a.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, a.Iterations, a.Memory, a.Parallelism, a.KeyLength)
+
+	return encodeArgon2id(a, salt, key), nil
+}
+
+func (a Argon2id) Verify(password string, hash []byte) error {
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return err
+	}
+
+	other := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return ErrMismatchedPassword
+	}
+
+	return nil
+}
+
+func (a Argon2id) Recognizes(hash []byte) bool {
+	return bytes.HasPrefix(hash, []byte(argon2idPrefix))
+}
+
+func (a Argon2id) NeedsRehash(hash []byte) bool {
+	params, salt, _, err := decodeArgon2id(hash)
+	if err != nil {
+		return true
+	}
+
+	params.SaltLength = uint32(len(salt))
+
+	return params != a
+}
+
+// encodeArgon2id returns the PHC string of the hash.
+func encodeArgon2id(params Argon2id, salt, key []byte) []byte {
+	return []byte(fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix, argon2.Version, params.Memory, params.Iterations, params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key),
+	))
+}
+
+// decodeArgon2id parses the PHC string of the hash.
+// Only the parameters stored in the string are set, KeyLength is the length of the key.
+func decodeArgon2id(hash []byte) (Argon2id, []byte, []byte, error) {
+	parts := strings.Split(string(hash), "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return Argon2id{}, nil, nil, ErrMalformedHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return Argon2id{}, nil, nil, fmt.Errorf("%w: %v", ErrMalformedHash, err)
+	}
+
+	if version != argon2.Version {
+		return Argon2id{}, nil, nil, fmt.Errorf("%w: unsupported version %d", ErrMalformedHash, version)
+	}
+
+	var params Argon2id
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return Argon2id{}, nil, nil, fmt.Errorf("%w: %v", ErrMalformedHash, err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Argon2id{}, nil, nil, fmt.Errorf("%w: %v", ErrMalformedHash, err)
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return Argon2id{}, nil, nil, fmt.Errorf("%w: %v", ErrMalformedHash, err)
+	}
+
+	if len(key) == 0 || params.Iterations == 0 || params.Parallelism == 0 {
+		return Argon2id{}, nil, nil, ErrMalformedHash
+	}
+
+	params.KeyLength = uint32(len(key))
+
+	return params, salt, key, nil
+}
@@ -0,0 +1,102 @@
+// Package password hashes and verifies user passwords.
+//
+// Hashes are self-describing strings: Argon2id hashes use the PHC string format
+// ($argon2id$v=19$m=65536,t=3,p=2$salt$key) and bcrypt hashes the modular crypt format ($2a$10$...),
+// so a stored hash is verified with the algorithm and parameters it was created with,
+// whatever the service is configured with now.
+package password
+
+import (
+	"auth/internal/config"
+	"errors"
+	"fmt"
+)
+
+const (
+	AlgBcrypt   = "bcrypt"
+	AlgArgon2id = "argon2id"
+)
+
+var (
+	ErrMismatchedPassword = errors.New("password does not match the hash")
+	ErrUnknownHash        = errors.New("unknown password hash format")
+	ErrMalformedHash      = errors.New("malformed password hash")
+	ErrUnsupportedAlg     = errors.New("unsupported password hashing algorithm")
+)
+
+// Scheme is one hashing algorithm with its parameters.
+type Scheme interface {
+	Hash(password string) ([]byte, error)
+	// Verify returns ErrMismatchedPassword if the password does not match the hash.
+	Verify(password string, hash []byte) error
+	// Recognizes reports whether the hash was created by the algorithm of the scheme.
+	Recognizes(hash []byte) bool
+	// NeedsRehash reports whether the hash was created with other parameters than the scheme has.
+	NeedsRehash(hash []byte) bool
+}
+
+// Hasher hashes new passwords with the current scheme and verifies hashes of every known one.
+type Hasher struct {
+	current Scheme
+	schemes []Scheme
+}
+
+// NewHasher returns a Hasher creating hashes with current.
+// Hashes of the other schemes are still verified, but need a rehash.
+func NewHasher(current Scheme, others ...Scheme) *Hasher {
+	return &Hasher{
+		current: current,
+		schemes: append([]Scheme{current}, others...),
+	}
+}
+
+// New returns a Hasher creating hashes with the algorithm chosen in config.
+// Both bcrypt and Argon2id hashes are verified whatever the algorithm is.
+func New(cfg config.PasswordConfig) (*Hasher, error) {
+	bcrypt := Bcrypt{Cost: cfg.Bcrypt.Cost}
+	argon2id := Argon2id{
+		Memory:      cfg.Argon2id.Memory,
+		Iterations:  cfg.Argon2id.Iterations,
+		Parallelism: cfg.Argon2id.Parallelism,
+		SaltLength:  cfg.Argon2id.SaltLength,
+		KeyLength:   cfg.Argon2id.KeyLength,
+	}
+
+	switch cfg.Algorithm {
+	case AlgBcrypt:
+		return NewHasher(bcrypt, argon2id), nil
+	case AlgArgon2id:
+		return NewHasher(argon2id, bcrypt), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedAlg, cfg.Algorithm)
+	}
+}
+
+func (h *Hasher) Hash(password string) ([]byte, error) {
+	return h.current.Hash(password)
+}
+
+// Verify checks the password against a hash of any known scheme.
+func (h *Hasher) Verify(password string, hash []byte) error {
+	scheme := h.scheme(hash)
+	if scheme == nil {
+		return ErrUnknownHash
+	}
+
+	return scheme.Verify(password, hash)
+}
+
+// NeedsRehash reports whether the hash should be replaced with a hash of the current scheme.
+func (h *Hasher) NeedsRehash(hash []byte) bool {
+	return !h.current.Recognizes(hash) || h.current.NeedsRehash(hash)
+}
+
+func (h *Hasher) scheme(hash []byte) Scheme {
+	for _, scheme := range h.schemes {
+		if scheme.Recognizes(hash) {
+			return scheme
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package password
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2id has small parameters to keep the tests fast.
+var testArgon2id = Argon2id{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func Test_Scheme_HashVerify(t *testing.T) {
+	tests := []struct {
+		nameTest string
+		scheme   Scheme
+		prefix   string
+	}{
+		{
+			nameTest: "bcrypt",
+			scheme:   Bcrypt{Cost: bcrypt.MinCost},
+			prefix:   "$2a$04$",
+		},
+		{
+			nameTest: "argon2id",
+			scheme:   testArgon2id,
+			prefix:   "$argon2id$v=19$m=64,t=1,p=1$",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nameTest, func(t *testing.T) {
+			hash, err := tc.scheme.Hash("correct horse")
+			require.NoError(t, err)
+
+			assert.True(t, len(hash) > len(tc.prefix))
+			assert.Equal(t, tc.prefix, string(hash[:len(tc.prefix)]))
+			assert.True(t, tc.scheme.Recognizes(hash))
+			assert.False(t, tc.scheme.NeedsRehash(hash))
+
+			assert.NoError(t, tc.scheme.Verify("correct horse", hash))
+			assert.ErrorIs(t, tc.scheme.Verify("battery staple", hash), ErrMismatchedPassword)
+
+			other, err := tc.scheme.Hash("correct horse")
+			require.NoError(t, err)
+			assert.NotEqual(t, hash, other, "hashes must be salted")
+		})
+	}
+}
+
+func Test_Hasher(t *testing.T) {
+	bcryptHash, err := Bcrypt{Cost: bcrypt.MinCost}.Hash("correct horse")
+	require.NoError(t, err)
+
+	argon2idHash, err := testArgon2id.Hash("correct horse")
+	require.NoError(t, err)
+
+	stronger := testArgon2id
+	stronger.Iterations = 2
+
+	tests := []struct {
+		nameTest       string
+		hasher         *Hasher
+		hash           []byte
+		expectedErr    error
+		expectedRehash bool
+	}{
+		{
+			nameTest: "Current scheme",
+			hasher:   NewHasher(testArgon2id, Bcrypt{Cost: bcrypt.MinCost}),
+			hash:     argon2idHash,
+		},
+		{
+			nameTest:       "Another scheme",
+			hasher:         NewHasher(testArgon2id, Bcrypt{Cost: bcrypt.MinCost}),
+			hash:           bcryptHash,
+			expectedRehash: true,
+		},
+		{
+			nameTest:       "Changed cost",
+			hasher:         NewHasher(Bcrypt{Cost: bcrypt.MinCost + 1}),
+			hash:           bcryptHash,
+			expectedRehash: true,
+		},
+		{
+			nameTest:       "Changed parameters",
+			hasher:         NewHasher(stronger),
+			hash:           argon2idHash,
+			expectedRehash: true,
+		},
+		{
+			nameTest:       "Unknown scheme",
+			hasher:         NewHasher(Bcrypt{Cost: bcrypt.MinCost}),
+			hash:           argon2idHash,
+			expectedErr:    ErrUnknownHash,
+			expectedRehash: true,
+		},
+		{
+			nameTest:       "Malformed hash",
+			hasher:         NewHasher(testArgon2id),
+			hash:           []byte("$argon2id$v=19$m=64,t=1$salt$key"),
+			expectedErr:    ErrMalformedHash,
+			expectedRehash: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nameTest, func(t *testing.T) {
+			err := tc.hasher.Verify("correct horse", tc.hash)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tc.expectedRehash, tc.hasher.NeedsRehash(tc.hash))
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package password
+
+import (
+	"auth/internal/config"
+	"bufio"
+	"bytes"
+	_ "embed"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Rules a password may violate.
+const (
+	RuleMinLength        = "min_length"
+	RuleMaxLength        = "max_length"
+	RuleMaxBytes         = "max_bytes"
+	RuleCharacterClasses = "character_classes"
+	RuleCommonPassword   = "common_password"
+	RuleContainsUsername = "contains_username"
+)
+
+// minUsernameLength is the shortest username the password is checked not to contain,
+// shorter ones are parts of too many good passwords.
+const minUsernameLength = 3
+
+//go:embed denylist.txt
+var denylistFile []byte
+
+// denylist is the set of common passwords in lower case.
+var denylist = parseDenylist(denylistFile)
+
+// Violation is a rule the password breaks.
+type Violation struct {
+	Rule        string
+	Description string
+}
+
+// Policy is the set of rules a new password must satisfy. Zero values disable the rules.
+// Lengths are counted in characters, MaxBytes limits the length in bytes
+// since bcrypt ignores everything after the first 72.
+type Policy struct {
+	MinLength           int
+	MaxLength           int
+	MaxBytes            int
+	MinCharacterClasses int
+	Denylist            bool
+	ForbidUsername      bool
+}
+
+func NewPolicy(cfg config.PasswordPolicyConfig) Policy {
+	return Policy{
+		MinLength:           cfg.MinLength,
+		MaxLength:           cfg.MaxLength,
+		MaxBytes:            cfg.MaxBytes,
+		MinCharacterClasses: cfg.MinCharacterClasses,
+		Denylist:            cfg.Denylist,
+		ForbidUsername:      cfg.ForbidUsername,
+	}
+}
+
+// Check returns every rule the password of the user breaks, nil if there are none.
+func (p Policy) Check(password, username string) []Violation {
+	var violations []Violation
+
+	length := utf8.RuneCountInString(password)
+
+	if p.MinLength > 0 && length < p.MinLength {
+		violations = append(violations, Violation{
+			Rule:        RuleMinLength,
+			Description: fmt.Sprintf("password must be at least %d characters long", p.MinLength),
+		})
+	}
+
+	if p.MaxLength > 0 && length > p.MaxLength {
+		violations = append(violations, Violation{
+			Rule:        RuleMaxLength,
+			Description: fmt.Sprintf("password must be at most %d characters long", p.MaxLength),
+		})
+	}
+
+	if p.MaxBytes > 0 && len(password) > p.MaxBytes {
+		violations = append(violations, Violation{
+			Rule:        RuleMaxBytes,
+			Description: fmt.Sprintf("password must be at most %d bytes long", p.MaxBytes),
+		})
+	}
+
+	if p.MinCharacterClasses > 0 && characterClasses(password) < p.MinCharacterClasses {
+		violations = append(violations, Violation{
+			Rule: RuleCharacterClasses,
+			Description: fmt.Sprintf("password must contain characters of at least %d of the classes: "+
+				"lowercase letters, uppercase letters, digits, symbols", p.MinCharacterClasses),
+		})
+	}
+
+	if p.Denylist && IsCommon(password) {
+		violations = append(violations, Violation{
+			Rule:        RuleCommonPassword,
+			Description: "password is too common",
+		})
+	}
+
+	if p.ForbidUsername && utf8.RuneCountInString(username) >= minUsernameLength &&
+		strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		violations = append(violations, Violation{
+			Rule:        RuleContainsUsername,
+			Description: "password must not contain the username",
+		})
+	}
+
+	return violations
+}
+
+// IsCommon reports whether the password is in the list of common passwords.
+func IsCommon(password string) bool {
+	_, ok := denylist[strings.ToLower(password)]
+
+	return ok
+}
+
+// characterClasses counts the classes of characters in the password:
+// lowercase letters, uppercase letters, digits and the rest.
+func characterClasses(password string) int {
+	var lower, upper, digit, other int
+
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			other = 1
+		}
+	}
+
+	return lower + upper + digit + other
+}
+
+func parseDenylist(file []byte) map[string]struct{} {
+	denylist := make(map[string]struct{})
+
+	scanner := bufio.NewScanner(bytes.NewReader(file))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		denylist[strings.ToLower(line)] = struct{}{}
+	}
+
+	return denylist
+}
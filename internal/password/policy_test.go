@@ -0,0 +1,114 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Policy_Check(t *testing.T) {
+	policy := Policy{
+		MinLength:           8,
+		MaxLength:           64,
+		MaxBytes:            72,
+		MinCharacterClasses: 2,
+		Denylist:            true,
+		ForbidUsername:      true,
+	}
+
+	tests := []struct {
+		nameTest      string
+		policy        Policy
+		password      string
+		username      string
+		expectedRules []string
+	}{
+		{
+			nameTest: "Good password",
+			policy:   policy,
+			password: "correct horse battery",
+			username: "MatveyTabby",
+		},
+		{
+			nameTest:      "Too short",
+			policy:        policy,
+			password:      "a1",
+			username:      "MatveyTabby",
+			expectedRules: []string{RuleMinLength},
+		},
+		{
+			nameTest:      "Too long",
+			policy:        policy,
+			password:      strings.Repeat("ab1", 22),
+			username:      "MatveyTabby",
+			expectedRules: []string{RuleMaxLength},
+		},
+		{
+			nameTest:      "Too many bytes",
+			policy:        policy,
+			password:      strings.Repeat("пароль1", 6),
+			username:      "MatveyTabby",
+			expectedRules: []string{RuleMaxBytes},
+		},
+		{
+			nameTest:      "Single character class",
+			policy:        policy,
+			password:      "correcthorsebattery",
+			username:      "MatveyTabby",
+			expectedRules: []string{RuleCharacterClasses},
+		},
+		{
+			nameTest:      "Common password",
+			policy:        policy,
+			password:      "Password123",
+			username:      "MatveyTabby",
+			expectedRules: []string{RuleCommonPassword},
+		},
+		{
+			nameTest:      "Contains username",
+			policy:        policy,
+			password:      "my name is matveytabby",
+			username:      "MatveyTabby",
+			expectedRules: []string{RuleContainsUsername},
+		},
+		{
+			nameTest: "Short username is not checked",
+			policy:   policy,
+			password: "correct horse battery",
+			username: "co",
+		},
+		{
+			nameTest:      "Several rules",
+			policy:        policy,
+			password:      "qwerty",
+			username:      "MatveyTabby",
+			expectedRules: []string{RuleMinLength, RuleCharacterClasses, RuleCommonPassword},
+		},
+		{
+			nameTest: "Zero policy",
+			policy:   Policy{},
+			password: "a",
+			username: "a",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nameTest, func(t *testing.T) {
+			var rules []string
+			for _, violation := range tc.policy.Check(tc.password, tc.username) {
+				assert.NotEmpty(t, violation.Description)
+				rules = append(rules, violation.Rule)
+			}
+
+			assert.Equal(t, tc.expectedRules, rules)
+		})
+	}
+}
+
+func Test_IsCommon(t *testing.T) {
+	assert.True(t, IsCommon("123456"))
+	assert.True(t, IsCommon("QWERTY"))
+	assert.False(t, IsCommon("correct horse battery"))
+	assert.False(t, IsCommon("# Common passwords rejected by the policy, one per line, compared case-insensitively."))
+}
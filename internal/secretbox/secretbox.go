@@ -0,0 +1,104 @@
+// Package secretbox encrypts small secrets kept at rest, like TOTP secrets, with AES-256-GCM.
+package secretbox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// KeyLen is the length of a key, AES-256.
+const KeyLen = 32
+
+var (
+	ErrKeyMissing = errors.New("encryption key is missing")
+	ErrKeyLength  = errors.New("encryption key must be 32 bytes")
+	ErrDecryption = errors.New("failed to decrypt secret")
+)
+
+// Box seals and opens secrets with one key.
+// Every sealed secret carries its own random nonce in front of the ciphertext.
+type Box struct {
+	aead cipher.AEAD
+}
+
+// New returns a Box sealing with the key.
+func New(key []byte) (*Box, error) {
+	const op = "secretbox.New"
+
+	if len(key) != KeyLen {
+		return nil, fmt.Errorf("%s: %w", op, ErrKeyLength)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &Box{aead: aead}, nil
+}
+
+// ParseKey decodes a base64 encoded key, the form the key is configured in.
+func ParseKey(s string) ([]byte, error) {
+	const op = "secretbox.ParseKey"
+
+	if s == "" {
+		return nil, fmt.Errorf("%s: %w", op, ErrKeyMissing)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(key) != KeyLen {
+		return nil, fmt.Errorf("%s: %w", op, ErrKeyLength)
+	}
+
+	return key, nil
+}
+
+// GenerateKey returns a new random key.
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, KeyLen)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// Seal encrypts the plaintext. The additional data is authenticated but not stored,
+// Open must be given the same, so a sealed secret can't be moved to another record.
+func (b *Box) Seal(plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, b.aead.NonceSize(), b.aead.NonceSize()+len(plaintext)+b.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return b.aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// Open decrypts a secret sealed by Seal.
+func (b *Box) Open(sealed, additionalData []byte) ([]byte, error) {
+	if len(sealed) < b.aead.NonceSize() {
+		return nil, ErrDecryption
+	}
+
+	nonce, ciphertext := sealed[:b.aead.NonceSize()], sealed[b.aead.NonceSize():]
+
+	plaintext, err := b.aead.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return nil, ErrDecryption
+	}
+
+	return plaintext, nil
+}
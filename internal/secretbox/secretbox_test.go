@@ -0,0 +1,92 @@
+package secretbox
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Box(t *testing.T) {
+	key, err := GenerateKey()
+	require.NoError(t, err)
+
+	box, err := New(key)
+	require.NoError(t, err)
+
+	secret := []byte("totp secret")
+	ad := []byte("user:1")
+
+	sealed, err := box.Seal(secret, ad)
+	require.NoError(t, err)
+	assert.NotContains(t, string(sealed), string(secret))
+
+	again, err := box.Seal(secret, ad)
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed, again, "nonces must differ")
+
+	opened, err := box.Open(sealed, ad)
+	require.NoError(t, err)
+	assert.Equal(t, secret, opened)
+
+	_, err = box.Open(sealed, []byte("user:2"))
+	assert.ErrorIs(t, err, ErrDecryption)
+
+	tampered := append([]byte(nil), sealed...)
+	tampered[len(tampered)-1] ^= 1
+	_, err = box.Open(tampered, ad)
+	assert.ErrorIs(t, err, ErrDecryption)
+
+	_, err = box.Open(sealed[:4], ad)
+	assert.ErrorIs(t, err, ErrDecryption)
+
+	otherKey, err := GenerateKey()
+	require.NoError(t, err)
+	other, err := New(otherKey)
+	require.NoError(t, err)
+	_, err = other.Open(sealed, ad)
+	assert.ErrorIs(t, err, ErrDecryption)
+}
+
+func Test_ParseKey(t *testing.T) {
+	key, err := GenerateKey()
+	require.NoError(t, err)
+
+	tests := []struct {
+		nameTest    string
+		in          string
+		expected    []byte
+		expectedErr error
+	}{
+		{
+			nameTest: "Valid key",
+			in:       base64.StdEncoding.EncodeToString(key),
+			expected: key,
+		},
+		{
+			nameTest:    "Empty",
+			in:          "",
+			expectedErr: ErrKeyMissing,
+		},
+		{
+			nameTest:    "Short key",
+			in:          base64.StdEncoding.EncodeToString(key[:16]),
+			expectedErr: ErrKeyLength,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nameTest, func(t *testing.T) {
+			got, err := ParseKey(tc.in)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/jwt"
+	"auth/internal/storage"
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// appKeyPrefix marks the kid of tokens signed with the secret of an app.
+const appKeyPrefix = "app-"
+
+// emptyAppID is the app ID of clients that don't specify one, they get the service defaults.
+const emptyAppID = 0
+
+var ErrInvalidAppID = errors.New("invalid app id")
+
+//go:generate go run github.com/vektra/mockery/v2@latest --name=AppProvider --with-expecter=true
+type AppProvider interface {
+	App(ctx context.Context,
+		appID int,
+	) (models.App, error)
+}
+
+// tokenParams are the keys, audience and lifetime of access tokens issued for an app.
+// Tokens are signed with the active key of keys and verified with keys only.
+type tokenParams struct {
+	keys     *jwt.KeyRing
+	key      jwt.Key
+	audience []string
+	ttl      time.Duration
+}
+
+// tokenParams resolves the parameters of access tokens issued for the app.
+// Unknown apps fail with ErrInvalidAppID.
+func (a *Auth) tokenParams(ctx context.Context, appID int) (tokenParams, error) {
+	params := tokenParams{
+		keys:     a.keys,
+		key:      a.keys.Active(),
+		audience: a.audience,
+		ttl:      a.TokenTTL,
+	}
+
+	if appID == emptyAppID {
+		return params, nil
+	}
+
+	app, err := a.appProvider.App(ctx, appID)
+	if err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			return tokenParams{}, ErrInvalidAppID
+		}
+
+		return tokenParams{}, err
+	}
+
+	if len(app.Secret) > 0 {
+		params.keys = jwt.NewKeyRing(appKey(app))
+		params.key = params.keys.Active()
+	}
+
+	if len(app.Audience) > 0 {
+		params.audience = app.Audience
+	}
+
+	if app.TokenTTL > 0 {
+		params.ttl = app.TokenTTL
+	}
+
+	return params, nil
+}
+
+// isAppKey reports whether the kid names the secret of an app.
+// Such tokens are only valid for the app, anyone holding its secret can sign them.
+func isAppKey(kid string) bool {
+	return strings.HasPrefix(kid, appKeyPrefix)
+}
+
+func appKey(app models.App) jwt.Key {
+	return jwt.Key{
+		ID:     appKeyPrefix + strconv.Itoa(app.ID),
+		Secret: app.Secret,
+	}
+}
@@ -3,13 +3,16 @@ package auth
 import (
 	"auth/internal/domain/models"
 	"auth/internal/jwt"
+	"auth/internal/password"
 	"auth/internal/storage"
 	"context"
 	"errors"
 	"fmt"
-	"golang.org/x/crypto/bcrypt"
 	"log/slog"
+	"sync"
 	"time"
+
+	"github.com/go-webauthn/webauthn/webauthn"
 )
 
 //go:generate  go run github.com/vektra/mockery/v2@latest --name=UserProvider --with-expecter=true
@@ -17,6 +20,14 @@ type UserProvider interface {
 	User(ctx context.Context,
 		username string,
 	) (models.User, error)
+
+	UserByID(ctx context.Context,
+		uid int,
+	) (models.User, error)
+
+	UserByEmail(ctx context.Context,
+		email string,
+	) (models.User, error)
 }
 
 //go:generate  go run github.com/vektra/mockery/v2@latest --name=UserSaver --with-expecter=true
@@ -24,42 +35,167 @@ type UserSaver interface {
 	SaveUser(ctx context.Context,
 		name string,
 		username string,
+		email string,
 		PassHash []byte,
 	) (uid int, err error)
+
+	UpdatePasswordHash(ctx context.Context,
+		uid int,
+		passHash []byte,
+	) error
+
+	SetEmail(ctx context.Context,
+		uid int,
+		email string,
+	) error
+
+	VerifyEmail(ctx context.Context,
+		uid int,
+		email string,
+		verifiedAt time.Time,
+	) error
+}
+
+// PasswordHasher hashes passwords and verifies them against stored hashes.
+// Verify fails with password.ErrMismatchedPassword for a wrong password,
+// NeedsRehash reports whether a stored hash is outdated.
+//
+//go:generate  go run github.com/vektra/mockery/v2@latest --name=PasswordHasher --with-expecter=true
+type PasswordHasher interface {
+	Hash(password string) ([]byte, error)
+	Verify(password string, hash []byte) error
+	NeedsRehash(hash []byte) bool
 }
 
 type Auth struct { // Repository
 	UserProvider
 	UserSaver
-	log      *slog.Logger
-	TokenTTL time.Duration
+	refreshTokens        RefreshTokenStorage
+	revoker              TokenRevoker
+	log                  *slog.Logger
+	TokenTTL             time.Duration
+	RefreshTokenTTL      time.Duration
+	ResetTokenTTL        time.Duration
+	VerificationTokenTTL time.Duration
+	MFATokenTTL          time.Duration
+	appProvider          AppProvider
+	roleProvider         RoleProvider
+	attempts             AttemptStore
+	throttle             Throttle
+	hasher               PasswordHasher
+	passwordPolicy       password.Policy
+	breaches             BreachChecker
+	oneTimeTokens        OneTimeTokenStorage
+	notifier             Notifier
+	mfa                  MFAStorage
+	secrets              SecretBox
+	webAuthnCredentials  WebAuthnStorage
+	webAuthn             *webauthn.WebAuthn
+	mfaIssuer            string         // the name authenticator apps show next to the codes
+	requireVerifiedEmail bool           // Login is blocked until the email of the user is verified
+	background           sync.WaitGroup // deliveries of notifications in progress
+	dummyHashOnce        sync.Once
+	dummyHash            []byte
+	keys                 *jwt.KeyRing
+	issuer               string
+	audience             []string
 }
 
 var (
 	ErrInvalidCredentials = errors.New("invalid credentials")
 	ErrUserExists         = errors.New("user already exists")
+	ErrUserNotFound       = errors.New("user not found")
 )
 
+// Deps are the storages and collaborators of the service.
+// Attempts, Breaches, MFA, Secrets, WebAuthnCredentials and WebAuthn are optional,
+// without them the features they back are disabled.
+type Deps struct {
+	UserProvider        UserProvider
+	UserSaver           UserSaver
+	RefreshTokens       RefreshTokenStorage
+	Revoker             TokenRevoker
+	AppProvider         AppProvider
+	RoleProvider        RoleProvider
+	Attempts            AttemptStore
+	Hasher              PasswordHasher
+	Breaches            BreachChecker
+	OneTimeTokens       OneTimeTokenStorage
+	Notifier            Notifier
+	MFA                 MFAStorage
+	Secrets             SecretBox
+	WebAuthnCredentials WebAuthnStorage
+	WebAuthn            *webauthn.WebAuthn
+	Keys                *jwt.KeyRing
+}
+
+// Options are the settings of the service.
+// A zero RefreshTokenTTL disables refresh tokens.
+type Options struct {
+	TokenTTL             time.Duration
+	RefreshTokenTTL      time.Duration
+	ResetTokenTTL        time.Duration
+	VerificationTokenTTL time.Duration
+	MFATokenTTL          time.Duration
+	Throttle             Throttle
+	PasswordPolicy       password.Policy
+	// RequireVerifiedEmail blocks Login until the email of the user is verified.
+	RequireVerifiedEmail bool
+	// MFAIssuer is the name authenticator apps show next to the codes.
+	MFAIssuer string
+	Issuer    string
+	// Audience is the default audience of the issued tokens and the one the service accepts itself.
+	Audience []string
+}
+
 // NewAuth returns a new instance of the Auth service
-func NewAuth(
-	log *slog.Logger,
-	userProvider UserProvider,
-	userSaver UserSaver,
-	tokenTTL time.Duration,
-) *Auth {
+func NewAuth(log *slog.Logger, deps Deps, opts Options) *Auth {
 	return &Auth{
-		UserProvider: userProvider,
-		UserSaver:    userSaver,
-		log:          log,
-		TokenTTL:     tokenTTL,
+		UserProvider:         deps.UserProvider,
+		UserSaver:            deps.UserSaver,
+		refreshTokens:        deps.RefreshTokens,
+		revoker:              deps.Revoker,
+		appProvider:          deps.AppProvider,
+		roleProvider:         deps.RoleProvider,
+		attempts:             deps.Attempts,
+		throttle:             opts.Throttle,
+		hasher:               deps.Hasher,
+		passwordPolicy:       opts.PasswordPolicy,
+		breaches:             deps.Breaches,
+		oneTimeTokens:        deps.OneTimeTokens,
+		notifier:             deps.Notifier,
+		mfa:                  deps.MFA,
+		secrets:              deps.Secrets,
+		webAuthnCredentials:  deps.WebAuthnCredentials,
+		webAuthn:             deps.WebAuthn,
+		log:                  log,
+		TokenTTL:             opts.TokenTTL,
+		RefreshTokenTTL:      opts.RefreshTokenTTL,
+		ResetTokenTTL:        opts.ResetTokenTTL,
+		VerificationTokenTTL: opts.VerificationTokenTTL,
+		MFATokenTTL:          opts.MFATokenTTL,
+		requireVerifiedEmail: opts.RequireVerifiedEmail,
+		mfaIssuer:            opts.MFAIssuer,
+		keys:                 deps.Keys,
+		issuer:               opts.Issuer,
+		audience:             opts.Audience,
 	}
 }
 
+// Login checks the credentials and issues tokens for the app.
+// The tokens are signed with the secret of the app and carry its audience and TTL,
+// zero appID means the service defaults. Unknown apps fail with ErrInvalidAppID.
+// Failed attempts are counted per user and per clientIP, while either is throttled
+// Login fails with AttemptsError without checking the password.
+// A username containing @ is taken for the verified email of the user.
+// Users with two-factor authentication get only an MFA token, see VerifyMFA.
 func (a *Auth) Login(
 	ctx context.Context,
 	username string,
 	password string,
-) (string, error) {
+	appID int,
+	clientIP string,
+) (models.TokenPair, error) {
 
 	const op = "auth.Login"
 
@@ -69,38 +205,131 @@ func (a *Auth) Login(
 	)
 	log.Info("attempting to login user")
 
-	user, err := a.UserProvider.User(ctx, username)
+	params, err := a.tokenParams(ctx, appID)
 	if err != nil {
-		if errors.Is(err, storage.ErrUserNotFound) {
-			a.log.Warn("user not found")
-			return "", fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		if errors.Is(err, ErrInvalidAppID) {
+			log.Warn("unknown app", slog.Int("app_id", appID))
+			return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidAppID)
 		}
 
+		log.Error("failed to get app", "", err.Error())
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := a.userByLogin(ctx, username)
+	if err != nil && !errors.Is(err, storage.ErrUserNotFound) {
 		a.log.Error("failed to get user", "", err.Error())
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
 
-		return "", fmt.Errorf("%s: %w", op, err)
+	found := err == nil
+
+	// Failures are counted under the user, whether it logs in with the username or the email,
+	// so that every login of the user shares the same guesses. An unknown login counts under itself.
+	attemptsName := username
+	if found {
+		attemptsName = user.Username
 	}
 
-	if err := bcrypt.CompareHashAndPassword(user.PassHash, []byte(password)); err != nil {
+	reserved, err := a.reserveAttempt(ctx, attemptKeys(attemptsName, clientIP))
+	if err != nil {
+		var attemptsErr *AttemptsError
+		if errors.As(err, &attemptsErr) {
+			log.Warn("login is throttled", slog.String("client_ip", clientIP))
+			return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		log.Error("failed to check login attempts", "", err.Error())
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !found {
+		a.log.Warn("user not found")
+		a.burnPasswordCheck(password)
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+	}
+
+	if err := a.hasher.Verify(password, user.PassHash); err != nil {
 		a.log.Info("invalid credentials", "", err.Error())
-		return "", fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+	}
+
+	a.rehashPassword(ctx, log, user, password)
+
+	// The password is right, but the failures of the user are forgotten only when tokens are issued.
+	// Until then the attempt is just not counted, otherwise knowing the password would reset
+	// the guesses at the second factor, which VerifyMFA counts under the same user.
+	if a.requireVerifiedEmail && user.EmailVerifiedAt.IsZero() {
+		log.Info("email is not verified")
+		a.cancelAttempt(ctx, log, reserved)
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrEmailNotVerified)
 	}
 
+	mfaRequired, err := a.mfaRequired(ctx, user.ID)
+	if err != nil {
+		log.Error("failed to check two-factor authentication", "", err.Error())
+		a.cancelAttempt(ctx, log, reserved)
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if mfaRequired {
+		a.cancelAttempt(ctx, log, reserved)
+
+		mfaToken, err := a.issueMFAToken(ctx, user.ID, appID)
+		if err != nil {
+			log.Error("failed to issue mfa token", "", err.Error())
+			return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		log.Info("second factor required")
+
+		return models.TokenPair{MFAToken: mfaToken}, nil
+	}
+
+	a.succeedAttempt(ctx, log, reserved)
+
 	log.Info("user logged in successfully")
 
-	token, err := jwt.NewToken(user, a.TokenTTL)
+	tokens, err := a.issueTokens(ctx, user, appID, params, "")
 	if err != nil {
 		a.log.Error("failed to generate token", "", err.Error())
-		return "", fmt.Errorf("%s: %w", op, err)
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tokens, nil
+}
+
+// rehashPassword replaces an outdated hash of the password after a successful login,
+// the only moment the service knows the password. The login succeeds anyway, so an error is only logged.
+func (a *Auth) rehashPassword(ctx context.Context, log *slog.Logger, user models.User, password string) {
+	if !a.hasher.NeedsRehash(user.PassHash) {
+		return
+	}
+
+	passHash, err := a.hasher.Hash(password)
+	if err != nil {
+		log.Error("failed to rehash password", "", err.Error())
+		return
 	}
 
-	return token, nil
+	if err := a.UserSaver.UpdatePasswordHash(ctx, user.ID, passHash); err != nil {
+		log.Error("failed to update password hash", "", err.Error())
+		return
+	}
+
+	log.Info("password rehashed")
 }
 
+// RegisterNewUser stores a new user. The email is optional unless Login requires a verified one.
+// A token to verify the email is sent in the background, a failed delivery is only logged:
+// RequestEmailVerification sends another one.
 func (a *Auth) RegisterNewUser(
 	ctx context.Context,
 	name string,
 	username string,
+	email string,
 	pass string,
 ) (int, error) {
 	const op = "auth.RegisterNewUser"
@@ -108,17 +337,35 @@ func (a *Auth) RegisterNewUser(
 	log := a.log.With(
 		slog.String("op", op),
 		slog.String("username", username),
-		slog.String("passHash", pass),
 	)
 	log.Info("registering user")
 
-	passHash, err := bcrypt.GenerateFromPassword([]byte(pass), bcrypt.DefaultCost)
+	if email != "" || a.requireVerifiedEmail {
+		var err error
+		if email, err = normalizeEmail(email); err != nil {
+			log.Info("invalid email", "", err.Error())
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := a.checkPassword(pass, username); err != nil {
+		if errors.Is(err, ErrWeakPassword) {
+			log.Info("password does not satisfy the policy", "", err.Error())
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+
+		log.Error("failed to check password", "", err.Error())
+
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	passHash, err := a.hasher.Hash(pass)
 	if err != nil {
 		log.Error("failed to generate password hash", "", err.Error())
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 
-	id, err := a.UserSaver.SaveUser(ctx, name, username, passHash)
+	id, err := a.UserSaver.SaveUser(ctx, name, username, email, passHash)
 	if err != nil {
 		if errors.Is(err, storage.ErrUserExists) {
 			a.log.Warn("user already exists", "", err.Error())
@@ -133,5 +380,85 @@ func (a *Auth) RegisterNewUser(
 
 	log.Info("user registered successfully")
 
+	if email != "" {
+		a.sendVerificationInBackground(ctx, log, models.User{ID: id, Name: name, Username: username, Email: email})
+	}
+
 	return id, nil
 }
+
+// ValidateToken checks that the token was issued by the service to its own audience,
+// is still valid and has not been revoked. Only the keys of the service are trusted:
+// tokens signed with the secret of an app and tokens issued to other apps are rejected,
+// they are checked with ValidateAppToken.
+// The returned error wraps one of the jwt.ErrToken* errors or ErrTokenRevoked.
+func (a *Auth) ValidateToken(
+	ctx context.Context,
+	token string,
+) (*jwt.Claims, error) {
+	const op = "auth.ValidateToken"
+
+	kid, err := jwt.KeyID(token)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if isAppKey(kid) {
+		a.log.Warn("token signed with the secret of an app", slog.String("op", op), slog.String("kid", kid))
+
+		return nil, fmt.Errorf("%s: %w: key %q belongs to an app", op, jwt.ErrTokenSignatureInvalid, kid)
+	}
+
+	claims, err := a.validateToken(ctx, token, a.keys, a.audience)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return claims, nil
+}
+
+// ValidateAppToken checks a token issued for the app: it must be signed with the secret of the app,
+// or with the keys of the service if the app has no secret, and carry the audience of the app.
+// Unknown apps fail with ErrInvalidAppID.
+func (a *Auth) ValidateAppToken(
+	ctx context.Context,
+	token string,
+	appID int,
+) (*jwt.Claims, error) {
+	const op = "auth.ValidateAppToken"
+
+	params, err := a.tokenParams(ctx, appID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	claims, err := a.validateToken(ctx, token, params.keys, params.audience)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return claims, nil
+}
+
+// validateToken verifies the token with the keys, expecting any of the audiences,
+// and checks that it has not been revoked.
+func (a *Auth) validateToken(ctx context.Context, token string, keys *jwt.KeyRing, audience []string) (*jwt.Claims, error) {
+	const op = "auth.validateToken"
+
+	claims, err := jwt.Verify(token, keys, jwt.VerifyOptions{Issuer: a.issuer, Audience: audience})
+	if err != nil {
+		a.log.Debug("token is invalid", slog.String("op", op), slog.String("error", err.Error()))
+
+		return nil, err
+	}
+
+	if err := a.checkRevoked(ctx, claims); err != nil {
+		if !errors.Is(err, ErrTokenRevoked) {
+			a.log.Error("failed to check token revocation", slog.String("op", op), slog.String("error", err.Error()))
+		}
+
+		return nil, err
+	}
+
+	return claims, nil
+}
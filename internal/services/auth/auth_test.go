@@ -2,6 +2,8 @@ package auth
 
 import (
 	"auth/internal/domain/models"
+	"auth/internal/jwt"
+	"auth/internal/password"
 
 	"auth/internal/services/auth/mocks"
 	"auth/internal/storage"
@@ -13,6 +15,7 @@ import (
 	"log/slog"
 	"os"
 	"testing"
+	"time"
 )
 
 func Test_Auth_RegisterNewUser(t *testing.T) {
@@ -41,7 +44,7 @@ func Test_Auth_RegisterNewUser(t *testing.T) {
 				s := mocks.NewUserSaver(t)
 
 				s.EXPECT().
-					SaveUser(ctx, name, username, mock.Anything).
+					SaveUser(ctx, name, username, "", mock.Anything).
 					Return(1, nil)
 				return s
 			},
@@ -56,7 +59,7 @@ func Test_Auth_RegisterNewUser(t *testing.T) {
 			mockUserSaver: func(name, username string, passHash []byte) UserSaver {
 				s := mocks.NewUserSaver(t)
 				s.EXPECT().
-					SaveUser(ctx, name, username, mock.Anything).
+					SaveUser(ctx, name, username, "", mock.Anything).
 					Return(0, ErrUserExists)
 				return s
 			},
@@ -71,7 +74,7 @@ func Test_Auth_RegisterNewUser(t *testing.T) {
 			mockUserSaver: func(name, username string, passHash []byte) UserSaver {
 				s := mocks.NewUserSaver(t)
 				s.EXPECT().
-					SaveUser(ctx, name, username, mock.Anything).
+					SaveUser(ctx, name, username, "", mock.Anything).
 					Return(0, fmt.Errorf("failed to save user"))
 				return s
 			},
@@ -84,11 +87,13 @@ func Test_Auth_RegisterNewUser(t *testing.T) {
 		t.Run(tc.nameTest, func(t *testing.T) {
 
 			s := Auth{
-				UserSaver: tc.mockUserSaver(tc.name, tc.username, tc.password),
-				log:       log,
+				UserSaver:      tc.mockUserSaver(tc.name, tc.username, tc.password),
+				passwordPolicy: password.Policy{MinLength: 6},
+				hasher:         password.NewHasher(password.Bcrypt{Cost: bcrypt.MinCost}),
+				log:            log,
 			}
 
-			ID, err := s.RegisterNewUser(ctx, tc.name, tc.username, tc.username)
+			ID, err := s.RegisterNewUser(ctx, tc.name, tc.username, "", tc.username)
 
 			if tc.expectedErrStr != "" {
 				assert.ErrorContains(t, err, tc.expectedErrStr)
@@ -101,6 +106,99 @@ func Test_Auth_RegisterNewUser(t *testing.T) {
 	}
 }
 
+func Test_Auth_RegisterNewUser_WeakPassword(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(
+		slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+	)
+
+	s := Auth{
+		UserSaver:      mocks.NewUserSaver(t),
+		hasher:         password.NewHasher(password.Bcrypt{Cost: bcrypt.MinCost}),
+		passwordPolicy: password.Policy{MinLength: 8, Denylist: true},
+		log:            log,
+	}
+
+	id, err := s.RegisterNewUser(ctx, "Matvey", "MatveyTabby", "", "qwerty")
+
+	assert.ErrorIs(t, err, ErrWeakPassword)
+	assert.Equal(t, 0, id)
+
+	var policyErr *PasswordPolicyError
+	if assert.ErrorAs(t, err, &policyErr) {
+		assert.Len(t, policyErr.Violations, 2)
+	}
+}
+
+func Test_Auth_RegisterNewUser_BreachedPassword(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(
+		slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+	)
+
+	tests := []struct {
+		nameTest      string
+		mockBreaches  func(s *mocks.BreachChecker)
+		mockUserSaver func(s *mocks.UserSaver)
+		expectedErr   error
+	}{
+		{
+			nameTest: "Not breached",
+			mockBreaches: func(s *mocks.BreachChecker) {
+				s.EXPECT().IsBreached("correct horse").Return(false, nil).Once()
+			},
+			mockUserSaver: func(s *mocks.UserSaver) {
+				s.EXPECT().SaveUser(ctx, "Matvey", "MatveyTabby", "", mock.Anything).Return(1, nil).Once()
+			},
+		},
+		{
+			nameTest: "Breached",
+			mockBreaches: func(s *mocks.BreachChecker) {
+				s.EXPECT().IsBreached("correct horse").Return(true, nil).Once()
+			},
+			expectedErr: ErrWeakPassword,
+		},
+		{
+			nameTest: "Corpus is unreadable",
+			mockBreaches: func(s *mocks.BreachChecker) {
+				s.EXPECT().IsBreached("correct horse").Return(false, fmt.Errorf("permission denied")).Once()
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nameTest, func(t *testing.T) {
+			breaches := mocks.NewBreachChecker(t)
+			tc.mockBreaches(breaches)
+
+			userSaver := mocks.NewUserSaver(t)
+			if tc.mockUserSaver != nil {
+				tc.mockUserSaver(userSaver)
+			}
+
+			s := Auth{
+				UserSaver: userSaver,
+				hasher:    password.NewHasher(password.Bcrypt{Cost: bcrypt.MinCost}),
+				breaches:  breaches,
+				log:       log,
+			}
+
+			id, err := s.RegisterNewUser(ctx, "Matvey", "MatveyTabby", "", "correct horse")
+
+			switch {
+			case tc.expectedErr != nil:
+				assert.ErrorIs(t, err, tc.expectedErr)
+			case tc.mockUserSaver == nil:
+				assert.Error(t, err)
+				assert.NotErrorIs(t, err, ErrWeakPassword)
+			default:
+				assert.NoError(t, err)
+				assert.Equal(t, 1, id)
+			}
+		})
+	}
+}
+
 func Test_Auth_Login(t *testing.T) {
 	ctx := context.Background()
 	var log *slog.Logger
@@ -193,21 +291,373 @@ func Test_Auth_Login(t *testing.T) {
 	}
 	for _, tc := range tests {
 		t.Run(tc.nameTest, func(t *testing.T) {
+			refreshTokens := mocks.NewRefreshTokenStorage(t)
+			refreshTokens.EXPECT().
+				SaveRefreshToken(ctx, mock.Anything).
+				Return(nil).Maybe()
+
+			roleProvider := mocks.NewRoleProvider(t)
+			roleProvider.EXPECT().UserRoles(ctx, mock.Anything).Return(nil, nil).Maybe()
+			roleProvider.EXPECT().UserPermissions(ctx, mock.Anything).Return(nil, nil).Maybe()
+
 			s := Auth{
-				UserProvider: tc.mockProvider(tc.name, tc.username, tc.password),
-				log:          log,
+				UserProvider:    tc.mockProvider(tc.name, tc.username, tc.password),
+				refreshTokens:   refreshTokens,
+				roleProvider:    roleProvider,
+				hasher:          password.NewHasher(password.Bcrypt{Cost: 10}),
+				log:             log,
+				RefreshTokenTTL: 24 * time.Hour,
+				keys:            jwt.NewKeyRing(jwt.Key{ID: "test", Secret: []byte("test-signing-key")}),
 			}
 
-			token, err := s.Login(ctx, tc.username, tc.password)
+			tokens, err := s.Login(ctx, tc.username, tc.password, 0, "")
 
 			if tc.expectedErrStr != "" {
 				assert.ErrorContains(t, err, tc.expectedErrStr)
-				assert.Equal(t, "", token)
+				assert.Equal(t, models.TokenPair{}, tokens)
 			} else {
 				assert.NoError(t, err)
-				assert.NotEmpty(t, token)
+				assert.NotEmpty(t, tokens.AccessToken)
+				assert.NotEmpty(t, tokens.RefreshToken)
 			}
 		})
 	}
 
 }
+
+func Test_Auth_ValidateToken(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(
+		slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+	)
+
+	key := jwt.Key{ID: "test", Secret: []byte("test-signing-key")}
+	user := models.User{ID: 1, Name: "Matvey", Username: "MatveyTabby"}
+
+	valid, err := jwt.NewToken(user, key, jwt.TokenOptions{TTL: time.Hour})
+	assert.NoError(t, err)
+
+	relogged, err := jwt.NewToken(models.User{ID: 1, Username: "MatveyTabby", TokenVersion: 1}, key, jwt.TokenOptions{TTL: time.Hour})
+	assert.NoError(t, err)
+
+	expired, err := jwt.NewToken(user, key, jwt.TokenOptions{TTL: -time.Hour})
+	assert.NoError(t, err)
+
+	// Whoever holds the secret of an app may sign any claims with it.
+	forged, err := jwt.NewToken(user, appKey(models.App{ID: 2, Secret: []byte("shop-secret")}), jwt.TokenOptions{
+		TTL:   time.Hour,
+		Roles: []string{"admin"},
+	})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		nameTest    string
+		token       string
+		mockRevoker func(s *mocks.TokenRevoker)
+		expectedErr error
+	}{
+		{
+			nameTest: "Success",
+			token:    valid,
+			mockRevoker: func(s *mocks.TokenRevoker) {
+				s.EXPECT().IsTokenRevoked(ctx, mock.Anything).Return(false, nil).Once()
+				s.EXPECT().UserTokenVersion(ctx, user.ID).Return(0, nil).Once()
+			},
+		},
+		{
+			nameTest: "Issued after logout from all devices",
+			token:    relogged,
+			mockRevoker: func(s *mocks.TokenRevoker) {
+				s.EXPECT().IsTokenRevoked(ctx, mock.Anything).Return(false, nil).Once()
+				s.EXPECT().UserTokenVersion(ctx, user.ID).Return(1, nil).Once()
+			},
+		},
+		{
+			nameTest: "Revoked token",
+			token:    valid,
+			mockRevoker: func(s *mocks.TokenRevoker) {
+				s.EXPECT().IsTokenRevoked(ctx, mock.Anything).Return(true, nil).Once()
+			},
+			expectedErr: ErrTokenRevoked,
+		},
+		{
+			nameTest: "Logged out from all devices",
+			token:    valid,
+			mockRevoker: func(s *mocks.TokenRevoker) {
+				s.EXPECT().IsTokenRevoked(ctx, mock.Anything).Return(false, nil).Once()
+				s.EXPECT().UserTokenVersion(ctx, user.ID).Return(1, nil).Once()
+			},
+			expectedErr: ErrTokenRevoked,
+		},
+		{
+			nameTest: "User deleted",
+			token:    valid,
+			mockRevoker: func(s *mocks.TokenRevoker) {
+				s.EXPECT().IsTokenRevoked(ctx, mock.Anything).Return(false, nil).Once()
+				s.EXPECT().UserTokenVersion(ctx, user.ID).Return(0, storage.ErrUserNotFound).Once()
+			},
+			expectedErr: ErrTokenRevoked,
+		},
+		{
+			nameTest:    "Expired token",
+			token:       expired,
+			expectedErr: jwt.ErrTokenExpired,
+		},
+		{
+			nameTest:    "Malformed token",
+			token:       "token",
+			expectedErr: jwt.ErrTokenMalformed,
+		},
+		{
+			nameTest:    "Signed with the secret of an app",
+			token:       forged,
+			expectedErr: jwt.ErrTokenSignatureInvalid,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nameTest, func(t *testing.T) {
+			revoker := mocks.NewTokenRevoker(t)
+			if tc.mockRevoker != nil {
+				tc.mockRevoker(revoker)
+			}
+
+			s := Auth{
+				revoker: revoker,
+				log:     log,
+				keys:    jwt.NewKeyRing(key),
+			}
+
+			claims, err := s.ValidateToken(ctx, tc.token)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, claims)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, int64(user.ID), claims.UID)
+				assert.Equal(t, user.Username, claims.Username)
+				assert.NotEmpty(t, claims.ID)
+			}
+		})
+	}
+}
+
+func Test_Auth_Login_App(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(
+		slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+	)
+
+	key := jwt.Key{ID: "test", Secret: []byte("test-signing-key")}
+	passHash, _ := bcrypt.GenerateFromPassword([]byte("123456"), bcrypt.MinCost)
+	user := models.User{ID: 1, Name: "Matvey", Username: "MatveyTabby", PassHash: passHash}
+
+	shop := models.App{
+		ID:       2,
+		Name:     "shop",
+		Secret:   []byte("shop-secret"),
+		Audience: []string{"shop"},
+		TokenTTL: 5 * time.Minute,
+	}
+
+	tests := []struct {
+		nameTest         string
+		appID            int
+		mockAppProvider  func(s *mocks.AppProvider)
+		expectedKeyID    string
+		expectedAudience []string
+		expectedTTL      time.Duration
+		// expectedServiceErr is the error of ValidateToken, which accepts only the audience of the service
+		expectedServiceErr error
+		expectedErr        error
+	}{
+		{
+			nameTest:         "Default app",
+			appID:            0,
+			expectedKeyID:    key.ID,
+			expectedAudience: []string{"auth"},
+			expectedTTL:      time.Hour,
+		},
+		{
+			nameTest: "App with own secret",
+			appID:    shop.ID,
+			mockAppProvider: func(s *mocks.AppProvider) {
+				s.EXPECT().App(ctx, shop.ID).Return(shop, nil)
+			},
+			expectedKeyID:      "app-2",
+			expectedAudience:   []string{"shop"},
+			expectedTTL:        5 * time.Minute,
+			expectedServiceErr: jwt.ErrTokenSignatureInvalid,
+		},
+		{
+			nameTest: "App without secret",
+			appID:    3,
+			mockAppProvider: func(s *mocks.AppProvider) {
+				s.EXPECT().App(ctx, 3).Return(models.App{ID: 3, Name: "blog"}, nil)
+			},
+			expectedKeyID:    key.ID,
+			expectedAudience: []string{"auth"},
+			expectedTTL:      time.Hour,
+		},
+		{
+			nameTest: "Unknown app",
+			appID:    4,
+			mockAppProvider: func(s *mocks.AppProvider) {
+				s.EXPECT().App(ctx, 4).Return(models.App{}, storage.ErrAppNotFound).Once()
+			},
+			expectedErr: ErrInvalidAppID,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nameTest, func(t *testing.T) {
+			appProvider := mocks.NewAppProvider(t)
+			if tc.mockAppProvider != nil {
+				tc.mockAppProvider(appProvider)
+			}
+
+			userProvider := mocks.NewUserProvider(t)
+			refreshTokens := mocks.NewRefreshTokenStorage(t)
+			if tc.expectedErr == nil {
+				userProvider.EXPECT().User(ctx, user.Username).Return(user, nil).Once()
+				refreshTokens.EXPECT().
+					SaveRefreshToken(ctx, mock.MatchedBy(func(token models.RefreshToken) bool {
+						return token.AppID == tc.appID
+					})).
+					Return(nil).Once()
+			}
+
+			revoker := mocks.NewTokenRevoker(t)
+			revoker.EXPECT().IsTokenRevoked(ctx, mock.Anything).Return(false, nil).Maybe()
+			revoker.EXPECT().UserTokenVersion(ctx, user.ID).Return(0, nil).Maybe()
+
+			roleProvider := mocks.NewRoleProvider(t)
+			roleProvider.EXPECT().UserRoles(ctx, user.ID).Return([]string{"admin"}, nil).Maybe()
+			roleProvider.EXPECT().UserPermissions(ctx, user.ID).Return([]string{"users.read"}, nil).Maybe()
+
+			s := Auth{
+				UserProvider:    userProvider,
+				refreshTokens:   refreshTokens,
+				revoker:         revoker,
+				appProvider:     appProvider,
+				roleProvider:    roleProvider,
+				hasher:          password.NewHasher(password.Bcrypt{Cost: bcrypt.MinCost}),
+				log:             log,
+				TokenTTL:        time.Hour,
+				RefreshTokenTTL: 24 * time.Hour,
+				keys:            jwt.NewKeyRing(key),
+				issuer:          "auth",
+				audience:        []string{"auth"},
+			}
+
+			tokens, err := s.Login(ctx, user.Username, "123456", tc.appID, "")
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			kid, err := jwt.KeyID(tokens.AccessToken)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedKeyID, kid)
+
+			_, err = s.ValidateToken(ctx, tokens.AccessToken)
+			if tc.expectedServiceErr != nil {
+				assert.ErrorIs(t, err, tc.expectedServiceErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			claims, err := s.ValidateAppToken(ctx, tokens.AccessToken, tc.appID)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedAudience, []string(claims.Audience))
+			assert.Equal(t, tc.expectedTTL, claims.ExpiresAt.Sub(claims.IssuedAt.Time))
+			assert.Equal(t, "1", claims.Subject)
+			assert.Equal(t, []string{"admin"}, claims.Roles)
+			assert.Equal(t, []string{"users.read"}, claims.Permissions)
+		})
+	}
+}
+
+func Test_Auth_Login_Rehash(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(
+		slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+	)
+
+	user := models.User{ID: 1, Name: "Matvey", Username: "MatveyTabby", PassHash: []byte("old-hash")}
+
+	tests := []struct {
+		nameTest      string
+		mockHasher    func(s *mocks.PasswordHasher)
+		mockUserSaver func(s *mocks.UserSaver)
+	}{
+		{
+			nameTest: "Up to date hash",
+			mockHasher: func(s *mocks.PasswordHasher) {
+				s.EXPECT().NeedsRehash(user.PassHash).Return(false).Once()
+			},
+		},
+		{
+			nameTest: "Outdated hash is replaced",
+			mockHasher: func(s *mocks.PasswordHasher) {
+				s.EXPECT().NeedsRehash(user.PassHash).Return(true).Once()
+				s.EXPECT().Hash("123456").Return([]byte("new-hash"), nil).Once()
+			},
+			mockUserSaver: func(s *mocks.UserSaver) {
+				s.EXPECT().UpdatePasswordHash(ctx, user.ID, []byte("new-hash")).Return(nil).Once()
+			},
+		},
+		{
+			nameTest: "Failed update doesn't fail login",
+			mockHasher: func(s *mocks.PasswordHasher) {
+				s.EXPECT().NeedsRehash(user.PassHash).Return(true).Once()
+				s.EXPECT().Hash("123456").Return([]byte("new-hash"), nil).Once()
+			},
+			mockUserSaver: func(s *mocks.UserSaver) {
+				s.EXPECT().UpdatePasswordHash(ctx, user.ID, []byte("new-hash")).Return(fmt.Errorf("storage is down")).Once()
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nameTest, func(t *testing.T) {
+			hasher := mocks.NewPasswordHasher(t)
+			hasher.EXPECT().Verify("123456", user.PassHash).Return(nil).Once()
+			tc.mockHasher(hasher)
+
+			userSaver := mocks.NewUserSaver(t)
+			if tc.mockUserSaver != nil {
+				tc.mockUserSaver(userSaver)
+			}
+
+			userProvider := mocks.NewUserProvider(t)
+			userProvider.EXPECT().User(ctx, user.Username).Return(user, nil).Once()
+
+			refreshTokens := mocks.NewRefreshTokenStorage(t)
+			refreshTokens.EXPECT().SaveRefreshToken(ctx, mock.Anything).Return(nil).Once()
+
+			roleProvider := mocks.NewRoleProvider(t)
+			roleProvider.EXPECT().UserRoles(ctx, user.ID).Return(nil, nil).Maybe()
+			roleProvider.EXPECT().UserPermissions(ctx, user.ID).Return(nil, nil).Maybe()
+
+			s := Auth{
+				UserProvider:    userProvider,
+				UserSaver:       userSaver,
+				refreshTokens:   refreshTokens,
+				roleProvider:    roleProvider,
+				hasher:          hasher,
+				log:             log,
+				RefreshTokenTTL: 24 * time.Hour,
+				keys:            jwt.NewKeyRing(jwt.Key{ID: "test", Secret: []byte("test-signing-key")}),
+			}
+
+			tokens, err := s.Login(ctx, user.Username, "123456", 0, "")
+
+			assert.NoError(t, err)
+			assert.NotEmpty(t, tokens.AccessToken)
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+// Flags of authenticator data.
+const (
+	flagUserPresent            = 0x01
+	flagUserVerified           = 0x04
+	flagAttestedCredentialData = 0x40
+)
+
+// fakeAuthenticator is a software platform authenticator with one ES256 credential.
+// It produces the JSON a browser sends after navigator.credentials.create and get,
+// so WebAuthn ceremonies are tested end to end without hardware.
+type fakeAuthenticator struct {
+	t            *testing.T
+	origin       string
+	credentialID []byte
+	key          *ecdsa.PrivateKey
+	signCount    uint32
+	userVerified bool
+}
+
+func newFakeAuthenticator(t *testing.T, origin string) *fakeAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	credentialID := make([]byte, 16)
+	_, err = rand.Read(credentialID)
+	require.NoError(t, err)
+
+	return &fakeAuthenticator{
+		t:            t,
+		origin:       origin,
+		credentialID: credentialID,
+		key:          key,
+		userVerified: true,
+	}
+}
+
+// create answers the options of a registration with a new credential, attested with the "none" format.
+func (a *fakeAuthenticator) create(options []byte) []byte {
+	var creation protocol.CredentialCreation
+	require.NoError(a.t, json.Unmarshal(options, &creation))
+
+	clientData := a.clientData(protocol.CreateCeremony, creation.Response.Challenge)
+
+	publicKey, err := cbor.Marshal(map[int]any{
+		1:  2,  // kty: EC2
+		3:  -7, // alg: ES256
+		-1: 1,  // crv: P-256
+		-2: a.key.X.FillBytes(make([]byte, 32)),
+		-3: a.key.Y.FillBytes(make([]byte, 32)),
+	})
+	require.NoError(a.t, err)
+
+	authData := a.authData(creation.Response.RelyingParty.ID, flagAttestedCredentialData)
+	authData = append(authData, make([]byte, 16)...) // AAGUID
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(a.credentialID)))
+	authData = append(authData, a.credentialID...)
+	authData = append(authData, publicKey...)
+
+	attestationObject, err := cbor.Marshal(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": authData,
+	})
+	require.NoError(a.t, err)
+
+	return a.credential(map[string]any{
+		"clientDataJSON":    encode(clientData),
+		"attestationObject": encode(attestationObject),
+		"transports":        []string{"internal"},
+	})
+}
+
+// get answers the options of a login with an assertion signed by the credential.
+func (a *fakeAuthenticator) get(options []byte, rpID string) []byte {
+	var assertion protocol.CredentialAssertion
+	require.NoError(a.t, json.Unmarshal(options, &assertion))
+
+	clientData := a.clientData(protocol.AssertCeremony, assertion.Response.Challenge)
+	authData := a.authData(rpID, 0)
+
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte(nil), authData...), clientDataHash[:]...))
+
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	require.NoError(a.t, err)
+
+	return a.credential(map[string]any{
+		"clientDataJSON":    encode(clientData),
+		"authenticatorData": encode(authData),
+		"signature":         encode(signature),
+	})
+}
+
+func (a *fakeAuthenticator) clientData(ceremony protocol.CeremonyType, challenge protocol.URLEncodedBase64) []byte {
+	clientData, err := json.Marshal(map[string]any{
+		"type":      ceremony,
+		"challenge": encode(challenge),
+		"origin":    a.origin,
+	})
+	require.NoError(a.t, err)
+
+	return clientData
+}
+
+// authData returns the authenticator data up to the attested credential data,
+// counting a new signature.
+func (a *fakeAuthenticator) authData(rpID string, flags byte) []byte {
+	a.signCount++
+
+	flags |= flagUserPresent
+	if a.userVerified {
+		flags |= flagUserVerified
+	}
+
+	rpIDHash := sha256.Sum256([]byte(rpID))
+
+	authData := append([]byte(nil), rpIDHash[:]...)
+	authData = append(authData, flags)
+
+	return binary.BigEndian.AppendUint32(authData, a.signCount)
+}
+
+func (a *fakeAuthenticator) credential(response map[string]any) []byte {
+	credential, err := json.Marshal(map[string]any{
+		"id":       encode(a.credentialID),
+		"rawId":    encode(a.credentialID),
+		"type":     "public-key",
+		"response": response,
+	})
+	require.NoError(a.t, err)
+
+	return credential
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
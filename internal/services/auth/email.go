@@ -0,0 +1,302 @@
+package auth
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// purposeEmailVerification is the purpose of one-time tokens verifying an email.
+const purposeEmailVerification = "email_verification"
+
+var (
+	ErrInvalidEmail             = errors.New("invalid email")
+	ErrEmailExists              = errors.New("email already exists")
+	ErrEmailNotVerified         = errors.New("email is not verified")
+	ErrInvalidVerificationToken = errors.New("invalid email verification token")
+)
+
+// ChangeEmail sets the email of the user and sends a token to verify it with.
+// The email stays unverified until ConfirmEmail is called with the token,
+// tokens sent for the previous email stop working.
+func (a *Auth) ChangeEmail(
+	ctx context.Context,
+	uid int,
+	email string,
+) error {
+	const op = "auth.ChangeEmail"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int("uid", uid),
+	)
+	log.Info("setting email")
+
+	email, err := normalizeEmail(email)
+	if err != nil {
+		log.Info("invalid email", "", err.Error())
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.UserSaver.SetEmail(ctx, uid, email); err != nil {
+		switch {
+		case errors.Is(err, storage.ErrEmailExists):
+			log.Warn("email already exists")
+			return fmt.Errorf("%s: %w", op, ErrEmailExists)
+		case errors.Is(err, storage.ErrUserNotFound):
+			log.Warn("user not found")
+			return fmt.Errorf("%s: %w", op, ErrUserNotFound)
+		}
+
+		log.Error("failed to set email", "", err.Error())
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := a.UserProvider.UserByID(ctx, uid)
+	if err != nil {
+		log.Error("failed to get user", "", err.Error())
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.sendVerificationToken(ctx, user); err != nil {
+		log.Error("failed to send verification token", "", err.Error())
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("verification token sent")
+
+	return nil
+}
+
+// ResendVerification sends a new token to verify the current email of the user with.
+func (a *Auth) ResendVerification(
+	ctx context.Context,
+	uid int,
+) error {
+	const op = "auth.ResendVerification"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int("uid", uid),
+	)
+
+	user, err := a.UserProvider.UserByID(ctx, uid)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("user not found")
+			return fmt.Errorf("%s: %w", op, ErrUserNotFound)
+		}
+
+		log.Error("failed to get user", "", err.Error())
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if user.Email == "" {
+		log.Info("user has no email")
+		return fmt.Errorf("%s: %w", op, ErrInvalidEmail)
+	}
+
+	if !user.EmailVerifiedAt.IsZero() {
+		log.Info("email is verified already")
+		return nil
+	}
+
+	if err := a.sendVerificationToken(ctx, user); err != nil {
+		log.Error("failed to send verification token", "", err.Error())
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("verification token sent")
+
+	return nil
+}
+
+// RequestEmailVerification sends a new token to verify the email of the user with, without logging in,
+// so a user who can't log in until the email is verified can get another token.
+// Like RequestPasswordReset it doesn't reveal which accounts exist: the result is the same for unknown
+// users, users without an email and verified ones, and the token is delivered in the background.
+func (a *Auth) RequestEmailVerification(
+	ctx context.Context,
+	username string,
+) error {
+	const op = "auth.RequestEmailVerification"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.String("username", username),
+	)
+	log.Info("email verification requested")
+
+	user, err := a.UserProvider.User(ctx, username)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("user not found")
+			return nil
+		}
+
+		log.Error("failed to get user", "", err.Error())
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if user.Email == "" || !user.EmailVerifiedAt.IsZero() {
+		log.Info("no email to verify")
+		return nil
+	}
+
+	a.sendVerificationInBackground(ctx, log, user)
+
+	return nil
+}
+
+// ConfirmEmail marks the email the token was sent to verified.
+func (a *Auth) ConfirmEmail(
+	ctx context.Context,
+	token string,
+) error {
+	const op = "auth.ConfirmEmail"
+
+	log := a.log.With(slog.String("op", op))
+
+	stored, err := a.oneTimeTokens.OneTimeToken(ctx, hashOpaqueToken(token), purposeEmailVerification)
+	if err != nil {
+		if errors.Is(err, storage.ErrOneTimeTokenNotFound) {
+			log.Warn("unknown verification token")
+			return fmt.Errorf("%s: %w", op, ErrInvalidVerificationToken)
+		}
+
+		log.Error("failed to get verification token", "", err.Error())
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int("uid", stored.UserID))
+
+	if !stored.UsedAt.IsZero() || time.Now().After(stored.ExpiresAt) {
+		log.Warn("verification token is used or expired")
+		return fmt.Errorf("%s: %w", op, ErrInvalidVerificationToken)
+	}
+
+	user, err := a.UserProvider.UserByID(ctx, stored.UserID)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("user not found")
+			return fmt.Errorf("%s: %w", op, ErrInvalidVerificationToken)
+		}
+
+		log.Error("failed to get user", "", err.Error())
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.oneTimeTokens.UseOneTimeToken(ctx, stored.ID); err != nil {
+		if errors.Is(err, storage.ErrOneTimeTokenUsed) {
+			log.Warn("verification token used concurrently")
+			return fmt.Errorf("%s: %w", op, ErrInvalidVerificationToken)
+		}
+
+		log.Error("failed to use verification token", "", err.Error())
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// The email is passed along, so a token can't verify an email set after it was sent.
+	if err := a.UserSaver.VerifyEmail(ctx, user.ID, user.Email, time.Now()); err != nil {
+		switch {
+		case errors.Is(err, storage.ErrUserNotFound):
+			log.Warn("email changed since the token was sent")
+			return fmt.Errorf("%s: %w", op, ErrInvalidVerificationToken)
+		case errors.Is(err, storage.ErrEmailExists):
+			log.Warn("email verified by another user")
+			return fmt.Errorf("%s: %w", op, ErrEmailExists)
+		}
+
+		log.Error("failed to verify email", "", err.Error())
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("email verified")
+
+	return nil
+}
+
+// sendVerificationToken issues a token verifying the current email of the user,
+// dropping the ones issued before, and delivers it.
+func (a *Auth) sendVerificationToken(ctx context.Context, user models.User) error {
+	if err := a.oneTimeTokens.DeleteUserOneTimeTokens(ctx, user.ID, purposeEmailVerification); err != nil {
+		return err
+	}
+
+	token, err := newOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().Add(a.VerificationTokenTTL)
+
+	if err := a.oneTimeTokens.SaveOneTimeToken(ctx, models.OneTimeToken{
+		UserID:    user.ID,
+		Purpose:   purposeEmailVerification,
+		TokenHash: hashOpaqueToken(token),
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return err
+	}
+
+	return a.notifier.Notify(ctx, models.Notification{
+		Kind:      models.NotificationEmailVerification,
+		UserID:    user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		Token:     token,
+		ExpiresAt: expiresAt,
+	})
+}
+
+// sendVerificationInBackground delivers a verification token to the user without holding up the caller,
+// a failure is only logged. Stop waits for the delivery.
+func (a *Auth) sendVerificationInBackground(ctx context.Context, log *slog.Logger, user models.User) {
+	a.background.Add(1)
+	go func() {
+		defer a.background.Done()
+
+		if err := a.sendVerificationToken(context.WithoutCancel(ctx), user); err != nil {
+			log.Error("failed to send verification token", "", err.Error())
+			return
+		}
+
+		log.Info("verification token sent")
+	}()
+}
+
+// userByLogin returns the user by the email if the login looks like one, by the username otherwise.
+// Only verified emails identify a user, any number of users may claim an unverified one.
+func (a *Auth) userByLogin(ctx context.Context, login string) (models.User, error) {
+	if strings.Contains(login, "@") {
+		return a.UserProvider.UserByEmail(ctx, login)
+	}
+
+	return a.UserProvider.User(ctx, login)
+}
+
+// normalizeEmail checks that the email is a bare address and returns it without surrounding spaces.
+// The case is kept, emails are compared case-insensitively.
+func normalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return "", ErrInvalidEmail
+	}
+
+	return email, nil
+}
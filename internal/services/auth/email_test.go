@@ -0,0 +1,509 @@
+package auth
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/jwt"
+	"auth/internal/password"
+	"auth/internal/services/auth/mocks"
+	"auth/internal/storage"
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func Test_Auth_ChangeEmail(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(
+		slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+	)
+
+	const email = "matvey@example.com"
+
+	user := models.User{ID: 1, Name: "Matvey", Username: "MatveyTabby", Email: email}
+
+	tests := []struct {
+		nameTest          string
+		email             string
+		mockUserSaver     func(s *mocks.UserSaver)
+		mockProvider      func(s *mocks.UserProvider)
+		mockOneTimeTokens func(s *mocks.OneTimeTokenStorage)
+		mockNotifier      func(s *mocks.Notifier)
+		expectedErr       error
+	}{
+		{
+			nameTest: "Success",
+			email:    " " + email + " ",
+			mockUserSaver: func(s *mocks.UserSaver) {
+				s.EXPECT().SetEmail(ctx, user.ID, email).Return(nil).Once()
+			},
+			mockProvider: func(s *mocks.UserProvider) {
+				s.EXPECT().UserByID(ctx, user.ID).Return(user, nil).Once()
+			},
+			mockOneTimeTokens: func(s *mocks.OneTimeTokenStorage) {
+				s.EXPECT().DeleteUserOneTimeTokens(ctx, user.ID, purposeEmailVerification).Return(nil).Once()
+				s.EXPECT().
+					SaveOneTimeToken(ctx, mock.MatchedBy(func(token models.OneTimeToken) bool {
+						return token.UserID == user.ID && token.Purpose == purposeEmailVerification && len(token.TokenHash) > 0
+					})).
+					Return(nil).Once()
+			},
+			mockNotifier: func(s *mocks.Notifier) {
+				s.EXPECT().
+					Notify(ctx, mock.MatchedBy(func(n models.Notification) bool {
+						return n.Kind == models.NotificationEmailVerification && n.Email == email && n.Token != ""
+					})).
+					Return(nil).Once()
+			},
+		},
+		{
+			nameTest:    "Invalid email",
+			email:       "Matvey <matvey@example.com>",
+			expectedErr: ErrInvalidEmail,
+		},
+		{
+			nameTest:    "Not an email",
+			email:       "MatveyTabby",
+			expectedErr: ErrInvalidEmail,
+		},
+		{
+			nameTest: "Email taken",
+			email:    email,
+			mockUserSaver: func(s *mocks.UserSaver) {
+				s.EXPECT().SetEmail(ctx, user.ID, email).Return(storage.ErrEmailExists).Once()
+			},
+			expectedErr: ErrEmailExists,
+		},
+		{
+			nameTest: "Unknown user",
+			email:    email,
+			mockUserSaver: func(s *mocks.UserSaver) {
+				s.EXPECT().SetEmail(ctx, user.ID, email).Return(storage.ErrUserNotFound).Once()
+			},
+			expectedErr: ErrUserNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nameTest, func(t *testing.T) {
+			userSaver := mocks.NewUserSaver(t)
+			if tc.mockUserSaver != nil {
+				tc.mockUserSaver(userSaver)
+			}
+
+			userProvider := mocks.NewUserProvider(t)
+			if tc.mockProvider != nil {
+				tc.mockProvider(userProvider)
+			}
+
+			oneTimeTokens := mocks.NewOneTimeTokenStorage(t)
+			if tc.mockOneTimeTokens != nil {
+				tc.mockOneTimeTokens(oneTimeTokens)
+			}
+
+			notifier := mocks.NewNotifier(t)
+			if tc.mockNotifier != nil {
+				tc.mockNotifier(notifier)
+			}
+
+			s := Auth{
+				UserProvider:         userProvider,
+				UserSaver:            userSaver,
+				oneTimeTokens:        oneTimeTokens,
+				notifier:             notifier,
+				VerificationTokenTTL: 24 * time.Hour,
+				log:                  log,
+			}
+
+			err := s.ChangeEmail(ctx, user.ID, tc.email)
+
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func Test_Auth_ConfirmEmail(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(
+		slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+	)
+
+	const token = "verification-token"
+
+	user := models.User{ID: 1, Name: "Matvey", Username: "MatveyTabby", Email: "matvey@example.com"}
+	stored := models.OneTimeToken{
+		ID:        10,
+		UserID:    user.ID,
+		Purpose:   purposeEmailVerification,
+		TokenHash: hashOpaqueToken(token),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	tests := []struct {
+		nameTest          string
+		mockOneTimeTokens func(s *mocks.OneTimeTokenStorage)
+		mockProvider      func(s *mocks.UserProvider)
+		mockUserSaver     func(s *mocks.UserSaver)
+		expectedErr       error
+	}{
+		{
+			nameTest: "Success",
+			mockOneTimeTokens: func(s *mocks.OneTimeTokenStorage) {
+				s.EXPECT().OneTimeToken(ctx, hashOpaqueToken(token), purposeEmailVerification).Return(stored, nil).Once()
+				s.EXPECT().UseOneTimeToken(ctx, stored.ID).Return(nil).Once()
+			},
+			mockProvider: func(s *mocks.UserProvider) {
+				s.EXPECT().UserByID(ctx, user.ID).Return(user, nil).Once()
+			},
+			mockUserSaver: func(s *mocks.UserSaver) {
+				s.EXPECT().VerifyEmail(ctx, user.ID, user.Email, mock.Anything).Return(nil).Once()
+			},
+		},
+		{
+			nameTest: "Unknown token",
+			mockOneTimeTokens: func(s *mocks.OneTimeTokenStorage) {
+				s.EXPECT().OneTimeToken(ctx, mock.Anything, purposeEmailVerification).Return(models.OneTimeToken{}, storage.ErrOneTimeTokenNotFound).Once()
+			},
+			expectedErr: ErrInvalidVerificationToken,
+		},
+		{
+			nameTest: "Expired token",
+			mockOneTimeTokens: func(s *mocks.OneTimeTokenStorage) {
+				expired := stored
+				expired.ExpiresAt = time.Now().Add(-time.Minute)
+				s.EXPECT().OneTimeToken(ctx, mock.Anything, purposeEmailVerification).Return(expired, nil).Once()
+			},
+			expectedErr: ErrInvalidVerificationToken,
+		},
+		{
+			nameTest: "Used token",
+			mockOneTimeTokens: func(s *mocks.OneTimeTokenStorage) {
+				used := stored
+				used.UsedAt = time.Now().Add(-time.Minute)
+				s.EXPECT().OneTimeToken(ctx, mock.Anything, purposeEmailVerification).Return(used, nil).Once()
+			},
+			expectedErr: ErrInvalidVerificationToken,
+		},
+		{
+			nameTest: "Email changed since",
+			mockOneTimeTokens: func(s *mocks.OneTimeTokenStorage) {
+				s.EXPECT().OneTimeToken(ctx, mock.Anything, purposeEmailVerification).Return(stored, nil).Once()
+				s.EXPECT().UseOneTimeToken(ctx, stored.ID).Return(nil).Once()
+			},
+			mockProvider: func(s *mocks.UserProvider) {
+				s.EXPECT().UserByID(ctx, user.ID).Return(user, nil).Once()
+			},
+			mockUserSaver: func(s *mocks.UserSaver) {
+				s.EXPECT().VerifyEmail(ctx, user.ID, user.Email, mock.Anything).Return(storage.ErrUserNotFound).Once()
+			},
+			expectedErr: ErrInvalidVerificationToken,
+		},
+		{
+			nameTest: "Email verified by another user",
+			mockOneTimeTokens: func(s *mocks.OneTimeTokenStorage) {
+				s.EXPECT().OneTimeToken(ctx, mock.Anything, purposeEmailVerification).Return(stored, nil).Once()
+				s.EXPECT().UseOneTimeToken(ctx, stored.ID).Return(nil).Once()
+			},
+			mockProvider: func(s *mocks.UserProvider) {
+				s.EXPECT().UserByID(ctx, user.ID).Return(user, nil).Once()
+			},
+			mockUserSaver: func(s *mocks.UserSaver) {
+				s.EXPECT().VerifyEmail(ctx, user.ID, user.Email, mock.Anything).Return(storage.ErrEmailExists).Once()
+			},
+			expectedErr: ErrEmailExists,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nameTest, func(t *testing.T) {
+			oneTimeTokens := mocks.NewOneTimeTokenStorage(t)
+			tc.mockOneTimeTokens(oneTimeTokens)
+
+			userProvider := mocks.NewUserProvider(t)
+			if tc.mockProvider != nil {
+				tc.mockProvider(userProvider)
+			}
+
+			userSaver := mocks.NewUserSaver(t)
+			if tc.mockUserSaver != nil {
+				tc.mockUserSaver(userSaver)
+			}
+
+			s := Auth{
+				UserProvider:  userProvider,
+				UserSaver:     userSaver,
+				oneTimeTokens: oneTimeTokens,
+				log:           log,
+			}
+
+			err := s.ConfirmEmail(ctx, token)
+
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func Test_Auth_Login_Email(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(
+		slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+	)
+
+	const pass = "123456"
+
+	passHash, err := bcrypt.GenerateFromPassword([]byte(pass), bcrypt.MinCost)
+	assert.NoError(t, err)
+
+	unverified := models.User{ID: 1, Name: "Matvey", Username: "MatveyTabby", PassHash: passHash, Email: "matvey@example.com"}
+	verified := unverified
+	verified.EmailVerifiedAt = time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		nameTest        string
+		login           string
+		requireVerified bool
+		mockProvider    func(s *mocks.UserProvider)
+		expectedErr     error
+	}{
+		{
+			nameTest: "Login by email",
+			login:    "Matvey@Example.com",
+			mockProvider: func(s *mocks.UserProvider) {
+				s.EXPECT().UserByEmail(ctx, "Matvey@Example.com").Return(verified, nil).Once()
+			},
+		},
+		{
+			nameTest: "Unverified email is no login",
+			login:    unverified.Email,
+			mockProvider: func(s *mocks.UserProvider) {
+				s.EXPECT().UserByEmail(ctx, unverified.Email).Return(models.User{}, storage.ErrUserNotFound).Once()
+			},
+			expectedErr: ErrInvalidCredentials,
+		},
+		{
+			nameTest:        "Unverified email",
+			login:           unverified.Username,
+			requireVerified: true,
+			mockProvider: func(s *mocks.UserProvider) {
+				s.EXPECT().User(ctx, unverified.Username).Return(unverified, nil).Once()
+			},
+			expectedErr: ErrEmailNotVerified,
+		},
+		{
+			nameTest:        "Verified email",
+			login:           verified.Email,
+			requireVerified: true,
+			mockProvider: func(s *mocks.UserProvider) {
+				s.EXPECT().UserByEmail(ctx, verified.Email).Return(verified, nil).Once()
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nameTest, func(t *testing.T) {
+			userProvider := mocks.NewUserProvider(t)
+			tc.mockProvider(userProvider)
+
+			refreshTokens := mocks.NewRefreshTokenStorage(t)
+			refreshTokens.EXPECT().SaveRefreshToken(ctx, mock.Anything).Return(nil).Maybe()
+
+			roleProvider := mocks.NewRoleProvider(t)
+			roleProvider.EXPECT().UserRoles(ctx, mock.Anything).Return(nil, nil).Maybe()
+			roleProvider.EXPECT().UserPermissions(ctx, mock.Anything).Return(nil, nil).Maybe()
+
+			s := Auth{
+				UserProvider:         userProvider,
+				refreshTokens:        refreshTokens,
+				roleProvider:         roleProvider,
+				hasher:               password.NewHasher(password.Bcrypt{Cost: bcrypt.MinCost}),
+				requireVerifiedEmail: tc.requireVerified,
+				log:                  log,
+				keys:                 jwt.NewKeyRing(jwt.Key{ID: "test", Secret: []byte("test-signing-key")}),
+			}
+
+			tokens, err := s.Login(ctx, tc.login, pass, 0, "")
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Equal(t, models.TokenPair{}, tokens)
+			} else {
+				assert.NoError(t, err)
+				assert.NotEmpty(t, tokens.AccessToken)
+			}
+		})
+	}
+}
+
+// Test_Auth_RegisterVerifyLogin goes the whole way of a user who can't log in until the email is verified:
+// the email given at registration gets a token, and the token lets the user log in.
+func Test_Auth_RegisterVerifyLogin(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(
+		slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+	)
+
+	const email = "matvey@example.com"
+
+	var (
+		user   models.User
+		stored models.OneTimeToken
+		sent   string
+	)
+
+	userSaver := mocks.NewUserSaver(t)
+	userSaver.EXPECT().SaveUser(ctx, "Matvey", "MatveyTabby", email, mock.Anything).
+		RunAndReturn(func(_ context.Context, name, username, email string, passHash []byte) (int, error) {
+			user = models.User{ID: 1, Name: name, Username: username, Email: email, PassHash: passHash}
+			return user.ID, nil
+		}).Once()
+	userSaver.EXPECT().VerifyEmail(ctx, 1, email, mock.Anything).
+		RunAndReturn(func(_ context.Context, _ int, _ string, verifiedAt time.Time) error {
+			user.EmailVerifiedAt = verifiedAt
+			return nil
+		}).Once()
+
+	userProvider := mocks.NewUserProvider(t)
+	userProvider.EXPECT().User(ctx, "MatveyTabby").
+		RunAndReturn(func(context.Context, string) (models.User, error) { return user, nil }).Twice()
+	userProvider.EXPECT().UserByID(ctx, 1).
+		RunAndReturn(func(context.Context, int) (models.User, error) { return user, nil }).Once()
+
+	// The token is sent in the background, with a context that outlives the request.
+	oneTimeTokens := mocks.NewOneTimeTokenStorage(t)
+	oneTimeTokens.EXPECT().DeleteUserOneTimeTokens(mock.Anything, 1, purposeEmailVerification).Return(nil).Once()
+	oneTimeTokens.EXPECT().SaveOneTimeToken(mock.Anything, mock.Anything).
+		RunAndReturn(func(_ context.Context, token models.OneTimeToken) error {
+			token.ID = 1
+			stored = token
+			return nil
+		}).Once()
+	oneTimeTokens.EXPECT().OneTimeToken(ctx, mock.Anything, purposeEmailVerification).
+		RunAndReturn(func(_ context.Context, tokenHash []byte, _ string) (models.OneTimeToken, error) {
+			if string(tokenHash) != string(stored.TokenHash) {
+				return models.OneTimeToken{}, storage.ErrOneTimeTokenNotFound
+			}
+			return stored, nil
+		}).Once()
+	oneTimeTokens.EXPECT().UseOneTimeToken(ctx, int64(1)).Return(nil).Once()
+
+	notifier := mocks.NewNotifier(t)
+	notifier.EXPECT().Notify(mock.Anything, mock.Anything).
+		RunAndReturn(func(_ context.Context, n models.Notification) error {
+			assert.Equal(t, email, n.Email)
+			sent = n.Token
+			return nil
+		}).Once()
+
+	refreshTokens := mocks.NewRefreshTokenStorage(t)
+	refreshTokens.EXPECT().SaveRefreshToken(ctx, mock.Anything).Return(nil).Maybe()
+
+	roleProvider := mocks.NewRoleProvider(t)
+	roleProvider.EXPECT().UserRoles(ctx, 1).Return(nil, nil).Maybe()
+	roleProvider.EXPECT().UserPermissions(ctx, 1).Return(nil, nil).Maybe()
+
+	s := Auth{
+		UserProvider:         userProvider,
+		UserSaver:            userSaver,
+		refreshTokens:        refreshTokens,
+		roleProvider:         roleProvider,
+		oneTimeTokens:        oneTimeTokens,
+		notifier:             notifier,
+		hasher:               password.NewHasher(password.Bcrypt{Cost: bcrypt.MinCost}),
+		passwordPolicy:       password.Policy{MinLength: 8},
+		requireVerifiedEmail: true,
+		VerificationTokenTTL: time.Hour,
+		log:                  log,
+		keys:                 jwt.NewKeyRing(jwt.Key{ID: "test", Secret: []byte("test-signing-key")}),
+	}
+
+	id, err := s.RegisterNewUser(ctx, "Matvey", "MatveyTabby", email, "correct horse")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, id)
+
+	s.Stop()
+	assert.NotEmpty(t, sent)
+
+	_, err = s.Login(ctx, "MatveyTabby", "correct horse", 0, "")
+	assert.ErrorIs(t, err, ErrEmailNotVerified)
+
+	assert.NoError(t, s.ConfirmEmail(ctx, sent))
+
+	tokens, err := s.Login(ctx, "MatveyTabby", "correct horse", 0, "")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, tokens.AccessToken)
+}
+
+func Test_Auth_RegisterNewUser_RequiredEmail(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(
+		slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+	)
+
+	s := Auth{
+		UserSaver:            mocks.NewUserSaver(t),
+		hasher:               password.NewHasher(password.Bcrypt{Cost: bcrypt.MinCost}),
+		requireVerifiedEmail: true,
+		log:                  log,
+	}
+
+	for _, email := range []string{"", "not an email"} {
+		id, err := s.RegisterNewUser(ctx, "Matvey", "MatveyTabby", email, "correct horse")
+
+		assert.ErrorIs(t, err, ErrInvalidEmail, "email: %q", email)
+		assert.Equal(t, 0, id)
+	}
+}
+
+func Test_Auth_RequestEmailVerification(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(
+		slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+	)
+
+	user := models.User{ID: 1, Name: "Matvey", Username: "MatveyTabby", Email: "matvey@example.com"}
+
+	verified := user
+	verified.EmailVerifiedAt = time.Now()
+
+	tests := []struct {
+		nameTest     string
+		user         models.User
+		userErr      error
+		expectedSent bool
+	}{
+		{nameTest: "Unverified email", user: user, expectedSent: true},
+		{nameTest: "Verified email", user: verified},
+		{nameTest: "No email", user: models.User{ID: 1, Username: "MatveyTabby"}},
+		{nameTest: "Unknown user", userErr: storage.ErrUserNotFound},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nameTest, func(t *testing.T) {
+			userProvider := mocks.NewUserProvider(t)
+			userProvider.EXPECT().User(ctx, "MatveyTabby").Return(tc.user, tc.userErr).Once()
+
+			oneTimeTokens := mocks.NewOneTimeTokenStorage(t)
+			notifier := mocks.NewNotifier(t)
+			if tc.expectedSent {
+				oneTimeTokens.EXPECT().DeleteUserOneTimeTokens(mock.Anything, user.ID, purposeEmailVerification).Return(nil).Once()
+				oneTimeTokens.EXPECT().SaveOneTimeToken(mock.Anything, mock.Anything).Return(nil).Once()
+				notifier.EXPECT().Notify(mock.Anything, mock.Anything).Return(nil).Once()
+			}
+
+			s := Auth{
+				UserProvider:  userProvider,
+				oneTimeTokens: oneTimeTokens,
+				notifier:      notifier,
+				log:           log,
+			}
+
+			assert.NoError(t, s.RequestEmailVerification(ctx, "MatveyTabby"))
+			s.Stop()
+		})
+	}
+}
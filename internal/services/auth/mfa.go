@@ -0,0 +1,452 @@
+package auth
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/storage"
+	"auth/internal/totp"
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// purposeMFA is the purpose of one-time tokens finishing a login with a second factor.
+const purposeMFA = "mfa"
+
+const (
+	// recoveryCodeCount is the number of recovery codes issued on enrollment.
+	recoveryCodeCount = 10
+	// recoveryCodeLen is the number of characters in a recovery code, carrying 96 random bits:
+	// the codes are hashed with a fast hash, so they must be too long to guess offline.
+	recoveryCodeLen = 20
+	// recoveryCodeGroup is the number of characters between the dashes a recovery code is shown with.
+	recoveryCodeGroup = 5
+)
+
+var recoveryCodeEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+var (
+	ErrMFAUnavailable  = errors.New("two-factor authentication is not available")
+	ErrMFAEnabled      = errors.New("two-factor authentication already enabled")
+	ErrMFANotEnrolled  = errors.New("two-factor authentication is not enrolled")
+	ErrInvalidMFACode  = errors.New("invalid two-factor authentication code")
+	ErrInvalidMFAToken = errors.New("invalid mfa token")
+)
+
+// MFAStorage keeps the encrypted TOTP secrets of users and their recovery codes.
+// Only hashes of the recovery codes are stored.
+//
+//go:generate go run github.com/vektra/mockery/v2@latest --name=MFAStorage --with-expecter=true
+type MFAStorage interface {
+	SaveTOTP(ctx context.Context,
+		uid int,
+		secret []byte,
+	) error
+
+	TOTP(ctx context.Context,
+		uid int,
+	) (models.TOTP, error)
+
+	EnableTOTP(ctx context.Context,
+		uid int,
+		enabledAt time.Time,
+		step int64,
+		recoveryCodes [][]byte,
+	) error
+
+	UseTOTPStep(ctx context.Context,
+		uid int,
+		step int64,
+	) error
+
+	UseRecoveryCode(ctx context.Context,
+		uid int,
+		codeHash []byte,
+	) error
+}
+
+// SecretBox encrypts the TOTP secrets at rest. Open must be given the additional data passed to Seal.
+//
+//go:generate go run github.com/vektra/mockery/v2@latest --name=SecretBox --with-expecter=true
+type SecretBox interface {
+	Seal(plaintext, additionalData []byte) ([]byte, error)
+	Open(sealed, additionalData []byte) ([]byte, error)
+}
+
+// EnrollTOTP generates a new TOTP secret for the user and returns it for an authenticator app.
+// The secret is asked for on login only after ConfirmTOTP, enrolling again before that replaces it.
+func (a *Auth) EnrollTOTP(
+	ctx context.Context,
+	uid int,
+) (models.TOTPEnrollment, error) {
+	const op = "auth.EnrollTOTP"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int("uid", uid),
+	)
+	log.Info("enrolling totp")
+
+	if a.mfa == nil {
+		return models.TOTPEnrollment{}, fmt.Errorf("%s: %w", op, ErrMFAUnavailable)
+	}
+
+	user, err := a.UserProvider.UserByID(ctx, uid)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("user not found")
+			return models.TOTPEnrollment{}, fmt.Errorf("%s: %w", op, ErrUserNotFound)
+		}
+
+		log.Error("failed to get user", "", err.Error())
+
+		return models.TOTPEnrollment{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		log.Error("failed to generate secret", "", err.Error())
+		return models.TOTPEnrollment{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sealed, err := a.secrets.Seal(secret, totpAdditionalData(uid))
+	if err != nil {
+		log.Error("failed to encrypt secret", "", err.Error())
+		return models.TOTPEnrollment{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.mfa.SaveTOTP(ctx, uid, sealed); err != nil {
+		if errors.Is(err, storage.ErrTOTPEnabled) {
+			log.Info("totp already enabled")
+			return models.TOTPEnrollment{}, fmt.Errorf("%s: %w", op, ErrMFAEnabled)
+		}
+
+		log.Error("failed to save secret", "", err.Error())
+
+		return models.TOTPEnrollment{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("totp enrolled")
+
+	return models.TOTPEnrollment{
+		Secret: totp.EncodeSecret(secret),
+		URI:    totp.URI(a.mfaIssuer, user.Username, secret),
+	}, nil
+}
+
+// ConfirmTOTP enables two-factor authentication once the user proves the authenticator app
+// produces the right codes. It returns the recovery codes, each of them replaces a TOTP code once.
+// They are shown to the user only now, the service keeps just their hashes.
+func (a *Auth) ConfirmTOTP(
+	ctx context.Context,
+	uid int,
+	code string,
+) ([]string, error) {
+	const op = "auth.ConfirmTOTP"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int("uid", uid),
+	)
+	log.Info("confirming totp")
+
+	if a.mfa == nil {
+		return nil, fmt.Errorf("%s: %w", op, ErrMFAUnavailable)
+	}
+
+	stored, err := a.mfa.TOTP(ctx, uid)
+	if err != nil {
+		if errors.Is(err, storage.ErrTOTPNotFound) {
+			log.Info("totp is not enrolled")
+			return nil, fmt.Errorf("%s: %w", op, ErrMFANotEnrolled)
+		}
+
+		log.Error("failed to get totp", "", err.Error())
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !stored.EnabledAt.IsZero() {
+		log.Info("totp already enabled")
+		return nil, fmt.Errorf("%s: %w", op, ErrMFAEnabled)
+	}
+
+	secret, err := a.secrets.Open(stored.Secret, totpAdditionalData(uid))
+	if err != nil {
+		log.Error("failed to decrypt secret", "", err.Error())
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	step, ok := totp.Validate(secret, code, time.Now())
+	if !ok {
+		log.Info("invalid totp code")
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidMFACode)
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		log.Error("failed to generate recovery codes", "", err.Error())
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.mfa.EnableTOTP(ctx, uid, time.Now(), step, hashes); err != nil {
+		if errors.Is(err, storage.ErrTOTPNotFound) {
+			log.Info("totp enabled concurrently")
+			return nil, fmt.Errorf("%s: %w", op, ErrMFAEnabled)
+		}
+
+		log.Error("failed to enable totp", "", err.Error())
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("totp enabled")
+
+	return codes, nil
+}
+
+// VerifyMFA finishes a login of a user with two-factor authentication:
+// it exchanges the MFA token returned by Login and a TOTP or recovery code for tokens of the app.
+// A TOTP code is accepted once, a replay of it or of an earlier code is rejected.
+// An MFA token is used up by the first code checked with it, after a wrong code the user logs in again.
+// Wrong codes count as failed logins of the user like wrong passwords do.
+func (a *Auth) VerifyMFA(
+	ctx context.Context,
+	mfaToken string,
+	code string,
+	clientIP string,
+) (models.TokenPair, error) {
+	const op = "auth.VerifyMFA"
+
+	log := a.log.With(slog.String("op", op))
+
+	challenge, err := a.oneTimeTokens.OneTimeToken(ctx, hashOpaqueToken(mfaToken), purposeMFA)
+	if err != nil {
+		if errors.Is(err, storage.ErrOneTimeTokenNotFound) {
+			log.Warn("unknown mfa token")
+			return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidMFAToken)
+		}
+
+		log.Error("failed to get mfa token", "", err.Error())
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int("uid", challenge.UserID))
+
+	if !challenge.UsedAt.IsZero() || time.Now().After(challenge.ExpiresAt) {
+		log.Warn("mfa token is used or expired")
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidMFAToken)
+	}
+
+	user, err := a.UserProvider.UserByID(ctx, challenge.UserID)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("user not found")
+			return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidMFAToken)
+		}
+
+		log.Error("failed to get user", "", err.Error())
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	reserved, err := a.reserveAttempt(ctx, attemptKeys(user.Username, clientIP))
+	if err != nil {
+		var attemptsErr *AttemptsError
+		if !errors.As(err, &attemptsErr) {
+			log.Error("failed to check login attempts", "", err.Error())
+		}
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// The token is used up before the code is checked, so parallel requests with one token
+	// can't check more than a single code.
+	if err := a.oneTimeTokens.UseOneTimeToken(ctx, challenge.ID); err != nil {
+		a.cancelAttempt(ctx, log, reserved)
+
+		if errors.Is(err, storage.ErrOneTimeTokenUsed) {
+			log.Warn("mfa token used concurrently")
+			return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidMFAToken)
+		}
+
+		log.Error("failed to use mfa token", "", err.Error())
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.checkSecondFactor(ctx, log, user.ID, code); err != nil {
+		if !errors.Is(err, ErrInvalidMFACode) {
+			a.cancelAttempt(ctx, log, reserved)
+		}
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	a.succeedAttempt(ctx, log, reserved)
+
+	params, err := a.tokenParams(ctx, challenge.AppID)
+	if err != nil {
+		log.Error("failed to get app", "", err.Error())
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tokens, err := a.issueTokens(ctx, user, challenge.AppID, params, "")
+	if err != nil {
+		log.Error("failed to issue tokens", "", err.Error())
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("user logged in with second factor")
+
+	return tokens, nil
+}
+
+// checkSecondFactor accepts a TOTP code or, failing that, an unused recovery code of the user.
+func (a *Auth) checkSecondFactor(ctx context.Context, log *slog.Logger, uid int, code string) error {
+	stored, err := a.mfa.TOTP(ctx, uid)
+	if err != nil {
+		if errors.Is(err, storage.ErrTOTPNotFound) {
+			log.Warn("totp disabled since login")
+			return ErrInvalidMFAToken
+		}
+
+		log.Error("failed to get totp", "", err.Error())
+
+		return err
+	}
+
+	if stored.EnabledAt.IsZero() {
+		log.Warn("totp disabled since login")
+		return ErrInvalidMFAToken
+	}
+
+	secret, err := a.secrets.Open(stored.Secret, totpAdditionalData(uid))
+	if err != nil {
+		log.Error("failed to decrypt secret", "", err.Error())
+		return err
+	}
+
+	if step, ok := totp.Validate(secret, code, time.Now()); ok {
+		if err := a.mfa.UseTOTPStep(ctx, uid, step); err != nil {
+			if errors.Is(err, storage.ErrTOTPCodeUsed) {
+				log.Warn("totp code replayed")
+				return ErrInvalidMFACode
+			}
+
+			log.Error("failed to use totp code", "", err.Error())
+
+			return err
+		}
+
+		return nil
+	}
+
+	if err := a.mfa.UseRecoveryCode(ctx, uid, hashOpaqueToken(normalizeRecoveryCode(code))); err != nil {
+		if errors.Is(err, storage.ErrRecoveryCodeNotFound) {
+			log.Info("invalid mfa code")
+			return ErrInvalidMFACode
+		}
+
+		log.Error("failed to use recovery code", "", err.Error())
+
+		return err
+	}
+
+	log.Info("recovery code used")
+
+	return nil
+}
+
+// mfaRequired reports whether the user has enabled two-factor authentication.
+func (a *Auth) mfaRequired(ctx context.Context, uid int) (bool, error) {
+	if a.mfa == nil {
+		return false, nil
+	}
+
+	stored, err := a.mfa.TOTP(ctx, uid)
+	if err != nil {
+		if errors.Is(err, storage.ErrTOTPNotFound) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return !stored.EnabledAt.IsZero(), nil
+}
+
+// issueMFAToken returns a token finishing the login of the user into the app with VerifyMFA.
+func (a *Auth) issueMFAToken(ctx context.Context, uid int, appID int) (string, error) {
+	token, err := newOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	err = a.oneTimeTokens.SaveOneTimeToken(ctx, models.OneTimeToken{
+		UserID:    uid,
+		AppID:     appID,
+		Purpose:   purposeMFA,
+		TokenHash: hashOpaqueToken(token),
+		ExpiresAt: time.Now().Add(a.MFATokenTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// totpAdditionalData binds an encrypted secret to its user.
+func totpAdditionalData(uid int) []byte {
+	return []byte("totp:" + strconv.Itoa(uid))
+}
+
+// newRecoveryCodes returns new recovery codes formatted for the user, and their hashes.
+func newRecoveryCodes() ([]string, [][]byte, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([][]byte, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, recoveryCodeEncoding.DecodedLen(recoveryCodeLen))
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+
+		code := recoveryCodeEncoding.EncodeToString(b)[:recoveryCodeLen]
+
+		codes = append(codes, formatRecoveryCode(code))
+		hashes = append(hashes, hashOpaqueToken(code))
+	}
+
+	return codes, hashes, nil
+}
+
+// formatRecoveryCode splits the code into groups of recoveryCodeGroup characters separated by dashes.
+func formatRecoveryCode(code string) string {
+	groups := make([]string, 0, (len(code)+recoveryCodeGroup-1)/recoveryCodeGroup)
+	for len(code) > recoveryCodeGroup {
+		groups = append(groups, code[:recoveryCodeGroup])
+		code = code[recoveryCodeGroup:]
+	}
+
+	return strings.Join(append(groups, code), "-")
+}
+
+// normalizeRecoveryCode strips the separators and case a user may type a recovery code with.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, code)
+}
@@ -0,0 +1,429 @@
+package auth
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/jwt"
+	"auth/internal/password"
+	"auth/internal/secretbox"
+	"auth/internal/services/auth/mocks"
+	"auth/internal/storage"
+	"auth/internal/totp"
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newTestSecretBox(t *testing.T) *secretbox.Box {
+	key, err := secretbox.GenerateKey()
+	require.NoError(t, err)
+
+	box, err := secretbox.New(key)
+	require.NoError(t, err)
+
+	return box
+}
+
+func Test_Auth_EnrollTOTP(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(
+		slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+	)
+
+	box := newTestSecretBox(t)
+	user := models.User{ID: 1, Name: "Matvey", Username: "MatveyTabby"}
+
+	tests := []struct {
+		nameTest    string
+		mockMFA     func(s *mocks.MFAStorage)
+		expectedErr error
+	}{
+		{
+			nameTest: "Success",
+			mockMFA: func(s *mocks.MFAStorage) {
+				s.EXPECT().SaveTOTP(ctx, user.ID, mock.Anything).Return(nil).Once()
+			},
+		},
+		{
+			nameTest: "Already enabled",
+			mockMFA: func(s *mocks.MFAStorage) {
+				s.EXPECT().SaveTOTP(ctx, user.ID, mock.Anything).Return(storage.ErrTOTPEnabled).Once()
+			},
+			expectedErr: ErrMFAEnabled,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nameTest, func(t *testing.T) {
+			userProvider := mocks.NewUserProvider(t)
+			userProvider.EXPECT().UserByID(ctx, user.ID).Return(user, nil).Once()
+
+			mfa := mocks.NewMFAStorage(t)
+			tc.mockMFA(mfa)
+
+			s := Auth{
+				UserProvider: userProvider,
+				mfa:          mfa,
+				secrets:      box,
+				mfaIssuer:    "Auth",
+				log:          log,
+			}
+
+			enrollment, err := s.EnrollTOTP(ctx, user.ID)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.NotEmpty(t, enrollment.Secret)
+			assert.Contains(t, enrollment.URI, "secret="+enrollment.Secret)
+
+			// The secret is stored encrypted and bound to the user.
+			sealed := mfa.Calls[0].Arguments.Get(2).([]byte)
+			assert.NotContains(t, string(sealed), enrollment.Secret)
+
+			secret, err := box.Open(sealed, totpAdditionalData(user.ID))
+			assert.NoError(t, err)
+			assert.Equal(t, enrollment.Secret, totp.EncodeSecret(secret))
+		})
+	}
+
+	t.Run("Unavailable", func(t *testing.T) {
+		s := Auth{log: log}
+
+		_, err := s.EnrollTOTP(ctx, user.ID)
+
+		assert.ErrorIs(t, err, ErrMFAUnavailable)
+	})
+}
+
+func Test_Auth_ConfirmTOTP(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(
+		slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+	)
+
+	box := newTestSecretBox(t)
+
+	const uid = 1
+
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+
+	sealed, err := box.Seal(secret, totpAdditionalData(uid))
+	require.NoError(t, err)
+
+	now := time.Now()
+
+	pending := models.TOTP{UserID: uid, Secret: sealed}
+	enabled := models.TOTP{UserID: uid, Secret: sealed, EnabledAt: time.Now()}
+
+	tests := []struct {
+		nameTest    string
+		code        string
+		mockMFA     func(s *mocks.MFAStorage)
+		expectedErr error
+	}{
+		{
+			nameTest: "Success",
+			code:     totp.Code(secret, now),
+			mockMFA: func(s *mocks.MFAStorage) {
+				s.EXPECT().TOTP(ctx, uid).Return(pending, nil).Once()
+				s.EXPECT().
+					EnableTOTP(ctx, uid, mock.Anything, totp.Step(now), mock.MatchedBy(func(hashes [][]byte) bool {
+						return len(hashes) == recoveryCodeCount
+					})).
+					Return(nil).Once()
+			},
+		},
+		{
+			nameTest: "Wrong code",
+			code:     "000000",
+			mockMFA: func(s *mocks.MFAStorage) {
+				s.EXPECT().TOTP(ctx, uid).Return(pending, nil).Once()
+			},
+			expectedErr: ErrInvalidMFACode,
+		},
+		{
+			nameTest: "Not enrolled",
+			code:     totp.Code(secret, now),
+			mockMFA: func(s *mocks.MFAStorage) {
+				s.EXPECT().TOTP(ctx, uid).Return(models.TOTP{}, storage.ErrTOTPNotFound).Once()
+			},
+			expectedErr: ErrMFANotEnrolled,
+		},
+		{
+			nameTest: "Already enabled",
+			code:     totp.Code(secret, now),
+			mockMFA: func(s *mocks.MFAStorage) {
+				s.EXPECT().TOTP(ctx, uid).Return(enabled, nil).Once()
+			},
+			expectedErr: ErrMFAEnabled,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nameTest, func(t *testing.T) {
+			mfa := mocks.NewMFAStorage(t)
+			tc.mockMFA(mfa)
+
+			s := Auth{
+				mfa:     mfa,
+				secrets: box,
+				log:     log,
+			}
+
+			codes, err := s.ConfirmTOTP(ctx, uid, tc.code)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, codes)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Len(t, codes, recoveryCodeCount)
+
+			// Only hashes of the recovery codes are stored.
+			hashes := mfa.Calls[1].Arguments.Get(4).([][]byte)
+			assert.Equal(t, hashOpaqueToken(normalizeRecoveryCode(codes[0])), hashes[0])
+		})
+	}
+}
+
+func Test_Auth_VerifyMFA(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(
+		slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+	)
+
+	box := newTestSecretBox(t)
+
+	const (
+		mfaToken     = "mfa-token"
+		recoveryCode = "abcde-fghij-klmno-pqrst"
+	)
+
+	user := models.User{ID: 1, Name: "Matvey", Username: "MatveyTabby"}
+
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+
+	sealed, err := box.Seal(secret, totpAdditionalData(user.ID))
+	require.NoError(t, err)
+
+	now := time.Now()
+
+	enabled := models.TOTP{UserID: user.ID, Secret: sealed, EnabledAt: now.Add(-time.Hour)}
+	challenge := models.OneTimeToken{
+		ID:        10,
+		UserID:    user.ID,
+		Purpose:   purposeMFA,
+		TokenHash: hashOpaqueToken(mfaToken),
+		ExpiresAt: time.Now().Add(time.Minute),
+	}
+
+	tests := []struct {
+		nameTest          string
+		code              string
+		mockOneTimeTokens func(s *mocks.OneTimeTokenStorage)
+		mockMFA           func(s *mocks.MFAStorage)
+		expectedErr       error
+	}{
+		{
+			nameTest: "TOTP code",
+			code:     totp.Code(secret, now),
+			mockOneTimeTokens: func(s *mocks.OneTimeTokenStorage) {
+				s.EXPECT().OneTimeToken(ctx, hashOpaqueToken(mfaToken), purposeMFA).Return(challenge, nil).Once()
+				s.EXPECT().UseOneTimeToken(ctx, challenge.ID).Return(nil).Once()
+			},
+			mockMFA: func(s *mocks.MFAStorage) {
+				s.EXPECT().TOTP(ctx, user.ID).Return(enabled, nil).Once()
+				s.EXPECT().UseTOTPStep(ctx, user.ID, totp.Step(now)).Return(nil).Once()
+			},
+		},
+		{
+			nameTest: "Replayed TOTP code",
+			code:     totp.Code(secret, now),
+			mockOneTimeTokens: func(s *mocks.OneTimeTokenStorage) {
+				s.EXPECT().OneTimeToken(ctx, mock.Anything, purposeMFA).Return(challenge, nil).Once()
+				s.EXPECT().UseOneTimeToken(ctx, challenge.ID).Return(nil).Once()
+			},
+			mockMFA: func(s *mocks.MFAStorage) {
+				s.EXPECT().TOTP(ctx, user.ID).Return(enabled, nil).Once()
+				s.EXPECT().UseTOTPStep(ctx, user.ID, mock.Anything).Return(storage.ErrTOTPCodeUsed).Once()
+			},
+			expectedErr: ErrInvalidMFACode,
+		},
+		{
+			nameTest: "Recovery code",
+			code:     " ABCDE-FGHIJ-klmno-PQRST ",
+			mockOneTimeTokens: func(s *mocks.OneTimeTokenStorage) {
+				s.EXPECT().OneTimeToken(ctx, mock.Anything, purposeMFA).Return(challenge, nil).Once()
+				s.EXPECT().UseOneTimeToken(ctx, challenge.ID).Return(nil).Once()
+			},
+			mockMFA: func(s *mocks.MFAStorage) {
+				s.EXPECT().TOTP(ctx, user.ID).Return(enabled, nil).Once()
+				s.EXPECT().UseRecoveryCode(ctx, user.ID, hashOpaqueToken(normalizeRecoveryCode(recoveryCode))).Return(nil).Once()
+			},
+		},
+		{
+			nameTest: "Wrong code",
+			code:     "000000",
+			mockOneTimeTokens: func(s *mocks.OneTimeTokenStorage) {
+				s.EXPECT().OneTimeToken(ctx, mock.Anything, purposeMFA).Return(challenge, nil).Once()
+				s.EXPECT().UseOneTimeToken(ctx, challenge.ID).Return(nil).Once()
+			},
+			mockMFA: func(s *mocks.MFAStorage) {
+				s.EXPECT().TOTP(ctx, user.ID).Return(enabled, nil).Once()
+				s.EXPECT().UseRecoveryCode(ctx, user.ID, mock.Anything).Return(storage.ErrRecoveryCodeNotFound).Once()
+			},
+			expectedErr: ErrInvalidMFACode,
+		},
+		{
+			nameTest: "Token used concurrently",
+			code:     totp.Code(secret, now),
+			mockOneTimeTokens: func(s *mocks.OneTimeTokenStorage) {
+				s.EXPECT().OneTimeToken(ctx, mock.Anything, purposeMFA).Return(challenge, nil).Once()
+				s.EXPECT().UseOneTimeToken(ctx, challenge.ID).Return(storage.ErrOneTimeTokenUsed).Once()
+			},
+			expectedErr: ErrInvalidMFAToken,
+		},
+		{
+			nameTest: "Expired token",
+			code:     totp.Code(secret, now),
+			mockOneTimeTokens: func(s *mocks.OneTimeTokenStorage) {
+				expired := challenge
+				expired.ExpiresAt = time.Now().Add(-time.Minute)
+				s.EXPECT().OneTimeToken(ctx, mock.Anything, purposeMFA).Return(expired, nil).Once()
+			},
+			expectedErr: ErrInvalidMFAToken,
+		},
+		{
+			nameTest: "Unknown token",
+			code:     totp.Code(secret, now),
+			mockOneTimeTokens: func(s *mocks.OneTimeTokenStorage) {
+				s.EXPECT().OneTimeToken(ctx, mock.Anything, purposeMFA).Return(models.OneTimeToken{}, storage.ErrOneTimeTokenNotFound).Once()
+			},
+			expectedErr: ErrInvalidMFAToken,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nameTest, func(t *testing.T) {
+			oneTimeTokens := mocks.NewOneTimeTokenStorage(t)
+			tc.mockOneTimeTokens(oneTimeTokens)
+
+			mfa := mocks.NewMFAStorage(t)
+			if tc.mockMFA != nil {
+				tc.mockMFA(mfa)
+			}
+
+			userProvider := mocks.NewUserProvider(t)
+			userProvider.EXPECT().UserByID(ctx, user.ID).Return(user, nil).Maybe()
+
+			refreshTokens := mocks.NewRefreshTokenStorage(t)
+			refreshTokens.EXPECT().SaveRefreshToken(ctx, mock.Anything).Return(nil).Maybe()
+
+			roleProvider := mocks.NewRoleProvider(t)
+			roleProvider.EXPECT().UserRoles(ctx, mock.Anything).Return(nil, nil).Maybe()
+			roleProvider.EXPECT().UserPermissions(ctx, mock.Anything).Return(nil, nil).Maybe()
+
+			s := Auth{
+				UserProvider:  userProvider,
+				refreshTokens: refreshTokens,
+				roleProvider:  roleProvider,
+				oneTimeTokens: oneTimeTokens,
+				mfa:           mfa,
+				secrets:       box,
+				log:           log,
+				keys:          jwt.NewKeyRing(jwt.Key{ID: "test", Secret: []byte("test-signing-key")}),
+			}
+
+			tokens, err := s.VerifyMFA(ctx, mfaToken, tc.code, "")
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Equal(t, models.TokenPair{}, tokens)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.NotEmpty(t, tokens.AccessToken)
+		})
+	}
+}
+
+func Test_Auth_Login_MFA(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(
+		slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+	)
+
+	const pass = "123456"
+
+	passHash, err := bcrypt.GenerateFromPassword([]byte(pass), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	user := models.User{ID: 1, Name: "Matvey", Username: "MatveyTabby", PassHash: passHash}
+
+	userProvider := mocks.NewUserProvider(t)
+	userProvider.EXPECT().User(ctx, user.Username).Return(user, nil).Once()
+
+	mfa := mocks.NewMFAStorage(t)
+	mfa.EXPECT().TOTP(ctx, user.ID).Return(models.TOTP{UserID: user.ID, EnabledAt: time.Now()}, nil).Once()
+
+	oneTimeTokens := mocks.NewOneTimeTokenStorage(t)
+	oneTimeTokens.EXPECT().
+		SaveOneTimeToken(ctx, mock.MatchedBy(func(token models.OneTimeToken) bool {
+			return token.UserID == user.ID && token.AppID == 0 && token.Purpose == purposeMFA
+		})).
+		Return(nil).Once()
+
+	// The right password only takes back its own failure, the earlier ones stay until the second factor.
+	userKey := userAttemptKey(user.Username)
+	attempts := mocks.NewAttemptStore(t)
+	attempts.EXPECT().LoginAttempts(ctx, userKey).Return(models.LoginAttempts{Failures: 2, LastFailure: time.Now()}, nil).Once()
+	attempts.EXPECT().RecordLoginFailure(ctx, userKey, mock.Anything, mock.Anything).Return(models.LoginAttempts{Failures: 3}, nil).Once()
+	attempts.EXPECT().CancelLoginFailure(ctx, userKey, mock.Anything, mock.Anything).Return(nil).Once()
+
+	s := Auth{
+		UserProvider:  userProvider,
+		oneTimeTokens: oneTimeTokens,
+		mfa:           mfa,
+		attempts:      attempts,
+		throttle:      Throttle{Window: 15 * time.Minute, FreeAttempts: 3},
+		hasher:        password.NewHasher(password.Bcrypt{Cost: bcrypt.MinCost}),
+		MFATokenTTL:   5 * time.Minute,
+		log:           log,
+		keys:          jwt.NewKeyRing(jwt.Key{ID: "test", Secret: []byte("test-signing-key")}),
+	}
+
+	tokens, err := s.Login(ctx, user.Username, pass, 0, "")
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, tokens.MFAToken)
+	assert.Empty(t, tokens.AccessToken)
+	assert.Empty(t, tokens.RefreshToken)
+}
+
+func Test_normalizeRecoveryCode(t *testing.T) {
+	codes, hashes, err := newRecoveryCodes()
+	require.NoError(t, err)
+	require.Len(t, codes, recoveryCodeCount)
+
+	for i, code := range codes {
+		assert.Len(t, code, recoveryCodeLen+recoveryCodeLen/recoveryCodeGroup-1)
+		assert.Equal(t, hashes[i], hashOpaqueToken(normalizeRecoveryCode(code)))
+	}
+
+	assert.Equal(t, "abcdefghijklmnopqrst", normalizeRecoveryCode(" ABCDE-fghij-KLMNO-pqrst "))
+}
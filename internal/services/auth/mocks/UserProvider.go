@@ -1,4 +1,4 @@
-// Code generated by mockery v2.46.0. DO NOT EDIT.
+// Code generated by mockery v2.53.5. DO NOT EDIT.
 
 package mocks
 
@@ -79,6 +79,120 @@ func (_c *UserProvider_User_Call) RunAndReturn(run func(context.Context, string)
 	return _c
 }
 
+// UserByEmail provides a mock function with given fields: ctx, email
+func (_m *UserProvider) UserByEmail(ctx context.Context, email string) (models.User, error) {
+	ret := _m.Called(ctx, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UserByEmail")
+	}
+
+	var r0 models.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (models.User, error)); ok {
+		return rf(ctx, email)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) models.User); ok {
+		r0 = rf(ctx, email)
+	} else {
+		r0 = ret.Get(0).(models.User)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UserProvider_UserByEmail_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UserByEmail'
+type UserProvider_UserByEmail_Call struct {
+	*mock.Call
+}
+
+// UserByEmail is a helper method to define mock.On call
+//   - ctx context.Context
+//   - email string
+func (_e *UserProvider_Expecter) UserByEmail(ctx interface{}, email interface{}) *UserProvider_UserByEmail_Call {
+	return &UserProvider_UserByEmail_Call{Call: _e.mock.On("UserByEmail", ctx, email)}
+}
+
+func (_c *UserProvider_UserByEmail_Call) Run(run func(ctx context.Context, email string)) *UserProvider_UserByEmail_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *UserProvider_UserByEmail_Call) Return(_a0 models.User, _a1 error) *UserProvider_UserByEmail_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *UserProvider_UserByEmail_Call) RunAndReturn(run func(context.Context, string) (models.User, error)) *UserProvider_UserByEmail_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UserByID provides a mock function with given fields: ctx, uid
+func (_m *UserProvider) UserByID(ctx context.Context, uid int) (models.User, error) {
+	ret := _m.Called(ctx, uid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UserByID")
+	}
+
+	var r0 models.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (models.User, error)); ok {
+		return rf(ctx, uid)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) models.User); ok {
+		r0 = rf(ctx, uid)
+	} else {
+		r0 = ret.Get(0).(models.User)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, uid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UserProvider_UserByID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UserByID'
+type UserProvider_UserByID_Call struct {
+	*mock.Call
+}
+
+// UserByID is a helper method to define mock.On call
+//   - ctx context.Context
+//   - uid int
+func (_e *UserProvider_Expecter) UserByID(ctx interface{}, uid interface{}) *UserProvider_UserByID_Call {
+	return &UserProvider_UserByID_Call{Call: _e.mock.On("UserByID", ctx, uid)}
+}
+
+func (_c *UserProvider_UserByID_Call) Run(run func(ctx context.Context, uid int)) *UserProvider_UserByID_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int))
+	})
+	return _c
+}
+
+func (_c *UserProvider_UserByID_Call) Return(_a0 models.User, _a1 error) *UserProvider_UserByID_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *UserProvider_UserByID_Call) RunAndReturn(run func(context.Context, int) (models.User, error)) *UserProvider_UserByID_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewUserProvider creates a new instance of UserProvider. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewUserProvider(t interface {
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"auth/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+var ErrSamePassword = errors.New("new password must differ from the current one")
+
+// ChangePassword replaces the password of the user after checking the current one.
+// The new password must satisfy the policy and differ from the current one.
+// Every token issued to the user before the change is revoked, so all sessions,
+// including the one making the change, have to log in again.
+// Wrong current passwords count as failed logins of the user.
+func (a *Auth) ChangePassword(
+	ctx context.Context,
+	uid int,
+	oldPassword string,
+	newPassword string,
+) error {
+	const op = "auth.ChangePassword"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int("uid", uid),
+	)
+	log.Info("changing password")
+
+	user, err := a.UserProvider.UserByID(ctx, uid)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("user not found")
+			return fmt.Errorf("%s: %w", op, ErrUserNotFound)
+		}
+
+		log.Error("failed to get user", "", err.Error())
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	reserved, err := a.reserveAttempt(ctx, attemptKeys(user.Username, ""))
+	if err != nil {
+		var attemptsErr *AttemptsError
+		if !errors.As(err, &attemptsErr) {
+			log.Error("failed to check login attempts", "", err.Error())
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.hasher.Verify(oldPassword, user.PassHash); err != nil {
+		log.Info("invalid current password", "", err.Error())
+		return fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+	}
+
+	a.cancelAttempt(ctx, log, reserved)
+
+	if err := a.hasher.Verify(newPassword, user.PassHash); err == nil {
+		log.Info("new password is the current one")
+		return fmt.Errorf("%s: %w", op, ErrSamePassword)
+	}
+
+	if err := a.checkPassword(newPassword, user.Username); err != nil {
+		if errors.Is(err, ErrWeakPassword) {
+			log.Info("password does not satisfy the policy", "", err.Error())
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		log.Error("failed to check password", "", err.Error())
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	passHash, err := a.hasher.Hash(newPassword)
+	if err != nil {
+		log.Error("failed to generate password hash", "", err.Error())
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.UserSaver.UpdatePasswordHash(ctx, uid, passHash); err != nil {
+		log.Error("failed to update password", "", err.Error())
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// The password is changed already, a failure here leaves the old sessions alive,
+	// so it is reported to the caller instead of being only logged.
+	if err := a.LogoutAll(ctx, uid); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("password changed")
+
+	return nil
+}
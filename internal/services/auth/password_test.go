@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/password"
+	"auth/internal/services/auth/mocks"
+	"auth/internal/storage"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func Test_Auth_ChangePassword(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(
+		slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+	)
+
+	hasher := password.NewHasher(password.Bcrypt{Cost: bcrypt.MinCost})
+
+	passHash, err := hasher.Hash("old password")
+	assert.NoError(t, err)
+
+	user := models.User{ID: 1, Name: "Matvey", Username: "MatveyTabby", PassHash: passHash}
+
+	tests := []struct {
+		nameTest          string
+		oldPassword       string
+		newPassword       string
+		mockProvider      func(s *mocks.UserProvider)
+		mockUserSaver     func(s *mocks.UserSaver)
+		mockRevoker       func(s *mocks.TokenRevoker)
+		mockRefreshTokens func(s *mocks.RefreshTokenStorage)
+		expectedErr       error
+		expectedErrStr    string
+	}{
+		{
+			nameTest:    "Success",
+			oldPassword: "old password",
+			newPassword: "new password",
+			mockProvider: func(s *mocks.UserProvider) {
+				s.EXPECT().UserByID(ctx, user.ID).Return(user, nil).Once()
+			},
+			mockUserSaver: func(s *mocks.UserSaver) {
+				s.EXPECT().
+					UpdatePasswordHash(ctx, user.ID, mock.MatchedBy(func(hash []byte) bool {
+						return hasher.Verify("new password", hash) == nil
+					})).
+					Return(nil).Once()
+			},
+			mockRevoker: func(s *mocks.TokenRevoker) {
+				s.EXPECT().RevokeUserTokens(ctx, user.ID).Return(nil).Once()
+			},
+			mockRefreshTokens: func(s *mocks.RefreshTokenStorage) {
+				s.EXPECT().RevokeUserRefreshTokens(ctx, user.ID).Return(nil).Once()
+			},
+		},
+		{
+			nameTest:    "Wrong current password",
+			oldPassword: "guess",
+			newPassword: "new password",
+			mockProvider: func(s *mocks.UserProvider) {
+				s.EXPECT().UserByID(ctx, user.ID).Return(user, nil).Once()
+			},
+			expectedErr: ErrInvalidCredentials,
+		},
+		{
+			nameTest:    "Same password",
+			oldPassword: "old password",
+			newPassword: "old password",
+			mockProvider: func(s *mocks.UserProvider) {
+				s.EXPECT().UserByID(ctx, user.ID).Return(user, nil).Once()
+			},
+			expectedErr: ErrSamePassword,
+		},
+		{
+			nameTest:    "Weak password",
+			oldPassword: "old password",
+			newPassword: "short",
+			mockProvider: func(s *mocks.UserProvider) {
+				s.EXPECT().UserByID(ctx, user.ID).Return(user, nil).Once()
+			},
+			expectedErr: ErrWeakPassword,
+		},
+		{
+			nameTest:    "Unknown user",
+			oldPassword: "old password",
+			newPassword: "new password",
+			mockProvider: func(s *mocks.UserProvider) {
+				s.EXPECT().UserByID(ctx, user.ID).Return(models.User{}, storage.ErrUserNotFound).Once()
+			},
+			expectedErr: ErrUserNotFound,
+		},
+		{
+			nameTest:    "Error during revoke",
+			oldPassword: "old password",
+			newPassword: "new password",
+			mockProvider: func(s *mocks.UserProvider) {
+				s.EXPECT().UserByID(ctx, user.ID).Return(user, nil).Once()
+			},
+			mockUserSaver: func(s *mocks.UserSaver) {
+				s.EXPECT().UpdatePasswordHash(ctx, user.ID, mock.Anything).Return(nil).Once()
+			},
+			mockRevoker: func(s *mocks.TokenRevoker) {
+				s.EXPECT().RevokeUserTokens(ctx, user.ID).Return(fmt.Errorf("storage is down")).Once()
+			},
+			expectedErrStr: "storage is down",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nameTest, func(t *testing.T) {
+			userProvider := mocks.NewUserProvider(t)
+			tc.mockProvider(userProvider)
+
+			userSaver := mocks.NewUserSaver(t)
+			if tc.mockUserSaver != nil {
+				tc.mockUserSaver(userSaver)
+			}
+
+			revoker := mocks.NewTokenRevoker(t)
+			if tc.mockRevoker != nil {
+				tc.mockRevoker(revoker)
+			}
+
+			refreshTokens := mocks.NewRefreshTokenStorage(t)
+			if tc.mockRefreshTokens != nil {
+				tc.mockRefreshTokens(refreshTokens)
+			}
+
+			s := Auth{
+				UserProvider:   userProvider,
+				UserSaver:      userSaver,
+				revoker:        revoker,
+				refreshTokens:  refreshTokens,
+				hasher:         hasher,
+				passwordPolicy: password.Policy{MinLength: 8},
+				log:            log,
+			}
+
+			err := s.ChangePassword(ctx, user.ID, tc.oldPassword, tc.newPassword)
+
+			switch {
+			case tc.expectedErr != nil:
+				assert.ErrorIs(t, err, tc.expectedErr)
+			case tc.expectedErrStr != "":
+				assert.ErrorContains(t, err, tc.expectedErrStr)
+			default:
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"auth/internal/password"
+	"errors"
+	"strings"
+)
+
+var ErrWeakPassword = errors.New("password does not satisfy the policy")
+
+// ruleBreachedPassword is the rule broken by passwords found in breach corpora.
+const ruleBreachedPassword = "breached_password"
+
+// BreachChecker reports whether a password appears in known breach corpora.
+//
+//go:generate go run github.com/vektra/mockery/v2@latest --name=BreachChecker --with-expecter=true
+type BreachChecker interface {
+	IsBreached(password string) (bool, error)
+}
+
+// PasswordPolicyError lists the rules a new password breaks. It matches ErrWeakPassword.
+type PasswordPolicyError struct {
+	Violations []password.Violation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	descriptions := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		descriptions = append(descriptions, violation.Description)
+	}
+
+	return ErrWeakPassword.Error() + ": " + strings.Join(descriptions, "; ")
+}
+
+func (e *PasswordPolicyError) Is(target error) bool {
+	return target == ErrWeakPassword
+}
+
+// checkPassword fails with PasswordPolicyError if a new password of the user breaks the policy
+// or, when the service has a BreachChecker, was found in a breach.
+func (a *Auth) checkPassword(pass, username string) error {
+	violations := a.passwordPolicy.Check(pass, username)
+
+	if a.breaches != nil {
+		breached, err := a.breaches.IsBreached(pass)
+		if err != nil {
+			return err
+		}
+
+		if breached {
+			violations = append(violations, password.Violation{
+				Rule:        ruleBreachedPassword,
+				Description: "password has appeared in a data breach",
+			})
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+
+	return nil
+}
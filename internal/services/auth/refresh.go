@@ -0,0 +1,234 @@
+package auth
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/jwt"
+	"auth/internal/storage"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// opaqueTokenLen is the number of random bytes in refresh and one-time tokens.
+const opaqueTokenLen = 32
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reused")
+)
+
+//go:generate go run github.com/vektra/mockery/v2@latest --name=RefreshTokenStorage --with-expecter=true
+type RefreshTokenStorage interface {
+	SaveRefreshToken(ctx context.Context,
+		token models.RefreshToken,
+	) error
+
+	RefreshToken(ctx context.Context,
+		tokenHash []byte,
+	) (models.RefreshToken, error)
+
+	UseRefreshToken(ctx context.Context,
+		id int64,
+	) error
+
+	RevokeTokenFamily(ctx context.Context,
+		familyID string,
+	) error
+
+	RevokeUserRefreshTokens(ctx context.Context,
+		uid int,
+	) error
+}
+
+// Refresh exchanges a refresh token for a new access and refresh token pair.
+// Every refresh token can be exchanged only once. Presenting a token that has already been
+// exchanged means it has leaked, so the whole family of tokens issued since the login is revoked.
+func (a *Auth) Refresh(
+	ctx context.Context,
+	refreshToken string,
+) (models.TokenPair, error) {
+	const op = "auth.Refresh"
+
+	log := a.log.With(slog.String("op", op))
+
+	stored, err := a.refreshTokens.RefreshToken(ctx, hashOpaqueToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, storage.ErrRefreshTokenNotFound) {
+			log.Warn("refresh token not found")
+			return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidRefreshToken)
+		}
+
+		log.Error("failed to get refresh token", "", err.Error())
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int("uid", stored.UserID), slog.String("family", stored.FamilyID))
+
+	if stored.Revoked {
+		log.Warn("refresh token is revoked")
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidRefreshToken)
+	}
+
+	if !stored.UsedAt.IsZero() {
+		return models.TokenPair{}, a.revokeReusedFamily(ctx, log, op, stored.FamilyID)
+	}
+
+	if time.Now().After(stored.ExpiresAt) {
+		log.Info("refresh token is expired")
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidRefreshToken)
+	}
+
+	if err := a.refreshTokens.UseRefreshToken(ctx, stored.ID); err != nil {
+		if errors.Is(err, storage.ErrRefreshTokenUsed) {
+			return models.TokenPair{}, a.revokeReusedFamily(ctx, log, op, stored.FamilyID)
+		}
+
+		log.Error("failed to use refresh token", "", err.Error())
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	params, err := a.tokenParams(ctx, stored.AppID)
+	if err != nil {
+		if errors.Is(err, ErrInvalidAppID) {
+			log.Warn("app of refresh token no longer exists")
+			return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidRefreshToken)
+		}
+
+		log.Error("failed to get app", "", err.Error())
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := a.UserProvider.UserByID(ctx, stored.UserID)
+	if err != nil {
+		log.Error("failed to get user", "", err.Error())
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tokens, err := a.issueTokens(ctx, user, stored.AppID, params, stored.FamilyID)
+	if err != nil {
+
+		log.Error("failed to issue tokens", "", err.Error())
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("tokens refreshed")
+
+	return tokens, nil
+}
+
+func (a *Auth) revokeReusedFamily(ctx context.Context, log *slog.Logger, op string, familyID string) error {
+	log.Warn("refresh token reuse detected, revoking token family")
+
+	if err := a.refreshTokens.RevokeTokenFamily(ctx, familyID); err != nil {
+		log.Error("failed to revoke token family", "", err.Error())
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return fmt.Errorf("%s: %w", op, ErrRefreshTokenReused)
+}
+
+// issueTokens signs an access token for the app and stores a new refresh token of the family.
+// The current roles and permissions of the user are put into the access token.
+// An empty familyID starts a new family. With zero RefreshTokenTTL refresh tokens are disabled,
+// only the access token is issued and nothing is stored.
+func (a *Auth) issueTokens(
+	ctx context.Context,
+	user models.User,
+	appID int,
+	params tokenParams,
+	familyID string,
+) (models.TokenPair, error) {
+	var err error
+
+	if familyID == "" {
+		familyID, err = newFamilyID()
+		if err != nil {
+			return models.TokenPair{}, err
+		}
+	}
+
+	roles, err := a.roleProvider.UserRoles(ctx, user.ID)
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+
+	permissions, err := a.roleProvider.UserPermissions(ctx, user.ID)
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+
+	accessToken, err := jwt.NewToken(user, params.key, jwt.TokenOptions{
+		TTL:         params.ttl,
+		Issuer:      a.issuer,
+		Audience:    params.audience,
+		SessionID:   familyID,
+		Roles:       roles,
+		Permissions: permissions,
+	})
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+
+	if a.RefreshTokenTTL <= 0 {
+		return models.TokenPair{AccessToken: accessToken}, nil
+	}
+
+	refreshToken, err := newOpaqueToken()
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+
+	err = a.refreshTokens.SaveRefreshToken(ctx, models.RefreshToken{
+		UserID:    user.ID,
+		AppID:     appID,
+		FamilyID:  familyID,
+		TokenHash: hashOpaqueToken(refreshToken),
+		ExpiresAt: time.Now().Add(a.RefreshTokenTTL),
+	})
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+
+	return models.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// newOpaqueToken returns a random token for refresh and one-time tokens.
+func newOpaqueToken() (string, error) {
+	b := make([]byte, opaqueTokenLen)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func newFamilyID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// hashOpaqueToken returns the hash under which a refresh or one-time token is stored.
+// The tokens are random, so a fast unsalted hash is enough.
+func hashOpaqueToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/jwt"
+	"auth/internal/services/auth/mocks"
+	"auth/internal/storage"
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_Auth_Refresh(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(
+		slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+	)
+
+	const refreshToken = "refresh-token"
+
+	user := models.User{ID: 1, Name: "Matvey", Username: "MatveyTabby"}
+	stored := models.RefreshToken{
+		ID:        10,
+		UserID:    user.ID,
+		FamilyID:  "family",
+		TokenHash: hashOpaqueToken(refreshToken),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	tests := []struct {
+		nameTest          string
+		mockRefreshTokens func(s *mocks.RefreshTokenStorage)
+		mockProvider      func(s *mocks.UserProvider)
+		expectedErr       error
+	}{
+		{
+			nameTest: "Success",
+			mockRefreshTokens: func(s *mocks.RefreshTokenStorage) {
+				s.EXPECT().RefreshToken(ctx, hashOpaqueToken(refreshToken)).Return(stored, nil).Once()
+				s.EXPECT().UseRefreshToken(ctx, stored.ID).Return(nil).Once()
+				s.EXPECT().
+					SaveRefreshToken(ctx, mock.MatchedBy(func(token models.RefreshToken) bool {
+						return token.FamilyID == stored.FamilyID && token.UserID == user.ID
+					})).
+					Return(nil).Once()
+			},
+			mockProvider: func(s *mocks.UserProvider) {
+				s.EXPECT().UserByID(ctx, user.ID).Return(user, nil).Once()
+			},
+		},
+		{
+			nameTest: "Unknown token",
+			mockRefreshTokens: func(s *mocks.RefreshTokenStorage) {
+				s.EXPECT().RefreshToken(ctx, mock.Anything).Return(models.RefreshToken{}, storage.ErrRefreshTokenNotFound).Once()
+			},
+			expectedErr: ErrInvalidRefreshToken,
+		},
+		{
+			nameTest: "Expired token",
+			mockRefreshTokens: func(s *mocks.RefreshTokenStorage) {
+				expired := stored
+				expired.ExpiresAt = time.Now().Add(-time.Minute)
+				s.EXPECT().RefreshToken(ctx, mock.Anything).Return(expired, nil).Once()
+			},
+			expectedErr: ErrInvalidRefreshToken,
+		},
+		{
+			nameTest: "Revoked token",
+			mockRefreshTokens: func(s *mocks.RefreshTokenStorage) {
+				revoked := stored
+				revoked.Revoked = true
+				s.EXPECT().RefreshToken(ctx, mock.Anything).Return(revoked, nil).Once()
+			},
+			expectedErr: ErrInvalidRefreshToken,
+		},
+		{
+			nameTest: "Reused token revokes family",
+			mockRefreshTokens: func(s *mocks.RefreshTokenStorage) {
+				used := stored
+				used.UsedAt = time.Now().Add(-time.Minute)
+				s.EXPECT().RefreshToken(ctx, mock.Anything).Return(used, nil).Once()
+				s.EXPECT().RevokeTokenFamily(ctx, stored.FamilyID).Return(nil).Once()
+			},
+			expectedErr: ErrRefreshTokenReused,
+		},
+		{
+			nameTest: "Concurrent exchange revokes family",
+			mockRefreshTokens: func(s *mocks.RefreshTokenStorage) {
+				s.EXPECT().RefreshToken(ctx, mock.Anything).Return(stored, nil).Once()
+				s.EXPECT().UseRefreshToken(ctx, stored.ID).Return(storage.ErrRefreshTokenUsed).Once()
+				s.EXPECT().RevokeTokenFamily(ctx, stored.FamilyID).Return(nil).Once()
+			},
+			expectedErr: ErrRefreshTokenReused,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nameTest, func(t *testing.T) {
+			refreshTokens := mocks.NewRefreshTokenStorage(t)
+			tc.mockRefreshTokens(refreshTokens)
+
+			userProvider := mocks.NewUserProvider(t)
+			if tc.mockProvider != nil {
+				tc.mockProvider(userProvider)
+			}
+
+			roleProvider := mocks.NewRoleProvider(t)
+			roleProvider.EXPECT().UserRoles(ctx, user.ID).Return(nil, nil).Maybe()
+			roleProvider.EXPECT().UserPermissions(ctx, user.ID).Return(nil, nil).Maybe()
+
+			s := Auth{
+				UserProvider:    userProvider,
+				refreshTokens:   refreshTokens,
+				roleProvider:    roleProvider,
+				log:             log,
+				TokenTTL:        time.Hour,
+				RefreshTokenTTL: 24 * time.Hour,
+				keys:            jwt.NewKeyRing(jwt.Key{ID: "test", Secret: []byte("test-signing-key")}),
+			}
+
+			tokens, err := s.Refresh(ctx, refreshToken)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Equal(t, models.TokenPair{}, tokens)
+			} else {
+				assert.NoError(t, err)
+				assert.NotEmpty(t, tokens.AccessToken)
+				assert.NotEqual(t, refreshToken, tokens.RefreshToken)
+			}
+		})
+	}
+}
+
+func Test_Auth_issueTokens_RefreshDisabled(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(
+		slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+	)
+
+	user := models.User{ID: 1, Name: "Matvey", Username: "MatveyTabby"}
+	key := jwt.Key{ID: "test", Secret: []byte("test-signing-key")}
+
+	roleProvider := mocks.NewRoleProvider(t)
+	roleProvider.EXPECT().UserRoles(ctx, user.ID).Return(nil, nil).Once()
+	roleProvider.EXPECT().UserPermissions(ctx, user.ID).Return(nil, nil).Once()
+
+	s := Auth{
+		refreshTokens: mocks.NewRefreshTokenStorage(t), // nothing must be stored
+		roleProvider:  roleProvider,
+		log:           log,
+		keys:          jwt.NewKeyRing(key),
+	}
+
+	tokens, err := s.issueTokens(ctx, user, emptyAppID, tokenParams{key: key, ttl: time.Hour}, "")
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, tokens.AccessToken)
+	assert.Empty(t, tokens.RefreshToken)
+}
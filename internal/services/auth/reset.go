@@ -0,0 +1,219 @@
+package auth
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// purposePasswordReset is the purpose of one-time tokens resetting a password.
+const purposePasswordReset = "password_reset"
+
+var ErrInvalidResetToken = errors.New("invalid password reset token")
+
+//go:generate go run github.com/vektra/mockery/v2@latest --name=OneTimeTokenStorage --with-expecter=true
+type OneTimeTokenStorage interface {
+	SaveOneTimeToken(ctx context.Context,
+		token models.OneTimeToken,
+	) error
+
+	OneTimeToken(ctx context.Context,
+		tokenHash []byte,
+		purpose string,
+	) (models.OneTimeToken, error)
+
+	UseOneTimeToken(ctx context.Context,
+		id int64,
+	) error
+
+	UseResetToken(ctx context.Context,
+		id int64,
+		uid int,
+		passHash []byte,
+	) error
+
+	DeleteUserOneTimeTokens(ctx context.Context,
+		uid int,
+		purpose string,
+	) error
+}
+
+// Notifier delivers notifications with one-time tokens to users.
+//
+//go:generate go run github.com/vektra/mockery/v2@latest --name=Notifier --with-expecter=true
+type Notifier interface {
+	Notify(ctx context.Context,
+		notification models.Notification,
+	) error
+}
+
+// RequestPasswordReset sends the user a one-time token to reset the password with.
+// The result is the same whether the user exists or not, so the call doesn't reveal which accounts exist:
+// the token is issued and delivered in the background and a failure there is only logged.
+// A new token replaces the ones requested before.
+func (a *Auth) RequestPasswordReset(
+	ctx context.Context,
+	username string,
+) error {
+	const op = "auth.RequestPasswordReset"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.String("username", username),
+	)
+	log.Info("password reset requested")
+
+	user, err := a.UserProvider.User(ctx, username)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("user not found")
+			return nil
+		}
+
+		log.Error("failed to get user", "", err.Error())
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	a.background.Add(1)
+	go func() {
+		defer a.background.Done()
+
+		if err := a.sendResetToken(context.WithoutCancel(ctx), user); err != nil {
+			log.Error("failed to send password reset token", "", err.Error())
+			return
+		}
+
+		log.Info("password reset token sent")
+	}()
+
+	return nil
+}
+
+// Stop waits for the notifications being delivered in the background.
+// It is called once the servers have stopped, so that no new deliveries are started.
+func (a *Auth) Stop() {
+	a.background.Wait()
+}
+
+// ResetPassword sets a new password of the user the reset token was issued to.
+// The token is used up only when the new password is accepted, so a password rejected by the policy
+// can be corrected with the same token. Every token issued to the user before the reset is revoked
+// and the throttling of the failed logins of the user is lifted.
+func (a *Auth) ResetPassword(
+	ctx context.Context,
+	token string,
+	newPassword string,
+) error {
+	const op = "auth.ResetPassword"
+
+	log := a.log.With(slog.String("op", op))
+
+	stored, err := a.oneTimeTokens.OneTimeToken(ctx, hashOpaqueToken(token), purposePasswordReset)
+	if err != nil {
+		if errors.Is(err, storage.ErrOneTimeTokenNotFound) {
+			log.Warn("unknown reset token")
+			return fmt.Errorf("%s: %w", op, ErrInvalidResetToken)
+		}
+
+		log.Error("failed to get reset token", "", err.Error())
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int("uid", stored.UserID))
+
+	if !stored.UsedAt.IsZero() || time.Now().After(stored.ExpiresAt) {
+		log.Warn("reset token is used or expired")
+		return fmt.Errorf("%s: %w", op, ErrInvalidResetToken)
+	}
+
+	user, err := a.UserProvider.UserByID(ctx, stored.UserID)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("user not found")
+			return fmt.Errorf("%s: %w", op, ErrInvalidResetToken)
+		}
+
+		log.Error("failed to get user", "", err.Error())
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.checkPassword(newPassword, user.Username); err != nil {
+		if errors.Is(err, ErrWeakPassword) {
+			log.Info("password does not satisfy the policy", "", err.Error())
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		log.Error("failed to check password", "", err.Error())
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	passHash, err := a.hasher.Hash(newPassword)
+	if err != nil {
+		log.Error("failed to generate password hash", "", err.Error())
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// The token is used up in one transaction with the change, a failed update leaves it usable.
+	if err := a.oneTimeTokens.UseResetToken(ctx, stored.ID, user.ID, passHash); err != nil {
+		if errors.Is(err, storage.ErrOneTimeTokenUsed) {
+			log.Warn("reset token used concurrently")
+			return fmt.Errorf("%s: %w", op, ErrInvalidResetToken)
+		}
+
+		log.Error("failed to update password", "", err.Error())
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.LogoutAll(ctx, user.ID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.resetAttempts(ctx, user.Username); err != nil {
+		log.Error("failed to reset login attempts", "", err.Error())
+	}
+
+	log.Info("password reset")
+
+	return nil
+}
+
+// sendResetToken issues a reset token to the user, dropping the ones issued before, and delivers it.
+func (a *Auth) sendResetToken(ctx context.Context, user models.User) error {
+	if err := a.oneTimeTokens.DeleteUserOneTimeTokens(ctx, user.ID, purposePasswordReset); err != nil {
+		return err
+	}
+
+	token, err := newOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().Add(a.ResetTokenTTL)
+
+	if err := a.oneTimeTokens.SaveOneTimeToken(ctx, models.OneTimeToken{
+		UserID:    user.ID,
+		Purpose:   purposePasswordReset,
+		TokenHash: hashOpaqueToken(token),
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return err
+	}
+
+	return a.notifier.Notify(ctx, models.Notification{
+		Kind:      models.NotificationPasswordReset,
+		UserID:    user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		Token:     token,
+		ExpiresAt: expiresAt,
+	})
+}
@@ -0,0 +1,253 @@
+package auth
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/password"
+	"auth/internal/services/auth/mocks"
+	"auth/internal/storage"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func Test_Auth_RequestPasswordReset(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(
+		slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+	)
+
+	user := models.User{ID: 1, Name: "Matvey", Username: "MatveyTabby"}
+
+	tests := []struct {
+		nameTest          string
+		username          string
+		mockProvider      func(s *mocks.UserProvider)
+		mockOneTimeTokens func(s *mocks.OneTimeTokenStorage)
+		mockNotifier      func(s *mocks.Notifier)
+	}{
+		{
+			nameTest: "Existing user",
+			username: user.Username,
+			mockProvider: func(s *mocks.UserProvider) {
+				s.EXPECT().User(ctx, user.Username).Return(user, nil).Once()
+			},
+			mockOneTimeTokens: func(s *mocks.OneTimeTokenStorage) {
+				s.EXPECT().DeleteUserOneTimeTokens(mock.Anything, user.ID, purposePasswordReset).Return(nil).Once()
+				s.EXPECT().
+					SaveOneTimeToken(mock.Anything, mock.MatchedBy(func(token models.OneTimeToken) bool {
+						return token.UserID == user.ID && token.Purpose == purposePasswordReset && len(token.TokenHash) > 0
+					})).
+					Return(nil).Once()
+			},
+			mockNotifier: func(s *mocks.Notifier) {
+				s.EXPECT().
+					Notify(mock.Anything, mock.MatchedBy(func(n models.Notification) bool {
+						return n.Kind == models.NotificationPasswordReset && n.UserID == user.ID && n.Token != ""
+					})).
+					Return(nil).Once()
+			},
+		},
+		{
+			nameTest: "Unknown user",
+			username: "JohnTravolta",
+			mockProvider: func(s *mocks.UserProvider) {
+				s.EXPECT().User(ctx, "JohnTravolta").Return(models.User{}, storage.ErrUserNotFound).Once()
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nameTest, func(t *testing.T) {
+			userProvider := mocks.NewUserProvider(t)
+			tc.mockProvider(userProvider)
+
+			oneTimeTokens := mocks.NewOneTimeTokenStorage(t)
+			if tc.mockOneTimeTokens != nil {
+				tc.mockOneTimeTokens(oneTimeTokens)
+			}
+
+			notifier := mocks.NewNotifier(t)
+			if tc.mockNotifier != nil {
+				tc.mockNotifier(notifier)
+			}
+
+			s := Auth{
+				UserProvider:  userProvider,
+				oneTimeTokens: oneTimeTokens,
+				notifier:      notifier,
+				ResetTokenTTL: 15 * time.Minute,
+				log:           log,
+			}
+
+			err := s.RequestPasswordReset(ctx, tc.username)
+			s.Stop()
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func Test_Auth_ResetPassword(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(
+		slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+	)
+
+	const token = "reset-token"
+
+	errStorageIsDown := errors.New("storage is down")
+
+	user := models.User{ID: 1, Name: "Matvey", Username: "MatveyTabby"}
+	stored := models.OneTimeToken{
+		ID:        10,
+		UserID:    user.ID,
+		Purpose:   purposePasswordReset,
+		TokenHash: hashOpaqueToken(token),
+		ExpiresAt: time.Now().Add(time.Minute),
+	}
+
+	tests := []struct {
+		nameTest          string
+		newPassword       string
+		mockOneTimeTokens func(s *mocks.OneTimeTokenStorage)
+		mockProvider      func(s *mocks.UserProvider)
+		mockUserSaver     func(s *mocks.UserSaver)
+		mockRevoker       func(s *mocks.TokenRevoker)
+		mockRefreshTokens func(s *mocks.RefreshTokenStorage)
+		expectedErr       error
+	}{
+		{
+			nameTest:    "Success",
+			newPassword: "new password",
+			mockOneTimeTokens: func(s *mocks.OneTimeTokenStorage) {
+				s.EXPECT().OneTimeToken(ctx, hashOpaqueToken(token), purposePasswordReset).Return(stored, nil).Once()
+				s.EXPECT().UseResetToken(ctx, stored.ID, user.ID, mock.Anything).Return(nil).Once()
+			},
+			mockProvider: func(s *mocks.UserProvider) {
+				s.EXPECT().UserByID(ctx, user.ID).Return(user, nil).Once()
+			},
+			mockRevoker: func(s *mocks.TokenRevoker) {
+				s.EXPECT().RevokeUserTokens(ctx, user.ID).Return(nil).Once()
+			},
+			mockRefreshTokens: func(s *mocks.RefreshTokenStorage) {
+				s.EXPECT().RevokeUserRefreshTokens(ctx, user.ID).Return(nil).Once()
+			},
+		},
+		{
+			nameTest:    "Unknown token",
+			newPassword: "new password",
+			mockOneTimeTokens: func(s *mocks.OneTimeTokenStorage) {
+				s.EXPECT().OneTimeToken(ctx, mock.Anything, purposePasswordReset).Return(models.OneTimeToken{}, storage.ErrOneTimeTokenNotFound).Once()
+			},
+			expectedErr: ErrInvalidResetToken,
+		},
+		{
+			nameTest:    "Expired token",
+			newPassword: "new password",
+			mockOneTimeTokens: func(s *mocks.OneTimeTokenStorage) {
+				expired := stored
+				expired.ExpiresAt = time.Now().Add(-time.Minute)
+				s.EXPECT().OneTimeToken(ctx, mock.Anything, purposePasswordReset).Return(expired, nil).Once()
+			},
+			expectedErr: ErrInvalidResetToken,
+		},
+		{
+			nameTest:    "Used token",
+			newPassword: "new password",
+			mockOneTimeTokens: func(s *mocks.OneTimeTokenStorage) {
+				used := stored
+				used.UsedAt = time.Now().Add(-time.Minute)
+				s.EXPECT().OneTimeToken(ctx, mock.Anything, purposePasswordReset).Return(used, nil).Once()
+			},
+			expectedErr: ErrInvalidResetToken,
+		},
+		{
+			nameTest:    "Weak password keeps the token",
+			newPassword: "short",
+			mockOneTimeTokens: func(s *mocks.OneTimeTokenStorage) {
+				s.EXPECT().OneTimeToken(ctx, mock.Anything, purposePasswordReset).Return(stored, nil).Once()
+			},
+			mockProvider: func(s *mocks.UserProvider) {
+				s.EXPECT().UserByID(ctx, user.ID).Return(user, nil).Once()
+			},
+			expectedErr: ErrWeakPassword,
+		},
+		{
+			nameTest:    "Concurrent use",
+			newPassword: "new password",
+			mockOneTimeTokens: func(s *mocks.OneTimeTokenStorage) {
+				s.EXPECT().OneTimeToken(ctx, mock.Anything, purposePasswordReset).Return(stored, nil).Once()
+				s.EXPECT().UseResetToken(ctx, stored.ID, user.ID, mock.Anything).Return(storage.ErrOneTimeTokenUsed).Once()
+			},
+			mockProvider: func(s *mocks.UserProvider) {
+				s.EXPECT().UserByID(ctx, user.ID).Return(user, nil).Once()
+			},
+			expectedErr: ErrInvalidResetToken,
+		},
+		{
+			nameTest:    "Failed update keeps the token",
+			newPassword: "new password",
+			mockOneTimeTokens: func(s *mocks.OneTimeTokenStorage) {
+				s.EXPECT().OneTimeToken(ctx, mock.Anything, purposePasswordReset).Return(stored, nil).Once()
+				s.EXPECT().UseResetToken(ctx, stored.ID, user.ID, mock.Anything).Return(errStorageIsDown).Once()
+			},
+			mockProvider: func(s *mocks.UserProvider) {
+				s.EXPECT().UserByID(ctx, user.ID).Return(user, nil).Once()
+			},
+			expectedErr: errStorageIsDown,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nameTest, func(t *testing.T) {
+			oneTimeTokens := mocks.NewOneTimeTokenStorage(t)
+			tc.mockOneTimeTokens(oneTimeTokens)
+
+			userProvider := mocks.NewUserProvider(t)
+			if tc.mockProvider != nil {
+				tc.mockProvider(userProvider)
+			}
+
+			userSaver := mocks.NewUserSaver(t)
+			if tc.mockUserSaver != nil {
+				tc.mockUserSaver(userSaver)
+			}
+
+			revoker := mocks.NewTokenRevoker(t)
+			if tc.mockRevoker != nil {
+				tc.mockRevoker(revoker)
+			}
+
+			refreshTokens := mocks.NewRefreshTokenStorage(t)
+			if tc.mockRefreshTokens != nil {
+				tc.mockRefreshTokens(refreshTokens)
+			}
+
+			s := Auth{
+				UserProvider:   userProvider,
+				UserSaver:      userSaver,
+				revoker:        revoker,
+				refreshTokens:  refreshTokens,
+				oneTimeTokens:  oneTimeTokens,
+				hasher:         password.NewHasher(password.Bcrypt{Cost: bcrypt.MinCost}),
+				passwordPolicy: password.Policy{MinLength: 8},
+				log:            log,
+			}
+
+			err := s.ResetPassword(ctx, token, tc.newPassword)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
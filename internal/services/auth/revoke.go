@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"auth/internal/jwt"
+	"auth/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+var ErrTokenRevoked = errors.New("token is revoked")
+
+//go:generate go run github.com/vektra/mockery/v2@latest --name=TokenRevoker --with-expecter=true
+type TokenRevoker interface {
+	RevokeToken(ctx context.Context,
+		jti string,
+		expiresAt time.Time,
+	) error
+
+	IsTokenRevoked(ctx context.Context,
+		jti string,
+	) (bool, error)
+
+	RevokeUserTokens(ctx context.Context,
+		uid int,
+	) error
+
+	UserTokenVersion(ctx context.Context,
+		uid int,
+	) (int, error)
+}
+
+// Logout revokes the access token issued for the app and the session it belongs to.
+func (a *Auth) Logout(
+	ctx context.Context,
+	token string,
+	appID int,
+) error {
+	const op = "auth.Logout"
+
+	log := a.log.With(slog.String("op", op))
+
+	claims, err := a.ValidateAppToken(ctx, token, appID)
+	if err != nil {
+		if errors.Is(err, ErrTokenRevoked) {
+			return nil
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int64("uid", claims.UID))
+
+	if err := a.revoker.RevokeToken(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+		log.Error("failed to revoke token", "", err.Error())
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if claims.SessionID != "" {
+		if err := a.refreshTokens.RevokeTokenFamily(ctx, claims.SessionID); err != nil {
+			log.Error("failed to revoke session", "", err.Error())
+
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	log.Info("user logged out")
+
+	return nil
+}
+
+// LogoutAll revokes every token issued to the user so far, on all devices.
+// Tokens carry the token version of the user and the call bumps it, so unlike a cutoff time
+// it doesn't depend on the precision of iat: a token issued in the same second is revoked
+// if it was issued before the call, and a login after the call is not.
+func (a *Auth) LogoutAll(
+	ctx context.Context,
+	uid int,
+) error {
+	const op = "auth.LogoutAll"
+
+	log := a.log.With(slog.String("op", op), slog.Int("uid", uid))
+
+	if err := a.revoker.RevokeUserTokens(ctx, uid); err != nil {
+		log.Error("failed to revoke user tokens", "", err.Error())
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.refreshTokens.RevokeUserRefreshTokens(ctx, uid); err != nil {
+		log.Error("failed to revoke refresh tokens", "", err.Error())
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("user logged out from all devices")
+
+	return nil
+}
+
+// checkRevoked fails with ErrTokenRevoked if the token has been revoked by itself
+// or together with all tokens of the user.
+func (a *Auth) checkRevoked(ctx context.Context, claims *jwt.Claims) error {
+	revoked, err := a.revoker.IsTokenRevoked(ctx, claims.ID)
+	if err != nil {
+		return err
+	}
+
+	if revoked {
+		return ErrTokenRevoked
+	}
+
+	version, err := a.revoker.UserTokenVersion(ctx, int(claims.UID))
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return ErrTokenRevoked
+		}
+
+		return err
+	}
+
+	if claims.TokenVersion != version {
+		return ErrTokenRevoked
+	}
+
+	return nil
+}
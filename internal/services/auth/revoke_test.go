@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/jwt"
+	"auth/internal/services/auth/mocks"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_Auth_Logout(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(
+		slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+	)
+
+	key := jwt.Key{ID: "test", Secret: []byte("test-signing-key")}
+	user := models.User{ID: 1, Name: "Matvey", Username: "MatveyTabby"}
+
+	token, err := jwt.NewToken(user, key, jwt.TokenOptions{TTL: time.Hour, SessionID: "family"})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		nameTest          string
+		mockRevoker       func(s *mocks.TokenRevoker)
+		mockRefreshTokens func(s *mocks.RefreshTokenStorage)
+		expectedErrStr    string
+	}{
+		{
+			nameTest: "Success",
+			mockRevoker: func(s *mocks.TokenRevoker) {
+				s.EXPECT().IsTokenRevoked(ctx, mock.Anything).Return(false, nil).Once()
+				s.EXPECT().UserTokenVersion(ctx, user.ID).Return(0, nil).Once()
+				s.EXPECT().RevokeToken(ctx, mock.Anything, mock.Anything).Return(nil).Once()
+			},
+			mockRefreshTokens: func(s *mocks.RefreshTokenStorage) {
+				s.EXPECT().RevokeTokenFamily(ctx, "family").Return(nil).Once()
+			},
+		},
+		{
+			nameTest: "Already revoked",
+			mockRevoker: func(s *mocks.TokenRevoker) {
+				s.EXPECT().IsTokenRevoked(ctx, mock.Anything).Return(true, nil).Once()
+			},
+		},
+		{
+			nameTest: "Error during revoke",
+			mockRevoker: func(s *mocks.TokenRevoker) {
+				s.EXPECT().IsTokenRevoked(ctx, mock.Anything).Return(false, nil).Once()
+				s.EXPECT().UserTokenVersion(ctx, user.ID).Return(0, nil).Once()
+				s.EXPECT().RevokeToken(ctx, mock.Anything, mock.Anything).Return(fmt.Errorf("storage is down")).Once()
+			},
+			expectedErrStr: "storage is down",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nameTest, func(t *testing.T) {
+			revoker := mocks.NewTokenRevoker(t)
+			tc.mockRevoker(revoker)
+
+			refreshTokens := mocks.NewRefreshTokenStorage(t)
+			if tc.mockRefreshTokens != nil {
+				tc.mockRefreshTokens(refreshTokens)
+			}
+
+			s := Auth{
+				refreshTokens: refreshTokens,
+				revoker:       revoker,
+				log:           log,
+				keys:          jwt.NewKeyRing(key),
+			}
+
+			err := s.Logout(ctx, token, emptyAppID)
+
+			if tc.expectedErrStr != "" {
+				assert.ErrorContains(t, err, tc.expectedErrStr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_Auth_LogoutAll(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(
+		slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+	)
+
+	key := jwt.Key{ID: "test", Secret: []byte("test-signing-key")}
+	user := models.User{ID: 1, Name: "Matvey", Username: "MatveyTabby"}
+
+	// The store keeps the token version of the user, bumped by every logout from all devices.
+	version := 0
+
+	revoker := mocks.NewTokenRevoker(t)
+	revoker.EXPECT().RevokeUserTokens(ctx, user.ID).RunAndReturn(func(context.Context, int) error {
+		version++
+		return nil
+	}).Once()
+	revoker.EXPECT().UserTokenVersion(ctx, user.ID).RunAndReturn(func(context.Context, int) (int, error) {
+		return version, nil
+	})
+	revoker.EXPECT().IsTokenRevoked(ctx, mock.Anything).Return(false, nil)
+
+	refreshTokens := mocks.NewRefreshTokenStorage(t)
+	refreshTokens.EXPECT().RevokeUserRefreshTokens(ctx, user.ID).Return(nil).Once()
+
+	s := Auth{
+		refreshTokens: refreshTokens,
+		revoker:       revoker,
+		log:           log,
+		keys:          jwt.NewKeyRing(key),
+	}
+
+	// Start at the beginning of a second, so both tokens and the logout fall into the same one.
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+
+	before, err := jwt.NewToken(user, key, jwt.TokenOptions{TTL: time.Hour})
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.LogoutAll(ctx, user.ID))
+
+	user.TokenVersion = version
+	after, err := jwt.NewToken(user, key, jwt.TokenOptions{TTL: time.Hour})
+	assert.NoError(t, err)
+
+	beforeClaims, err := jwt.Verify(before, jwt.NewKeyRing(key), jwt.VerifyOptions{})
+	assert.NoError(t, err)
+	afterClaims, err := jwt.Verify(after, jwt.NewKeyRing(key), jwt.VerifyOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, beforeClaims.IssuedAt, afterClaims.IssuedAt, "issued in the same second")
+
+	_, err = s.ValidateToken(ctx, before)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+
+	_, err = s.ValidateToken(ctx, after)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"auth/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+)
+
+// RoleAdmin is the role of the service administrators.
+const RoleAdmin = "admin"
+
+var ErrRoleNotFound = errors.New("role not found")
+
+//go:generate go run github.com/vektra/mockery/v2@latest --name=RoleProvider --with-expecter=true
+type RoleProvider interface {
+	UserRoles(ctx context.Context,
+		uid int,
+	) ([]string, error)
+
+	UserPermissions(ctx context.Context,
+		uid int,
+	) ([]string, error)
+
+	AssignRole(ctx context.Context,
+		uid int,
+		role string,
+	) error
+
+	RevokeRole(ctx context.Context,
+		uid int,
+		role string,
+	) error
+}
+
+// AssignRole grants the role to the user. Tokens carry the role from the next login or refresh.
+func (a *Auth) AssignRole(
+	ctx context.Context,
+	uid int,
+	role string,
+) error {
+	const op = "auth.AssignRole"
+
+	log := a.log.With(slog.String("op", op), slog.Int("uid", uid), slog.String("role", role))
+
+	if err := a.roleProvider.AssignRole(ctx, uid, role); err != nil {
+		return a.roleError(log, op, "failed to assign role", err)
+	}
+
+	log.Info("role assigned")
+
+	return nil
+}
+
+// RevokeRole takes the role away from the user. Tokens lose the role from the next login or refresh.
+func (a *Auth) RevokeRole(
+	ctx context.Context,
+	uid int,
+	role string,
+) error {
+	const op = "auth.RevokeRole"
+
+	log := a.log.With(slog.String("op", op), slog.Int("uid", uid), slog.String("role", role))
+
+	if err := a.roleProvider.RevokeRole(ctx, uid, role); err != nil {
+		return a.roleError(log, op, "failed to revoke role", err)
+	}
+
+	log.Info("role revoked")
+
+	return nil
+}
+
+func (a *Auth) roleError(log *slog.Logger, op string, msg string, err error) error {
+	switch {
+	case errors.Is(err, storage.ErrRoleNotFound):
+		log.Warn("role not found")
+		return fmt.Errorf("%s: %w", op, ErrRoleNotFound)
+	case errors.Is(err, storage.ErrUserNotFound):
+		log.Warn("user not found")
+		return fmt.Errorf("%s: %w", op, ErrUserNotFound)
+	}
+
+	log.Error(msg, "", err.Error())
+
+	return fmt.Errorf("%s: %w", op, err)
+}
+
+func (a *Auth) IsAdmin(
+	ctx context.Context,
+	uid int,
+) (bool, error) {
+	const op = "auth.IsAdmin"
+
+	roles, err := a.roleProvider.UserRoles(ctx, uid)
+	if err != nil {
+		a.log.Error("failed to get user roles", slog.String("op", op), slog.String("error", err.Error()))
+
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return slices.Contains(roles, RoleAdmin), nil
+}
+
+func (a *Auth) HasPermission(
+	ctx context.Context,
+	uid int,
+	permission string,
+) (bool, error) {
+	const op = "auth.HasPermission"
+
+	permissions, err := a.roleProvider.UserPermissions(ctx, uid)
+	if err != nil {
+		a.log.Error("failed to get user permissions", slog.String("op", op), slog.String("error", err.Error()))
+
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return slices.Contains(permissions, permission), nil
+}
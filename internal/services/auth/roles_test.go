@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"auth/internal/services/auth/mocks"
+	"auth/internal/storage"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Auth_AssignRole(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(
+		slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+	)
+
+	tests := []struct {
+		nameTest       string
+		storageErr     error
+		expectedErr    error
+		expectedErrStr string
+	}{
+		{
+			nameTest: "Success",
+		},
+		{
+			nameTest:    "Unknown role",
+			storageErr:  storage.ErrRoleNotFound,
+			expectedErr: ErrRoleNotFound,
+		},
+		{
+			nameTest:    "Unknown user",
+			storageErr:  storage.ErrUserNotFound,
+			expectedErr: ErrUserNotFound,
+		},
+		{
+			nameTest:       "Another error",
+			storageErr:     fmt.Errorf("storage is down"),
+			expectedErrStr: "storage is down",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nameTest, func(t *testing.T) {
+			roleProvider := mocks.NewRoleProvider(t)
+			roleProvider.EXPECT().AssignRole(ctx, 1, RoleAdmin).Return(tc.storageErr).Once()
+
+			s := Auth{
+				roleProvider: roleProvider,
+				log:          log,
+			}
+
+			err := s.AssignRole(ctx, 1, RoleAdmin)
+
+			switch {
+			case tc.expectedErr != nil:
+				assert.ErrorIs(t, err, tc.expectedErr)
+			case tc.expectedErrStr != "":
+				assert.ErrorContains(t, err, tc.expectedErrStr)
+			default:
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_Auth_IsAdmin_HasPermission(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(
+		slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+	)
+
+	roleProvider := mocks.NewRoleProvider(t)
+	roleProvider.EXPECT().UserRoles(ctx, 1).Return([]string{"admin", "support"}, nil)
+	roleProvider.EXPECT().UserRoles(ctx, 2).Return([]string{"support"}, nil)
+	roleProvider.EXPECT().UserPermissions(ctx, 2).Return([]string{"users.read"}, nil)
+
+	s := Auth{
+		roleProvider: roleProvider,
+		log:          log,
+	}
+
+	isAdmin, err := s.IsAdmin(ctx, 1)
+	assert.NoError(t, err)
+	assert.True(t, isAdmin)
+
+	isAdmin, err = s.IsAdmin(ctx, 2)
+	assert.NoError(t, err)
+	assert.False(t, isAdmin)
+
+	allowed, err := s.HasPermission(ctx, 2, "users.read")
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+
+	allowed, err = s.HasPermission(ctx, 2, "users.write")
+	assert.NoError(t, err)
+	assert.False(t, allowed)
+}
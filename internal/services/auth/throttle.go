@@ -0,0 +1,261 @@
+package auth
+
+import (
+	"auth/internal/canonical"
+	"auth/internal/domain/models"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+const (
+	userAttemptsPrefix = "user:"
+	ipAttemptsPrefix   = "ip:"
+)
+
+var ErrTooManyAttempts = errors.New("too many login attempts")
+
+// AttemptsError is returned by Login while the username or the client address is throttled.
+// Locked is set when the user has reached the lockout threshold, not just a delay.
+// It matches ErrTooManyAttempts.
+type AttemptsError struct {
+	RetryAfter time.Duration
+	Locked     bool
+}
+
+func (e *AttemptsError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", ErrTooManyAttempts, e.RetryAfter)
+}
+
+func (e *AttemptsError) Is(target error) bool {
+	return target == ErrTooManyAttempts
+}
+
+// AttemptStore counts failed logins per key.
+// RecordLoginFailure must count atomically and return the count including the new failure,
+// it starts a new count when the last failure is older than window.
+// CancelLoginFailure takes back the failure counted at failedAt, the count of a key never goes below zero.
+// Unless a later failure has been counted since, the last failure of the key goes back to lastFailure.
+//
+//go:generate go run github.com/vektra/mockery/v2@latest --name=AttemptStore --with-expecter=true
+type AttemptStore interface {
+	LoginAttempts(ctx context.Context,
+		key string,
+	) (models.LoginAttempts, error)
+
+	RecordLoginFailure(ctx context.Context,
+		key string,
+		now time.Time,
+		window time.Duration,
+	) (models.LoginAttempts, error)
+
+	ResetLoginAttempts(ctx context.Context,
+		key string,
+	) error
+
+	CancelLoginFailure(ctx context.Context,
+		key string,
+		failedAt time.Time,
+		lastFailure time.Time,
+	) error
+}
+
+// Throttle is the policy applied to failed logins.
+// The first FreeAttempts failures cost nothing, each next one doubles the delay
+// before another attempt starting from BaseDelay up to MaxDelay.
+// LockoutThreshold failures lock the key for LockoutDuration.
+type Throttle struct {
+	Window           time.Duration
+	FreeAttempts     int
+	BaseDelay        time.Duration
+	MaxDelay         time.Duration
+	LockoutThreshold int
+	LockoutDuration  time.Duration
+}
+
+// delay returns how long to wait after the last of the failures.
+func (t Throttle) delay(failures int) time.Duration {
+	if t.locked(failures) {
+		return t.LockoutDuration
+	}
+
+	if failures <= t.FreeAttempts {
+		return 0
+	}
+
+	delay := t.BaseDelay
+	for i := t.FreeAttempts + 1; i < failures && delay < t.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, t.MaxDelay)
+}
+
+// locked reports whether the failures have reached the lockout threshold.
+func (t Throttle) locked(failures int) bool {
+	return t.LockoutThreshold > 0 && failures >= t.LockoutThreshold
+}
+
+// retryAfter returns how long the key stays throttled, zero if it is not.
+func (t Throttle) retryAfter(attempts models.LoginAttempts, now time.Time) time.Duration {
+	if attempts.Failures == 0 {
+		return 0
+	}
+
+	return max(attempts.LastFailure.Add(t.delay(attempts.Failures)).Sub(now), 0)
+}
+
+// attemptKeys returns the keys failed logins are counted under.
+func attemptKeys(username, clientIP string) []string {
+	keys := []string{userAttemptKey(username)}
+	if clientIP != "" {
+		keys = append(keys, ipAttemptsPrefix+clientIP)
+	}
+
+	return keys
+}
+
+// reservation is a failure counted by reserveAttempt before the credentials are checked.
+// Taking it back restores the last failures of the keys, so logins that don't fail
+// don't keep a shared client address from aging out.
+type reservation struct {
+	keys         []string
+	at           time.Time
+	lastFailures []time.Time
+}
+
+// reserveAttempt fails with AttemptsError if any of the keys is throttled. Otherwise it counts the attempt
+// as failed under every key before the credentials are checked, so that parallel attempts can't all pass
+// the check before any of them is counted: an attempt that finds more failures counted since the check
+// than the throttle allows is rejected as well. The caller takes the failure back with succeedAttempt
+// or cancelAttempt once the credentials turn out to be right, a wrong guess needs no further call.
+// Only a lockout of the user key locks the account, a locked client address just delays it.
+// Throttling is disabled when the service has no AttemptStore.
+func (a *Auth) reserveAttempt(ctx context.Context, keys []string) (reservation, error) {
+	reserved := reservation{keys: keys}
+
+	if a.attempts == nil {
+		return reserved, nil
+	}
+
+	now := time.Now()
+
+	var (
+		retryAfter time.Duration
+		locked     bool
+	)
+
+	seen := make([]int, len(keys))
+	reserved.lastFailures = make([]time.Time, len(keys))
+	for i, key := range keys {
+		attempts, err := a.attempts.LoginAttempts(ctx, key)
+		if err != nil {
+			return reservation{}, err
+		}
+
+		seen[i] = attempts.Failures
+		reserved.lastFailures[i] = attempts.LastFailure
+
+		keyRetryAfter := a.throttle.retryAfter(attempts, now)
+		if keyRetryAfter > 0 && isUserAttemptKey(key) && a.throttle.locked(attempts.Failures) {
+			locked = true
+		}
+
+		retryAfter = max(retryAfter, keyRetryAfter)
+	}
+
+	if retryAfter > 0 {
+		return reservation{}, &AttemptsError{RetryAfter: retryAfter, Locked: locked}
+	}
+
+	reserved.at = now
+
+	for i, key := range keys {
+		attempts, err := a.attempts.RecordLoginFailure(ctx, key, now, a.throttle.Window)
+		if err != nil {
+			return reservation{}, err
+		}
+
+		// The failures counted before this attempt, the ones above seen were counted by parallel attempts
+		// an instant ago, so their delay starts now.
+		before := attempts.Failures - 1
+		if before <= seen[i] {
+			continue
+		}
+
+		if delay := a.throttle.delay(before); delay > 0 {
+			if isUserAttemptKey(key) && a.throttle.locked(before) {
+				locked = true
+			}
+
+			retryAfter = max(retryAfter, delay)
+		}
+	}
+
+	if retryAfter > 0 {
+		return reservation{}, &AttemptsError{RetryAfter: retryAfter, Locked: locked}
+	}
+
+	return reserved, nil
+}
+
+// succeedAttempt ends an attempt reserved with reserveAttempt that has succeeded. The failures of the user
+// are forgotten, while the client address only gets its reserved failure back: otherwise an attacker
+// owning one account could reset the address between guesses at the others.
+// The login succeeds anyway, so an error is only logged.
+func (a *Auth) succeedAttempt(ctx context.Context, log *slog.Logger, reserved reservation) {
+	if a.attempts == nil {
+		return
+	}
+
+	for i, key := range reserved.keys {
+		var err error
+		if isUserAttemptKey(key) {
+			err = a.attempts.ResetLoginAttempts(ctx, key)
+		} else {
+			err = a.attempts.CancelLoginFailure(ctx, key, reserved.at, reserved.lastFailures[i])
+		}
+
+		if err != nil {
+			log.Error("failed to reset login attempts", "", err.Error())
+		}
+	}
+}
+
+// cancelAttempt takes back the failure reserved under every key by reserveAttempt,
+// for an attempt that has turned out not to be a failed guess. An error is only logged.
+func (a *Auth) cancelAttempt(ctx context.Context, log *slog.Logger, reserved reservation) {
+	if a.attempts == nil {
+		return
+	}
+
+	for i, key := range reserved.keys {
+		if err := a.attempts.CancelLoginFailure(ctx, key, reserved.at, reserved.lastFailures[i]); err != nil {
+			log.Error("failed to cancel login failure", "", err.Error())
+		}
+	}
+}
+
+// resetAttempts forgets the failures of the user, when the password is reset.
+// The counts of client addresses are kept.
+func (a *Auth) resetAttempts(ctx context.Context, username string) error {
+	if a.attempts == nil {
+		return nil
+	}
+
+	return a.attempts.ResetLoginAttempts(ctx, userAttemptKey(username))
+}
+
+// userAttemptKey returns the key failed logins of the user are counted under.
+// It is built from the canonical username, so case or look-alike variants of the name
+// can't be used to get more guesses.
+func userAttemptKey(username string) string {
+	return userAttemptsPrefix + canonical.Username(username)
+}
+
+func isUserAttemptKey(key string) bool {
+	return strings.HasPrefix(key, userAttemptsPrefix)
+}
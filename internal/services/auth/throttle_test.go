@@ -0,0 +1,385 @@
+package auth
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/jwt"
+	"auth/internal/password"
+	"auth/internal/services/auth/mocks"
+	"auth/internal/storage"
+	"auth/internal/storage/memory"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func Test_Throttle_delay(t *testing.T) {
+	throttle := Throttle{
+		FreeAttempts:     3,
+		BaseDelay:        time.Second,
+		MaxDelay:         10 * time.Second,
+		LockoutThreshold: 10,
+		LockoutDuration:  15 * time.Minute,
+	}
+
+	tests := []struct {
+		failures      int
+		expectedDelay time.Duration
+	}{
+		{failures: 0, expectedDelay: 0},
+		{failures: 3, expectedDelay: 0},
+		{failures: 4, expectedDelay: time.Second},
+		{failures: 5, expectedDelay: 2 * time.Second},
+		{failures: 6, expectedDelay: 4 * time.Second},
+		{failures: 8, expectedDelay: 10 * time.Second},
+		{failures: 9, expectedDelay: 10 * time.Second},
+		{failures: 10, expectedDelay: 15 * time.Minute},
+	}
+
+	for _, tc := range tests {
+		assert.Equal(t, tc.expectedDelay, throttle.delay(tc.failures), "failures: %d", tc.failures)
+	}
+}
+
+func Test_Auth_Login_Throttle(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(
+		slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+	)
+
+	const clientIP = "10.0.0.1"
+
+	passHash, _ := bcrypt.GenerateFromPassword([]byte("123456"), bcrypt.MinCost)
+	user := models.User{ID: 1, Name: "Matvey", Username: "MatveyTabby", PassHash: passHash}
+
+	userKey := userAttemptKey(user.Username)
+	ipKey := ipAttemptsPrefix + clientIP
+
+	throttle := Throttle{
+		Window:           15 * time.Minute,
+		FreeAttempts:     3,
+		BaseDelay:        time.Second,
+		MaxDelay:         time.Minute,
+		LockoutThreshold: 10,
+		LockoutDuration:  15 * time.Minute,
+	}
+
+	tests := []struct {
+		nameTest     string
+		login        string
+		password     string
+		mockAttempts func(s *mocks.AttemptStore)
+		mockProvider func(s *mocks.UserProvider)
+		expectedErr  error
+		expectLocked bool
+	}{
+		{
+			nameTest: "Success resets the user and cancels the address failure",
+			password: "123456",
+			mockAttempts: func(s *mocks.AttemptStore) {
+				s.EXPECT().LoginAttempts(ctx, userKey).Return(models.LoginAttempts{Failures: 2, LastFailure: time.Now()}, nil).Once()
+				s.EXPECT().LoginAttempts(ctx, ipKey).Return(models.LoginAttempts{}, nil).Once()
+				s.EXPECT().RecordLoginFailure(ctx, userKey, mock.Anything, throttle.Window).Return(models.LoginAttempts{Failures: 3}, nil).Once()
+				s.EXPECT().RecordLoginFailure(ctx, ipKey, mock.Anything, throttle.Window).Return(models.LoginAttempts{Failures: 1}, nil).Once()
+				s.EXPECT().ResetLoginAttempts(ctx, userKey).Return(nil).Once()
+				s.EXPECT().CancelLoginFailure(ctx, ipKey, mock.Anything, mock.Anything).Return(nil).Once()
+			},
+			mockProvider: func(s *mocks.UserProvider) {
+				s.EXPECT().User(ctx, user.Username).Return(user, nil).Once()
+			},
+		},
+		{
+			nameTest: "Wrong password counts both keys",
+			password: "654321",
+			mockAttempts: func(s *mocks.AttemptStore) {
+				s.EXPECT().LoginAttempts(ctx, mock.Anything).Return(models.LoginAttempts{}, nil).Twice()
+				s.EXPECT().RecordLoginFailure(ctx, userKey, mock.Anything, throttle.Window).Return(models.LoginAttempts{Failures: 1}, nil).Once()
+				s.EXPECT().RecordLoginFailure(ctx, ipKey, mock.Anything, throttle.Window).Return(models.LoginAttempts{Failures: 1}, nil).Once()
+			},
+			mockProvider: func(s *mocks.UserProvider) {
+				s.EXPECT().User(ctx, user.Username).Return(user, nil).Once()
+			},
+			expectedErr: ErrInvalidCredentials,
+		},
+		{
+			nameTest: "Unknown user counts both keys",
+			password: "123456",
+			mockAttempts: func(s *mocks.AttemptStore) {
+				s.EXPECT().LoginAttempts(ctx, mock.Anything).Return(models.LoginAttempts{}, nil).Twice()
+				s.EXPECT().RecordLoginFailure(ctx, mock.Anything, mock.Anything, throttle.Window).Return(models.LoginAttempts{Failures: 1}, nil).Twice()
+			},
+			mockProvider: func(s *mocks.UserProvider) {
+				s.EXPECT().User(ctx, user.Username).Return(models.User{}, storage.ErrUserNotFound).Once()
+			},
+			expectedErr: ErrInvalidCredentials,
+		},
+		{
+			nameTest: "Login by email counts under the username",
+			login:    "Matvey@Example.com",
+			password: "654321",
+			mockAttempts: func(s *mocks.AttemptStore) {
+				s.EXPECT().LoginAttempts(ctx, mock.Anything).Return(models.LoginAttempts{}, nil).Twice()
+				s.EXPECT().RecordLoginFailure(ctx, userKey, mock.Anything, throttle.Window).Return(models.LoginAttempts{Failures: 1}, nil).Once()
+				s.EXPECT().RecordLoginFailure(ctx, ipKey, mock.Anything, throttle.Window).Return(models.LoginAttempts{Failures: 1}, nil).Once()
+			},
+			mockProvider: func(s *mocks.UserProvider) {
+				s.EXPECT().UserByEmail(ctx, "Matvey@Example.com").Return(user, nil).Once()
+			},
+			expectedErr: ErrInvalidCredentials,
+		},
+		{
+			nameTest: "User backs off",
+			password: "123456",
+			mockAttempts: func(s *mocks.AttemptStore) {
+				s.EXPECT().LoginAttempts(ctx, userKey).Return(models.LoginAttempts{Failures: 5, LastFailure: time.Now()}, nil).Once()
+				s.EXPECT().LoginAttempts(ctx, ipKey).Return(models.LoginAttempts{}, nil).Once()
+			},
+			mockProvider: func(s *mocks.UserProvider) {
+				s.EXPECT().User(ctx, user.Username).Return(user, nil).Once()
+			},
+			expectedErr: ErrTooManyAttempts,
+		},
+		{
+			nameTest: "User is locked out",
+			password: "123456",
+			mockAttempts: func(s *mocks.AttemptStore) {
+				s.EXPECT().LoginAttempts(ctx, userKey).Return(models.LoginAttempts{Failures: 10, LastFailure: time.Now()}, nil).Once()
+				s.EXPECT().LoginAttempts(ctx, ipKey).Return(models.LoginAttempts{}, nil).Once()
+			},
+			mockProvider: func(s *mocks.UserProvider) {
+				s.EXPECT().User(ctx, user.Username).Return(user, nil).Once()
+			},
+			expectedErr:  ErrTooManyAttempts,
+			expectLocked: true,
+		},
+		{
+			nameTest: "Address is locked out",
+			password: "123456",
+			mockAttempts: func(s *mocks.AttemptStore) {
+				s.EXPECT().LoginAttempts(ctx, userKey).Return(models.LoginAttempts{}, nil).Once()
+				s.EXPECT().LoginAttempts(ctx, ipKey).Return(models.LoginAttempts{Failures: 10, LastFailure: time.Now()}, nil).Once()
+			},
+			mockProvider: func(s *mocks.UserProvider) {
+				s.EXPECT().User(ctx, user.Username).Return(user, nil).Once()
+			},
+			expectedErr: ErrTooManyAttempts,
+		},
+		{
+			nameTest: "Delay has passed",
+			password: "123456",
+			mockAttempts: func(s *mocks.AttemptStore) {
+				s.EXPECT().LoginAttempts(ctx, userKey).Return(models.LoginAttempts{Failures: 5, LastFailure: time.Now().Add(-time.Minute)}, nil).Once()
+				s.EXPECT().LoginAttempts(ctx, ipKey).Return(models.LoginAttempts{}, nil).Once()
+				s.EXPECT().RecordLoginFailure(ctx, userKey, mock.Anything, throttle.Window).Return(models.LoginAttempts{Failures: 6}, nil).Once()
+				s.EXPECT().RecordLoginFailure(ctx, ipKey, mock.Anything, throttle.Window).Return(models.LoginAttempts{Failures: 1}, nil).Once()
+				s.EXPECT().ResetLoginAttempts(ctx, userKey).Return(nil).Once()
+				s.EXPECT().CancelLoginFailure(ctx, ipKey, mock.Anything, mock.Anything).Return(nil).Once()
+			},
+			mockProvider: func(s *mocks.UserProvider) {
+				s.EXPECT().User(ctx, user.Username).Return(user, nil).Once()
+			},
+		},
+		{
+			nameTest: "Parallel attempt counted first",
+			password: "123456",
+			mockAttempts: func(s *mocks.AttemptStore) {
+				s.EXPECT().LoginAttempts(ctx, userKey).Return(models.LoginAttempts{Failures: 3, LastFailure: time.Now()}, nil).Once()
+				s.EXPECT().LoginAttempts(ctx, ipKey).Return(models.LoginAttempts{}, nil).Once()
+				s.EXPECT().RecordLoginFailure(ctx, userKey, mock.Anything, throttle.Window).Return(models.LoginAttempts{Failures: 5}, nil).Once()
+				s.EXPECT().RecordLoginFailure(ctx, ipKey, mock.Anything, throttle.Window).Return(models.LoginAttempts{Failures: 2}, nil).Once()
+			},
+			mockProvider: func(s *mocks.UserProvider) {
+				s.EXPECT().User(ctx, user.Username).Return(user, nil).Once()
+			},
+			expectedErr: ErrTooManyAttempts,
+		},
+		{
+			nameTest: "Parallel attempts reach the lockout",
+			password: "123456",
+			mockAttempts: func(s *mocks.AttemptStore) {
+				s.EXPECT().LoginAttempts(ctx, userKey).Return(models.LoginAttempts{Failures: 8, LastFailure: time.Now().Add(-2 * time.Minute)}, nil).Once()
+				s.EXPECT().LoginAttempts(ctx, ipKey).Return(models.LoginAttempts{}, nil).Once()
+				s.EXPECT().RecordLoginFailure(ctx, userKey, mock.Anything, throttle.Window).Return(models.LoginAttempts{Failures: 11}, nil).Once()
+				s.EXPECT().RecordLoginFailure(ctx, ipKey, mock.Anything, throttle.Window).Return(models.LoginAttempts{Failures: 1}, nil).Once()
+			},
+			mockProvider: func(s *mocks.UserProvider) {
+				s.EXPECT().User(ctx, user.Username).Return(user, nil).Once()
+			},
+			expectedErr:  ErrTooManyAttempts,
+			expectLocked: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nameTest, func(t *testing.T) {
+			attempts := mocks.NewAttemptStore(t)
+			tc.mockAttempts(attempts)
+
+			userProvider := mocks.NewUserProvider(t)
+			if tc.mockProvider != nil {
+				tc.mockProvider(userProvider)
+			}
+
+			refreshTokens := mocks.NewRefreshTokenStorage(t)
+			refreshTokens.EXPECT().SaveRefreshToken(ctx, mock.Anything).Return(nil).Maybe()
+
+			roleProvider := mocks.NewRoleProvider(t)
+			roleProvider.EXPECT().UserRoles(ctx, user.ID).Return(nil, nil).Maybe()
+			roleProvider.EXPECT().UserPermissions(ctx, user.ID).Return(nil, nil).Maybe()
+
+			s := Auth{
+				UserProvider:  userProvider,
+				refreshTokens: refreshTokens,
+				roleProvider:  roleProvider,
+				attempts:      attempts,
+				throttle:      throttle,
+				hasher:        password.NewHasher(password.Bcrypt{Cost: bcrypt.MinCost}),
+				log:           log,
+				keys:          jwt.NewKeyRing(jwt.Key{ID: "test", Secret: []byte("test-signing-key")}),
+			}
+
+			login := tc.login
+			if login == "" {
+				login = user.Username
+			}
+
+			tokens, err := s.Login(ctx, login, tc.password, 0, clientIP)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Equal(t, models.TokenPair{}, tokens)
+
+				var attemptsErr *AttemptsError
+				if errors.As(err, &attemptsErr) {
+					assert.Equal(t, tc.expectLocked, attemptsErr.Locked)
+				}
+			} else {
+				assert.NoError(t, err)
+				assert.NotEmpty(t, tokens.AccessToken)
+			}
+		})
+	}
+}
+
+func Test_Auth_Login_Throttle_Parallel(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(
+		slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}),
+	)
+
+	const guesses = 20
+
+	passHash, _ := bcrypt.GenerateFromPassword([]byte("123456"), bcrypt.MinCost)
+	user := models.User{ID: 1, Name: "Matvey", Username: "MatveyTabby", PassHash: passHash}
+
+	userProvider := mocks.NewUserProvider(t)
+	userProvider.EXPECT().User(ctx, user.Username).Return(user, nil).Maybe()
+
+	throttle := Throttle{
+		Window:           15 * time.Minute,
+		FreeAttempts:     3,
+		BaseDelay:        time.Second,
+		MaxDelay:         time.Minute,
+		LockoutThreshold: 10,
+		LockoutDuration:  15 * time.Minute,
+	}
+
+	s := Auth{
+		UserProvider: userProvider,
+		attempts:     memory.NewAttempts(),
+		throttle:     throttle,
+		hasher:       password.NewHasher(password.Bcrypt{Cost: bcrypt.MinCost}),
+		log:          log,
+		keys:         jwt.NewKeyRing(jwt.Key{ID: "test", Secret: []byte("test-signing-key")}),
+	}
+
+	var (
+		wg      sync.WaitGroup
+		checked atomic.Int32
+	)
+	for i := 0; i < guesses; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, err := s.Login(ctx, user.Username, "654321", 0, "10.0.0.1")
+			if errors.Is(err, ErrInvalidCredentials) {
+				checked.Add(1)
+			} else {
+				assert.ErrorIs(t, err, ErrTooManyAttempts)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, int(checked.Load()), throttle.FreeAttempts+1, "parallel guesses must not bypass the throttle")
+}
+
+func Test_Auth_Login_Throttle_SharedAddressAgesOut(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(
+		slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+	)
+
+	const clientIP = "10.0.0.1"
+
+	passHash, _ := bcrypt.GenerateFromPassword([]byte("123456"), bcrypt.MinCost)
+	user := models.User{ID: 1, Name: "Matvey", Username: "MatveyTabby", PassHash: passHash}
+
+	ipKey := ipAttemptsPrefix + clientIP
+
+	userProvider := mocks.NewUserProvider(t)
+	userProvider.EXPECT().User(ctx, user.Username).Return(user, nil).Times(3)
+
+	refreshTokens := mocks.NewRefreshTokenStorage(t)
+	refreshTokens.EXPECT().SaveRefreshToken(ctx, mock.Anything).Return(nil).Maybe()
+
+	roleProvider := mocks.NewRoleProvider(t)
+	roleProvider.EXPECT().UserRoles(ctx, user.ID).Return(nil, nil).Maybe()
+	roleProvider.EXPECT().UserPermissions(ctx, user.ID).Return(nil, nil).Maybe()
+
+	window := 15 * time.Minute
+
+	// Someone behind the address failed a few minutes ago.
+	attempts := memory.NewAttempts()
+	lastFailure := time.Now().Add(-5 * time.Minute)
+	_, err := attempts.RecordLoginFailure(ctx, ipKey, lastFailure, window)
+	assert.NoError(t, err)
+
+	s := Auth{
+		UserProvider:  userProvider,
+		refreshTokens: refreshTokens,
+		roleProvider:  roleProvider,
+		attempts:      attempts,
+		throttle:      Throttle{Window: window, FreeAttempts: 3, BaseDelay: time.Second, MaxDelay: time.Minute},
+		hasher:        password.NewHasher(password.Bcrypt{Cost: bcrypt.MinCost}),
+		log:           log,
+		keys:          jwt.NewKeyRing(jwt.Key{ID: "test", Secret: []byte("test-signing-key")}),
+	}
+
+	// Successful logins from the address don't count as its failures.
+	for i := 0; i < 3; i++ {
+		_, err := s.Login(ctx, user.Username, "123456", 0, clientIP)
+		assert.NoError(t, err)
+	}
+
+	ipAttempts, err := attempts.LoginAttempts(ctx, ipKey)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, ipAttempts.Failures)
+	assert.True(t, lastFailure.Equal(ipAttempts.LastFailure), "last failure of the address is kept")
+
+	// The cleanup a window after that failure.
+	attempts.Prune(lastFailure.Add(time.Second))
+
+	ipAttempts, err = attempts.LoginAttempts(ctx, ipKey)
+	assert.NoError(t, err)
+	assert.Zero(t, ipAttempts.Failures, "the address ages out")
+}
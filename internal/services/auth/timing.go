@@ -0,0 +1,22 @@
+package auth
+
+// dummyPassword is hashed once, so a login for an unknown username verifies a password
+// against a hash like a login with a wrong password does, and the response time doesn't reveal
+// which accounts exist.
+const dummyPassword = "dummy password"
+
+// burnPasswordCheck spends the time of a password check whose result is thrown away.
+// The dummy hash is made by the current scheme, so it costs as much as verifying a fresh hash.
+func (a *Auth) burnPasswordCheck(password string) {
+	a.dummyHashOnce.Do(func() {
+		hash, err := a.hasher.Hash(dummyPassword)
+		if err != nil {
+			a.log.Error("failed to generate dummy password hash", "", err.Error())
+			return
+		}
+
+		a.dummyHash = hash
+	})
+
+	_ = a.hasher.Verify(password, a.dummyHash)
+}
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/jwt"
+	"auth/internal/password"
+	"auth/internal/services/auth/mocks"
+	"auth/internal/storage"
+	"context"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Test_Auth_Login_TimingParity checks that a login for an unknown username takes as long
+// as one with a wrong password for an existing user.
+// The paths are measured in turns so drift of the machine affects both alike, and medians are compared
+// since single measurements are disturbed by the scheduler and the GC.
+func Test_Auth_Login_TimingParity(t *testing.T) {
+	if testing.Short() {
+		t.Skip("timing test is slow")
+	}
+
+	const (
+		rounds = 15
+		// maxRatio is the allowed ratio of the medians, an unprotected path is orders of magnitude faster.
+		maxRatio = 1.5
+	)
+
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	passHash, err := bcrypt.GenerateFromPassword([]byte("123456"), bcrypt.DefaultCost)
+	assert.NoError(t, err)
+
+	existing := models.User{ID: 1, Name: "Matvey", Username: "MatveyTabby", PassHash: passHash}
+	const unknown = "JohnTravolta"
+
+	userProvider := mocks.NewUserProvider(t)
+	userProvider.EXPECT().User(ctx, existing.Username).Return(existing, nil)
+	userProvider.EXPECT().User(ctx, unknown).Return(models.User{}, storage.ErrUserNotFound)
+
+	s := Auth{
+		UserProvider: userProvider,
+		hasher:       password.NewHasher(password.Bcrypt{Cost: bcrypt.DefaultCost}),
+		log:          log,
+		keys:         jwt.NewKeyRing(jwt.Key{ID: "test", Secret: []byte("test-signing-key")}),
+	}
+
+	// Generate the dummy hash outside of the measurements.
+	s.burnPasswordCheck("")
+
+	measure := func(username string) time.Duration {
+		start := time.Now()
+		_, err := s.Login(ctx, username, "wrong-password", 0, "")
+		elapsed := time.Since(start)
+
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+		return elapsed
+	}
+
+	var wrongPassword, unknownUser []time.Duration
+	for range rounds {
+		wrongPassword = append(wrongPassword, measure(existing.Username))
+		unknownUser = append(unknownUser, measure(unknown))
+	}
+
+	wrongMedian, unknownMedian := median(wrongPassword), median(unknownUser)
+	ratio := float64(max(wrongMedian, unknownMedian)) / float64(min(wrongMedian, unknownMedian))
+
+	assert.Lessf(t, ratio, maxRatio,
+		"wrong password median %s, unknown user median %s", wrongMedian, unknownMedian)
+}
+
+func median(durations []time.Duration) time.Duration {
+	sorted := slices.Clone(durations)
+	slices.Sort(sorted)
+
+	return sorted[len(sorted)/2]
+}
@@ -0,0 +1,525 @@
+package auth
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/storage"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+// Purposes of WebAuthn sessions.
+const (
+	purposeWebAuthnRegistration = "webauthn_registration"
+	purposeWebAuthnLogin        = "webauthn_login"
+)
+
+var (
+	ErrWebAuthnUnavailable      = errors.New("webauthn is not available")
+	ErrInvalidWebAuthnSession   = errors.New("invalid webauthn session")
+	ErrInvalidWebAuthnResponse  = errors.New("invalid webauthn response")
+	ErrWebAuthnCredentialExists = errors.New("webauthn credential already registered")
+	ErrWebAuthnCredentialCloned = errors.New("webauthn credential may be cloned")
+)
+
+// WebAuthnStorage keeps the WebAuthn credentials of users and the sessions of ceremonies in progress.
+//
+//go:generate go run github.com/vektra/mockery/v2@latest --name=WebAuthnStorage --with-expecter=true
+type WebAuthnStorage interface {
+	SaveWebAuthnCredential(ctx context.Context,
+		credential models.WebAuthnCredential,
+	) error
+
+	UserWebAuthnCredentials(ctx context.Context,
+		uid int,
+	) ([]models.WebAuthnCredential, error)
+
+	UpdateWebAuthnCredential(ctx context.Context,
+		credentialID []byte,
+		signCount uint32,
+		usedAt time.Time,
+	) error
+
+	SaveWebAuthnSession(ctx context.Context,
+		session models.WebAuthnSession,
+	) error
+
+	TakeWebAuthnSession(ctx context.Context,
+		tokenHash []byte,
+		purpose string,
+	) (models.WebAuthnSession, error)
+}
+
+// BeginWebAuthnRegistration starts registering a new passkey or security key of the user.
+// The returned options are passed to navigator.credentials.create, the result is sent
+// to FinishWebAuthnRegistration with the session token.
+func (a *Auth) BeginWebAuthnRegistration(
+	ctx context.Context,
+	uid int,
+) (models.WebAuthnCeremony, error) {
+	const op = "auth.BeginWebAuthnRegistration"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int("uid", uid),
+	)
+	log.Info("beginning webauthn registration")
+
+	if a.webAuthn == nil {
+		return models.WebAuthnCeremony{}, fmt.Errorf("%s: %w", op, ErrWebAuthnUnavailable)
+	}
+
+	user, err := a.webAuthnUser(ctx, uid)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("user not found")
+			return models.WebAuthnCeremony{}, fmt.Errorf("%s: %w", op, ErrUserNotFound)
+		}
+
+		log.Error("failed to get user", "", err.Error())
+
+		return models.WebAuthnCeremony{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Excluding the registered credentials stops an authenticator from being registered twice.
+	exclusions := make([]protocol.CredentialDescriptor, 0, len(user.credentials))
+	for _, credential := range user.credentials {
+		exclusions = append(exclusions, credential.Descriptor())
+	}
+
+	creation, session, err := a.webAuthn.BeginRegistration(user,
+		webauthn.WithExclusions(exclusions),
+		webauthn.WithAuthenticatorSelection(protocol.AuthenticatorSelection{
+			ResidentKey:      protocol.ResidentKeyRequirementPreferred,
+			UserVerification: protocol.VerificationRequired,
+		}),
+	)
+	if err != nil {
+		log.Error("failed to begin registration", "", err.Error())
+		return models.WebAuthnCeremony{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	ceremony, err := a.saveWebAuthnSession(ctx, uid, purposeWebAuthnRegistration, creation, session)
+	if err != nil {
+		log.Error("failed to save session", "", err.Error())
+		return models.WebAuthnCeremony{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return ceremony, nil
+}
+
+// FinishWebAuthnRegistration verifies the new credential created by the authenticator and stores it.
+// The session must have been begun by the same user.
+func (a *Auth) FinishWebAuthnRegistration(
+	ctx context.Context,
+	uid int,
+	sessionToken string,
+	response []byte,
+) error {
+	const op = "auth.FinishWebAuthnRegistration"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int("uid", uid),
+	)
+	log.Info("finishing webauthn registration")
+
+	if a.webAuthn == nil {
+		return fmt.Errorf("%s: %w", op, ErrWebAuthnUnavailable)
+	}
+
+	session, err := a.takeWebAuthnSession(ctx, log, sessionToken, purposeWebAuthnRegistration)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if session.userID != uid {
+		log.Warn("session of another user", slog.Int("session_uid", session.userID))
+		return fmt.Errorf("%s: %w", op, ErrInvalidWebAuthnSession)
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBody(bytes.NewReader(response))
+	if err != nil {
+		log.Info("malformed registration response", "", err.Error())
+		return fmt.Errorf("%s: %w", op, ErrInvalidWebAuthnResponse)
+	}
+
+	user, err := a.webAuthnUser(ctx, uid)
+	if err != nil {
+		log.Error("failed to get user", "", err.Error())
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	credential, err := a.webAuthn.CreateCredential(user, session.data, parsed)
+	if err != nil {
+		log.Info("invalid registration response", "", err.Error())
+		return fmt.Errorf("%s: %w", op, ErrInvalidWebAuthnResponse)
+	}
+
+	if err := a.webAuthnCredentials.SaveWebAuthnCredential(ctx, credentialToModel(uid, *credential)); err != nil {
+		if errors.Is(err, storage.ErrCredentialExists) {
+			log.Warn("credential already registered")
+			return fmt.Errorf("%s: %w", op, ErrWebAuthnCredentialExists)
+		}
+
+		log.Error("failed to save credential", "", err.Error())
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("webauthn credential registered")
+
+	return nil
+}
+
+// BeginWebAuthnLogin starts a passwordless login of the user with one of the registered credentials.
+// The returned options are passed to navigator.credentials.get, the result is sent
+// to FinishWebAuthnLogin with the session token. Users without credentials fail with ErrInvalidCredentials.
+func (a *Auth) BeginWebAuthnLogin(
+	ctx context.Context,
+	username string,
+) (models.WebAuthnCeremony, error) {
+	const op = "auth.BeginWebAuthnLogin"
+
+	log := a.log.With(
+		slog.String("op", op),
+		slog.String("username", username),
+	)
+	log.Info("beginning webauthn login")
+
+	if a.webAuthn == nil {
+		return models.WebAuthnCeremony{}, fmt.Errorf("%s: %w", op, ErrWebAuthnUnavailable)
+	}
+
+	found, err := a.userByLogin(ctx, username)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("user not found")
+			return models.WebAuthnCeremony{}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		}
+
+		log.Error("failed to get user", "", err.Error())
+
+		return models.WebAuthnCeremony{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := a.webAuthnUser(ctx, found.ID)
+	if err != nil {
+		log.Error("failed to get credentials", "", err.Error())
+		return models.WebAuthnCeremony{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(user.credentials) == 0 {
+		log.Info("user has no webauthn credentials")
+		return models.WebAuthnCeremony{}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+	}
+
+	assertion, session, err := a.webAuthn.BeginLogin(user, webauthn.WithUserVerification(protocol.VerificationRequired))
+	if err != nil {
+		log.Error("failed to begin login", "", err.Error())
+		return models.WebAuthnCeremony{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	ceremony, err := a.saveWebAuthnSession(ctx, found.ID, purposeWebAuthnLogin, assertion, session)
+	if err != nil {
+		log.Error("failed to save session", "", err.Error())
+		return models.WebAuthnCeremony{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return ceremony, nil
+}
+
+// FinishWebAuthnLogin verifies the assertion signed by the authenticator and issues tokens for the app.
+// The authenticator must have verified the user, so the credential is a second factor by itself
+// and no TOTP code is asked for. A signature counter that doesn't grow means the credential
+// may have been cloned, the login then fails with ErrWebAuthnCredentialCloned.
+// Failed assertions count as failed logins like wrong passwords do.
+func (a *Auth) FinishWebAuthnLogin(
+	ctx context.Context,
+	sessionToken string,
+	response []byte,
+	appID int,
+	clientIP string,
+) (models.TokenPair, error) {
+	const op = "auth.FinishWebAuthnLogin"
+
+	log := a.log.With(slog.String("op", op))
+	log.Info("finishing webauthn login")
+
+	if a.webAuthn == nil {
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrWebAuthnUnavailable)
+	}
+
+	params, err := a.tokenParams(ctx, appID)
+	if err != nil {
+		if errors.Is(err, ErrInvalidAppID) {
+			log.Warn("unknown app", slog.Int("app_id", appID))
+			return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidAppID)
+		}
+
+		log.Error("failed to get app", "", err.Error())
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	session, err := a.takeWebAuthnSession(ctx, log, sessionToken, purposeWebAuthnLogin)
+	if err != nil {
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.Int("uid", session.userID))
+
+	user, err := a.webAuthnUser(ctx, session.userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("user not found")
+			return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidWebAuthnSession)
+		}
+
+		log.Error("failed to get user", "", err.Error())
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	reserved, err := a.reserveAttempt(ctx, attemptKeys(user.user.Username, clientIP))
+	if err != nil {
+		var attemptsErr *AttemptsError
+		if !errors.As(err, &attemptsErr) {
+			log.Error("failed to check login attempts", "", err.Error())
+		}
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBody(bytes.NewReader(response))
+	if err != nil {
+		log.Info("malformed assertion", "", err.Error())
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+	}
+
+	credential, err := a.webAuthn.ValidateLogin(user, session.data, parsed)
+	if err != nil {
+		log.Info("invalid assertion", "", err.Error())
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+	}
+
+	if credential.Authenticator.CloneWarning {
+		log.Warn("signature counter went back, credential may be cloned",
+			slog.Uint64("sign_count", uint64(credential.Authenticator.SignCount)),
+			slog.Uint64("reported_sign_count", uint64(parsed.Response.AuthenticatorData.Counter)),
+		)
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrWebAuthnCredentialCloned)
+	}
+
+	err = a.webAuthnCredentials.UpdateWebAuthnCredential(ctx, credential.ID, credential.Authenticator.SignCount, time.Now())
+	if err != nil {
+		// Another login has recorded the same counter since the credential was loaded.
+		if errors.Is(err, storage.ErrSignCountNotIncreased) {
+			log.Warn("signature counter used concurrently, credential may be cloned",
+				slog.Uint64("reported_sign_count", uint64(credential.Authenticator.SignCount)),
+			)
+			return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrWebAuthnCredentialCloned)
+		}
+
+		log.Error("failed to update credential", "", err.Error())
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if a.requireVerifiedEmail && user.user.EmailVerifiedAt.IsZero() {
+		log.Info("email is not verified")
+		a.cancelAttempt(ctx, log, reserved)
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrEmailNotVerified)
+	}
+
+	a.succeedAttempt(ctx, log, reserved)
+
+	tokens, err := a.issueTokens(ctx, user.user, appID, params, "")
+	if err != nil {
+		log.Error("failed to issue tokens", "", err.Error())
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("user logged in with webauthn")
+
+	return tokens, nil
+}
+
+// webAuthnSession is a stored WebAuthn session decoded for the library.
+type webAuthnSession struct {
+	userID int
+	data   webauthn.SessionData
+}
+
+// saveWebAuthnSession stores the session of a ceremony being begun and returns the ceremony for the client.
+func (a *Auth) saveWebAuthnSession(
+	ctx context.Context,
+	uid int,
+	purpose string,
+	options any,
+	session *webauthn.SessionData,
+) (models.WebAuthnCeremony, error) {
+	encodedOptions, err := json.Marshal(options)
+	if err != nil {
+		return models.WebAuthnCeremony{}, err
+	}
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return models.WebAuthnCeremony{}, err
+	}
+
+	token, err := newOpaqueToken()
+	if err != nil {
+		return models.WebAuthnCeremony{}, err
+	}
+
+	err = a.webAuthnCredentials.SaveWebAuthnSession(ctx, models.WebAuthnSession{
+		UserID:    uid,
+		Purpose:   purpose,
+		TokenHash: hashOpaqueToken(token),
+		Data:      data,
+		ExpiresAt: session.Expires,
+	})
+	if err != nil {
+		return models.WebAuthnCeremony{}, err
+	}
+
+	return models.WebAuthnCeremony{
+		SessionToken: token,
+		Options:      encodedOptions,
+	}, nil
+}
+
+// takeWebAuthnSession consumes the session of a ceremony being finished.
+// Unknown and expired sessions fail with ErrInvalidWebAuthnSession.
+func (a *Auth) takeWebAuthnSession(
+	ctx context.Context,
+	log *slog.Logger,
+	sessionToken string,
+	purpose string,
+) (webAuthnSession, error) {
+	stored, err := a.webAuthnCredentials.TakeWebAuthnSession(ctx, hashOpaqueToken(sessionToken), purpose)
+	if err != nil {
+		if errors.Is(err, storage.ErrWebAuthnSessionNotFound) {
+			log.Warn("unknown webauthn session")
+			return webAuthnSession{}, ErrInvalidWebAuthnSession
+		}
+
+		log.Error("failed to get webauthn session", "", err.Error())
+
+		return webAuthnSession{}, err
+	}
+
+	if time.Now().After(stored.ExpiresAt) {
+		log.Info("webauthn session is expired")
+		return webAuthnSession{}, ErrInvalidWebAuthnSession
+	}
+
+	session := webAuthnSession{userID: stored.UserID}
+
+	if err := json.Unmarshal(stored.Data, &session.data); err != nil {
+		log.Error("failed to decode webauthn session", "", err.Error())
+		return webAuthnSession{}, err
+	}
+
+	return session, nil
+}
+
+// webAuthnUser returns the user with the registered credentials.
+func (a *Auth) webAuthnUser(ctx context.Context, uid int) (*webAuthnUser, error) {
+	user, err := a.UserProvider.UserByID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := a.webAuthnCredentials.UserWebAuthnCredentials(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials := make([]webauthn.Credential, 0, len(stored))
+	for _, credential := range stored {
+		credentials = append(credentials, credentialFromModel(credential))
+	}
+
+	return &webAuthnUser{user: user, credentials: credentials}, nil
+}
+
+// webAuthnUser adapts a user to the WebAuthn library.
+type webAuthnUser struct {
+	user        models.User
+	credentials []webauthn.Credential
+}
+
+// WebAuthnID returns the user handle. It must not contain personal data, so it is the bare user ID.
+func (u *webAuthnUser) WebAuthnID() []byte {
+	return webAuthnUserHandle(u.user.ID)
+}
+
+func (u *webAuthnUser) WebAuthnName() string {
+	return u.user.Username
+}
+
+func (u *webAuthnUser) WebAuthnDisplayName() string {
+	if u.user.Name != "" {
+		return u.user.Name
+	}
+
+	return u.user.Username
+}
+
+func (u *webAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	return u.credentials
+}
+
+func (u *webAuthnUser) WebAuthnIcon() string {
+	return ""
+}
+
+func webAuthnUserHandle(uid int) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(uid))
+}
+
+func credentialToModel(uid int, credential webauthn.Credential) models.WebAuthnCredential {
+	transports := make([]string, 0, len(credential.Transport))
+	for _, transport := range credential.Transport {
+		transports = append(transports, string(transport))
+	}
+
+	return models.WebAuthnCredential{
+		UserID:          uid,
+		CredentialID:    credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       credential.Authenticator.SignCount,
+		Transports:      transports,
+	}
+}
+
+func credentialFromModel(credential models.WebAuthnCredential) webauthn.Credential {
+	transports := make([]protocol.AuthenticatorTransport, 0, len(credential.Transports))
+	for _, transport := range credential.Transports {
+		transports = append(transports, protocol.AuthenticatorTransport(transport))
+	}
+
+	return webauthn.Credential{
+		ID:              credential.CredentialID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Transport:       transports,
+		Authenticator: webauthn.Authenticator{
+			AAGUID:    credential.AAGUID,
+			SignCount: credential.SignCount,
+		},
+	}
+}
@@ -0,0 +1,420 @@
+package auth
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/jwt"
+	"auth/internal/services/auth/mocks"
+	"auth/internal/storage"
+	"auth/internal/storage/memory"
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testRPID   = "localhost"
+	testOrigin = "https://localhost"
+)
+
+// newWebAuthnStorage returns a mock WebAuthnStorage keeping what is saved,
+// so a ceremony begun by one call can be finished by another.
+func newWebAuthnStorage(t *testing.T) (*mocks.WebAuthnStorage, *[]models.WebAuthnCredential) {
+	s := mocks.NewWebAuthnStorage(t)
+
+	credentials := &[]models.WebAuthnCredential{}
+	sessions := map[string]models.WebAuthnSession{}
+
+	s.EXPECT().
+		SaveWebAuthnSession(mock.Anything, mock.Anything).
+		RunAndReturn(func(_ context.Context, session models.WebAuthnSession) error {
+			sessions[session.Purpose+string(session.TokenHash)] = session
+			return nil
+		}).Maybe()
+	s.EXPECT().
+		TakeWebAuthnSession(mock.Anything, mock.Anything, mock.Anything).
+		RunAndReturn(func(_ context.Context, tokenHash []byte, purpose string) (models.WebAuthnSession, error) {
+			session, ok := sessions[purpose+string(tokenHash)]
+			if !ok {
+				return models.WebAuthnSession{}, storage.ErrWebAuthnSessionNotFound
+			}
+			delete(sessions, purpose+string(tokenHash))
+			return session, nil
+		}).Maybe()
+	s.EXPECT().
+		UserWebAuthnCredentials(mock.Anything, mock.Anything).
+		RunAndReturn(func(_ context.Context, uid int) ([]models.WebAuthnCredential, error) {
+			var owned []models.WebAuthnCredential
+			for _, credential := range *credentials {
+				if credential.UserID == uid {
+					owned = append(owned, credential)
+				}
+			}
+			return owned, nil
+		}).Maybe()
+	s.EXPECT().
+		SaveWebAuthnCredential(mock.Anything, mock.Anything).
+		RunAndReturn(func(_ context.Context, credential models.WebAuthnCredential) error {
+			for _, existing := range *credentials {
+				if bytes.Equal(existing.CredentialID, credential.CredentialID) {
+					return storage.ErrCredentialExists
+				}
+			}
+			*credentials = append(*credentials, credential)
+			return nil
+		}).Maybe()
+	s.EXPECT().
+		UpdateWebAuthnCredential(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		RunAndReturn(func(_ context.Context, credentialID []byte, signCount uint32, usedAt time.Time) error {
+			for i := range *credentials {
+				if bytes.Equal((*credentials)[i].CredentialID, credentialID) {
+					if stored := (*credentials)[i].SignCount; stored >= signCount && (stored != 0 || signCount != 0) {
+						return storage.ErrSignCountNotIncreased
+					}
+					(*credentials)[i].SignCount = signCount
+					(*credentials)[i].LastUsedAt = usedAt
+					return nil
+				}
+			}
+			return storage.ErrCredentialNotFound
+		}).Maybe()
+
+	return s, credentials
+}
+
+func newWebAuthnTestAuth(t *testing.T, users ...models.User) (*Auth, *[]models.WebAuthnCredential) {
+	log := slog.New(
+		slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+	)
+
+	userProvider := mocks.NewUserProvider(t)
+	for _, user := range users {
+		userProvider.EXPECT().UserByID(mock.Anything, user.ID).Return(user, nil).Maybe()
+		userProvider.EXPECT().User(mock.Anything, user.Username).Return(user, nil).Maybe()
+	}
+
+	refreshTokens := mocks.NewRefreshTokenStorage(t)
+	refreshTokens.EXPECT().SaveRefreshToken(mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	roleProvider := mocks.NewRoleProvider(t)
+	roleProvider.EXPECT().UserRoles(mock.Anything, mock.Anything).Return(nil, nil).Maybe()
+	roleProvider.EXPECT().UserPermissions(mock.Anything, mock.Anything).Return(nil, nil).Maybe()
+
+	webAuthn, err := webauthn.New(&webauthn.Config{
+		RPID:          testRPID,
+		RPDisplayName: "Auth",
+		RPOrigins:     []string{testOrigin},
+		Timeouts: webauthn.TimeoutsConfig{
+			Login:        webauthn.TimeoutConfig{Enforce: true, Timeout: time.Minute, TimeoutUVD: time.Minute},
+			Registration: webauthn.TimeoutConfig{Enforce: true, Timeout: time.Minute, TimeoutUVD: time.Minute},
+		},
+	})
+	require.NoError(t, err)
+
+	webAuthnStorage, credentials := newWebAuthnStorage(t)
+
+	return &Auth{
+		UserProvider:        userProvider,
+		refreshTokens:       refreshTokens,
+		roleProvider:        roleProvider,
+		webAuthnCredentials: webAuthnStorage,
+		webAuthn:            webAuthn,
+		log:                 log,
+		keys:                jwt.NewKeyRing(jwt.Key{ID: "test", Secret: []byte("test-signing-key")}),
+	}, credentials
+}
+
+// registerAuthenticator runs a whole registration ceremony of the authenticator for the user.
+func registerAuthenticator(ctx context.Context, s *Auth, uid int, authenticator *fakeAuthenticator) error {
+	ceremony, err := s.BeginWebAuthnRegistration(ctx, uid)
+	if err != nil {
+		return err
+	}
+
+	return s.FinishWebAuthnRegistration(ctx, uid, ceremony.SessionToken, authenticator.create(ceremony.Options))
+}
+
+func Test_Auth_WebAuthnRegistration(t *testing.T) {
+	ctx := context.Background()
+
+	user := models.User{ID: 1, Name: "Matvey", Username: "MatveyTabby"}
+	other := models.User{ID: 2, Name: "John", Username: "JohnTravolta"}
+
+	t.Run("Success", func(t *testing.T) {
+		s, credentials := newWebAuthnTestAuth(t, user)
+		authenticator := newFakeAuthenticator(t, testOrigin)
+
+		err := registerAuthenticator(ctx, s, user.ID, authenticator)
+
+		require.NoError(t, err)
+		require.Len(t, *credentials, 1)
+		assert.Equal(t, user.ID, (*credentials)[0].UserID)
+		assert.Equal(t, authenticator.credentialID, (*credentials)[0].CredentialID)
+		assert.Equal(t, uint32(1), (*credentials)[0].SignCount)
+		assert.Equal(t, []string{"internal"}, (*credentials)[0].Transports)
+	})
+
+	t.Run("Wrong origin", func(t *testing.T) {
+		s, credentials := newWebAuthnTestAuth(t, user)
+
+		err := registerAuthenticator(ctx, s, user.ID, newFakeAuthenticator(t, "https://evil.example"))
+
+		assert.ErrorIs(t, err, ErrInvalidWebAuthnResponse)
+		assert.Empty(t, *credentials)
+	})
+
+	t.Run("Malformed response", func(t *testing.T) {
+		s, _ := newWebAuthnTestAuth(t, user)
+
+		ceremony, err := s.BeginWebAuthnRegistration(ctx, user.ID)
+		require.NoError(t, err)
+
+		err = s.FinishWebAuthnRegistration(ctx, user.ID, ceremony.SessionToken, []byte("{}"))
+
+		assert.ErrorIs(t, err, ErrInvalidWebAuthnResponse)
+	})
+
+	t.Run("Session of another user", func(t *testing.T) {
+		s, credentials := newWebAuthnTestAuth(t, user, other)
+
+		ceremony, err := s.BeginWebAuthnRegistration(ctx, user.ID)
+		require.NoError(t, err)
+
+		response := newFakeAuthenticator(t, testOrigin).create(ceremony.Options)
+		err = s.FinishWebAuthnRegistration(ctx, other.ID, ceremony.SessionToken, response)
+
+		assert.ErrorIs(t, err, ErrInvalidWebAuthnSession)
+		assert.Empty(t, *credentials)
+	})
+
+	t.Run("Session used twice", func(t *testing.T) {
+		s, _ := newWebAuthnTestAuth(t, user)
+		authenticator := newFakeAuthenticator(t, testOrigin)
+
+		ceremony, err := s.BeginWebAuthnRegistration(ctx, user.ID)
+		require.NoError(t, err)
+
+		response := authenticator.create(ceremony.Options)
+		require.NoError(t, s.FinishWebAuthnRegistration(ctx, user.ID, ceremony.SessionToken, response))
+
+		err = s.FinishWebAuthnRegistration(ctx, user.ID, ceremony.SessionToken, response)
+
+		assert.ErrorIs(t, err, ErrInvalidWebAuthnSession)
+	})
+
+	t.Run("Already registered", func(t *testing.T) {
+		s, credentials := newWebAuthnTestAuth(t, user)
+		authenticator := newFakeAuthenticator(t, testOrigin)
+
+		require.NoError(t, registerAuthenticator(ctx, s, user.ID, authenticator))
+
+		err := registerAuthenticator(ctx, s, user.ID, authenticator)
+
+		assert.ErrorIs(t, err, ErrWebAuthnCredentialExists)
+		assert.Len(t, *credentials, 1)
+	})
+
+	t.Run("Unavailable", func(t *testing.T) {
+		s := Auth{log: slog.New(slog.NewTextHandler(os.Stdout, nil))}
+
+		_, err := s.BeginWebAuthnRegistration(ctx, user.ID)
+
+		assert.ErrorIs(t, err, ErrWebAuthnUnavailable)
+	})
+}
+
+func Test_Auth_WebAuthnLogin(t *testing.T) {
+	ctx := context.Background()
+
+	user := models.User{ID: 1, Name: "Matvey", Username: "MatveyTabby"}
+
+	tests := []struct {
+		nameTest string
+		// login runs the login ceremony with the registered authenticator.
+		login       func(t *testing.T, s *Auth, authenticator *fakeAuthenticator) (models.TokenPair, error)
+		expectedErr error
+	}{
+		{
+			nameTest: "Success",
+			login: func(t *testing.T, s *Auth, authenticator *fakeAuthenticator) (models.TokenPair, error) {
+				ceremony, err := s.BeginWebAuthnLogin(ctx, user.Username)
+				require.NoError(t, err)
+
+				return s.FinishWebAuthnLogin(ctx, ceremony.SessionToken, authenticator.get(ceremony.Options, testRPID), 0, "")
+			},
+		},
+		{
+			nameTest: "User not verified",
+			login: func(t *testing.T, s *Auth, authenticator *fakeAuthenticator) (models.TokenPair, error) {
+				ceremony, err := s.BeginWebAuthnLogin(ctx, user.Username)
+				require.NoError(t, err)
+
+				authenticator.userVerified = false
+
+				return s.FinishWebAuthnLogin(ctx, ceremony.SessionToken, authenticator.get(ceremony.Options, testRPID), 0, "")
+			},
+			expectedErr: ErrInvalidCredentials,
+		},
+		{
+			nameTest: "Wrong relying party",
+			login: func(t *testing.T, s *Auth, authenticator *fakeAuthenticator) (models.TokenPair, error) {
+				ceremony, err := s.BeginWebAuthnLogin(ctx, user.Username)
+				require.NoError(t, err)
+
+				return s.FinishWebAuthnLogin(ctx, ceremony.SessionToken, authenticator.get(ceremony.Options, "evil.example"), 0, "")
+			},
+			expectedErr: ErrInvalidCredentials,
+		},
+		{
+			nameTest: "Other authenticator",
+			login: func(t *testing.T, s *Auth, _ *fakeAuthenticator) (models.TokenPair, error) {
+				ceremony, err := s.BeginWebAuthnLogin(ctx, user.Username)
+				require.NoError(t, err)
+
+				other := newFakeAuthenticator(t, testOrigin)
+
+				return s.FinishWebAuthnLogin(ctx, ceremony.SessionToken, other.get(ceremony.Options, testRPID), 0, "")
+			},
+			expectedErr: ErrInvalidCredentials,
+		},
+		{
+			nameTest: "Replayed assertion",
+			login: func(t *testing.T, s *Auth, authenticator *fakeAuthenticator) (models.TokenPair, error) {
+				ceremony, err := s.BeginWebAuthnLogin(ctx, user.Username)
+				require.NoError(t, err)
+
+				response := authenticator.get(ceremony.Options, testRPID)
+				_, err = s.FinishWebAuthnLogin(ctx, ceremony.SessionToken, response, 0, "")
+				require.NoError(t, err)
+
+				return s.FinishWebAuthnLogin(ctx, ceremony.SessionToken, response, 0, "")
+			},
+			expectedErr: ErrInvalidWebAuthnSession,
+		},
+		{
+			nameTest: "Sign count regression",
+			login: func(t *testing.T, s *Auth, authenticator *fakeAuthenticator) (models.TokenPair, error) {
+				clone := *authenticator
+
+				ceremony, err := s.BeginWebAuthnLogin(ctx, user.Username)
+				require.NoError(t, err)
+
+				_, err = s.FinishWebAuthnLogin(ctx, ceremony.SessionToken, authenticator.get(ceremony.Options, testRPID), 0, "")
+				require.NoError(t, err)
+
+				// The clone counts from the state at the time it was copied, behind the original.
+				ceremony, err = s.BeginWebAuthnLogin(ctx, user.Username)
+				require.NoError(t, err)
+
+				return s.FinishWebAuthnLogin(ctx, ceremony.SessionToken, clone.get(ceremony.Options, testRPID), 0, "")
+			},
+			expectedErr: ErrWebAuthnCredentialCloned,
+		},
+		{
+			nameTest: "Unknown session",
+			login: func(t *testing.T, s *Auth, authenticator *fakeAuthenticator) (models.TokenPair, error) {
+				ceremony, err := s.BeginWebAuthnLogin(ctx, user.Username)
+				require.NoError(t, err)
+
+				return s.FinishWebAuthnLogin(ctx, "unknown", authenticator.get(ceremony.Options, testRPID), 0, "")
+			},
+			expectedErr: ErrInvalidWebAuthnSession,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nameTest, func(t *testing.T) {
+			s, credentials := newWebAuthnTestAuth(t, user)
+			authenticator := newFakeAuthenticator(t, testOrigin)
+
+			require.NoError(t, registerAuthenticator(ctx, s, user.ID, authenticator))
+
+			tokens, err := tc.login(t, s, authenticator)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Equal(t, models.TokenPair{}, tokens)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.NotEmpty(t, tokens.AccessToken)
+			assert.Equal(t, authenticator.signCount, (*credentials)[0].SignCount)
+			assert.False(t, (*credentials)[0].LastUsedAt.IsZero())
+		})
+	}
+
+	t.Run("Clone racing the original", func(t *testing.T) {
+		s, credentials := newWebAuthnTestAuth(t, user)
+		authenticator := newFakeAuthenticator(t, testOrigin)
+
+		require.NoError(t, registerAuthenticator(ctx, s, user.ID, authenticator))
+
+		clone := *authenticator
+		stale := append([]models.WebAuthnCredential(nil), *credentials...)
+
+		original, err := s.BeginWebAuthnLogin(ctx, user.Username)
+		require.NoError(t, err)
+		cloned, err := s.BeginWebAuthnLogin(ctx, user.Username)
+		require.NoError(t, err)
+
+		_, err = s.FinishWebAuthnLogin(ctx, original.SessionToken, authenticator.get(original.Options, testRPID), 0, "")
+		require.NoError(t, err)
+
+		// The login of the clone loaded the credential before the original recorded its counter.
+		store := s.webAuthnCredentials
+		racing := mocks.NewWebAuthnStorage(t)
+		racing.EXPECT().UserWebAuthnCredentials(mock.Anything, user.ID).Return(stale, nil).Maybe()
+		racing.EXPECT().TakeWebAuthnSession(mock.Anything, mock.Anything, mock.Anything).RunAndReturn(store.TakeWebAuthnSession).Maybe()
+		racing.EXPECT().UpdateWebAuthnCredential(mock.Anything, mock.Anything, mock.Anything, mock.Anything).RunAndReturn(store.UpdateWebAuthnCredential).Maybe()
+		s.webAuthnCredentials = racing
+
+		tokens, err := s.FinishWebAuthnLogin(ctx, cloned.SessionToken, clone.get(cloned.Options, testRPID), 0, "")
+
+		assert.ErrorIs(t, err, ErrWebAuthnCredentialCloned)
+		assert.Equal(t, models.TokenPair{}, tokens)
+	})
+
+	t.Run("Unverified email keeps the failures of the user", func(t *testing.T) {
+		s, _ := newWebAuthnTestAuth(t, user)
+		authenticator := newFakeAuthenticator(t, testOrigin)
+
+		require.NoError(t, registerAuthenticator(ctx, s, user.ID, authenticator))
+
+		window := 15 * time.Minute
+		attempts := memory.NewAttempts()
+		for i := 0; i < 2; i++ {
+			_, err := attempts.RecordLoginFailure(ctx, userAttemptKey(user.Username), time.Now().Add(-time.Minute), window)
+			require.NoError(t, err)
+		}
+
+		s.attempts = attempts
+		s.throttle = Throttle{Window: window, FreeAttempts: 5}
+		s.requireVerifiedEmail = true
+
+		ceremony, err := s.BeginWebAuthnLogin(ctx, user.Username)
+		require.NoError(t, err)
+
+		_, err = s.FinishWebAuthnLogin(ctx, ceremony.SessionToken, authenticator.get(ceremony.Options, testRPID), 0, "")
+		assert.ErrorIs(t, err, ErrEmailNotVerified)
+
+		userAttempts, err := attempts.LoginAttempts(ctx, userAttemptKey(user.Username))
+		require.NoError(t, err)
+		assert.Equal(t, 2, userAttempts.Failures)
+	})
+
+	t.Run("No credentials", func(t *testing.T) {
+		s, _ := newWebAuthnTestAuth(t, user)
+
+		_, err := s.BeginWebAuthnLogin(ctx, user.Username)
+
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	})
+}
@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"auth/internal/domain/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func (s *Storage) App(ctx context.Context, appID int) (models.App, error) {
+	const op = "storage.postgres.App"
+
+	query := `SELECT id, name, secret, audience, token_ttl_seconds FROM apps WHERE id=$1`
+
+	var (
+		app        models.App
+		ttlSeconds int64
+	)
+
+	err := s.db.QueryRowContext(ctx, query, appID).Scan(
+		&app.ID, &app.Name, &app.Secret, pq.Array(&app.Audience), &ttlSeconds,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.App{}, fmt.Errorf("%s: %w", op, ErrAppNotFound)
+		}
+
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	app.TokenTTL = time.Duration(ttlSeconds) * time.Second
+
+	return app, nil
+}
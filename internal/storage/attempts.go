@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"auth/internal/domain/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+func (s *Storage) LoginAttempts(ctx context.Context, key string) (models.LoginAttempts, error) {
+	const op = "storage.postgres.LoginAttempts"
+
+	query := `SELECT failures, last_failure_at FROM login_attempts WHERE key=$1`
+
+	var attempts models.LoginAttempts
+
+	err := s.db.QueryRowContext(ctx, query, key).Scan(&attempts.Failures, &attempts.LastFailure)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.LoginAttempts{}, nil
+		}
+
+		return models.LoginAttempts{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return attempts, nil
+}
+
+// RecordLoginFailure counts a failed login in one statement, so concurrent failures
+// on different instances are all counted. The count restarts when the last failure is older than window.
+func (s *Storage) RecordLoginFailure(ctx context.Context,
+	key string,
+	now time.Time,
+	window time.Duration,
+) (models.LoginAttempts, error) {
+	const op = "storage.postgres.RecordLoginFailure"
+
+	query := `INSERT INTO login_attempts (key, failures, last_failure_at) VALUES ($1, 1, $2)
+		ON CONFLICT (key) DO UPDATE
+		SET failures = CASE WHEN login_attempts.last_failure_at < $3 THEN 1 ELSE login_attempts.failures + 1 END,
+			last_failure_at = EXCLUDED.last_failure_at
+		RETURNING failures, last_failure_at`
+
+	var attempts models.LoginAttempts
+
+	err := s.db.QueryRowContext(ctx, query, key, now, now.Add(-window)).Scan(&attempts.Failures, &attempts.LastFailure)
+	if err != nil {
+		return models.LoginAttempts{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return attempts, nil
+}
+
+func (s *Storage) ResetLoginAttempts(ctx context.Context, key string) error {
+	const op = "storage.postgres.ResetLoginAttempts"
+
+	query := `DELETE FROM login_attempts WHERE key=$1`
+
+	if _, err := s.db.ExecContext(ctx, query, key); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// CancelLoginFailure takes back the failure counted at failedAt for an attempt that hasn't failed.
+// The last failure goes back to lastFailure unless a later failure has been counted since,
+// so the key ages out as if the attempt had never been counted.
+func (s *Storage) CancelLoginFailure(ctx context.Context, key string, failedAt time.Time, lastFailure time.Time) error {
+	const op = "storage.postgres.CancelLoginFailure"
+
+	query := `UPDATE login_attempts
+		SET failures = failures - 1,
+			last_failure_at = CASE WHEN last_failure_at = $2 THEN $3 ELSE last_failure_at END
+		WHERE key=$1 AND failures > 0`
+
+	if _, err := s.db.ExecContext(ctx, query, key, failedAt, lastFailure); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteStaleLoginAttempts removes the counters with no failures since before.
+func (s *Storage) DeleteStaleLoginAttempts(ctx context.Context, before time.Time) (int64, error) {
+	const op = "storage.postgres.DeleteStaleLoginAttempts"
+
+	query := `DELETE FROM login_attempts WHERE last_failure_at < $1`
+
+	res, err := s.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,152 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RevocationStore is the persistent store of token revocations shared by all instances.
+type RevocationStore interface {
+	RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+	RevokeUserTokens(ctx context.Context, uid int) error
+	UserTokenVersion(ctx context.Context, uid int) (int, error)
+}
+
+// Revocations is an in-memory cache in front of a RevocationStore.
+//
+// A revoked token stays revoked until it expires, so positive answers are kept until then.
+// Negative answers and token versions of users are kept for ttl only,
+// which bounds the delay before a revocation made by another instance is noticed.
+type Revocations struct {
+	store RevocationStore
+	ttl   time.Duration
+
+	mu      sync.Mutex
+	revoked map[string]time.Time // jti -> token expiry
+	checked map[string]time.Time // jti -> end of trust in the negative answer
+	users   map[int]userEntry
+}
+
+type userEntry struct {
+	version int
+	until   time.Time
+}
+
+func NewRevocations(store RevocationStore, ttl time.Duration) *Revocations {
+	return &Revocations{
+		store:   store,
+		ttl:     ttl,
+		revoked: make(map[string]time.Time),
+		checked: make(map[string]time.Time),
+		users:   make(map[int]userEntry),
+	}
+}
+
+func (c *Revocations) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	if err := c.store.RevokeToken(ctx, jti, expiresAt); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.revoked[jti] = expiresAt
+	delete(c.checked, jti)
+
+	return nil
+}
+
+func (c *Revocations) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	if _, ok := c.revoked[jti]; ok {
+		c.mu.Unlock()
+		return true, nil
+	}
+	if until, ok := c.checked[jti]; ok && now.Before(until) {
+		c.mu.Unlock()
+		return false, nil
+	}
+	c.mu.Unlock()
+
+	revoked, err := c.store.IsTokenRevoked(ctx, jti)
+	if err != nil {
+		return false, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if revoked {
+		// The expiry is unknown here, keep the answer for ttl like a negative one.
+		c.revoked[jti] = now.Add(c.ttl)
+	} else {
+		c.checked[jti] = now.Add(c.ttl)
+	}
+
+	return revoked, nil
+}
+
+func (c *Revocations) RevokeUserTokens(ctx context.Context, uid int) error {
+	if err := c.store.RevokeUserTokens(ctx, uid); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Drop the entry instead of bumping it: other instances may have bumped the version too.
+	delete(c.users, uid)
+
+	return nil
+}
+
+func (c *Revocations) UserTokenVersion(ctx context.Context, uid int) (int, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	if entry, ok := c.users[uid]; ok && now.Before(entry.until) {
+		c.mu.Unlock()
+		return entry.version, nil
+	}
+	c.mu.Unlock()
+
+	version, err := c.store.UserTokenVersion(ctx, uid)
+	if err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.users[uid] = userEntry{version: version, until: now.Add(c.ttl)}
+
+	return version, nil
+}
+
+// Prune drops the entries that are no longer needed.
+func (c *Revocations) Prune(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for jti, expiresAt := range c.revoked {
+		if now.After(expiresAt) {
+			delete(c.revoked, jti)
+		}
+	}
+
+	for jti, until := range c.checked {
+		if now.After(until) {
+			delete(c.checked, jti)
+		}
+	}
+
+	for uid, entry := range c.users {
+		if now.After(entry.until) {
+			delete(c.users, uid)
+		}
+	}
+}
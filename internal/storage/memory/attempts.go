@@ -0,0 +1,85 @@
+// Package memory keeps state that doesn't have to be shared between instances of the service.
+package memory
+
+import (
+	"auth/internal/domain/models"
+	"context"
+	"sync"
+	"time"
+)
+
+// Attempts counts failed logins in memory.
+// Each instance of the service counts on its own, use the Postgres storage to share the counters.
+type Attempts struct {
+	mu       sync.Mutex
+	attempts map[string]models.LoginAttempts
+}
+
+func NewAttempts() *Attempts {
+	return &Attempts{
+		attempts: make(map[string]models.LoginAttempts),
+	}
+}
+
+func (a *Attempts) LoginAttempts(_ context.Context, key string) (models.LoginAttempts, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.attempts[key], nil
+}
+
+func (a *Attempts) RecordLoginFailure(_ context.Context,
+	key string,
+	now time.Time,
+	window time.Duration,
+) (models.LoginAttempts, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	attempts := a.attempts[key]
+	if now.Sub(attempts.LastFailure) > window {
+		attempts.Failures = 0
+	}
+
+	attempts.Failures++
+	attempts.LastFailure = now
+	a.attempts[key] = attempts
+
+	return attempts, nil
+}
+
+func (a *Attempts) ResetLoginAttempts(_ context.Context, key string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	delete(a.attempts, key)
+
+	return nil
+}
+
+func (a *Attempts) CancelLoginFailure(_ context.Context, key string, failedAt time.Time, lastFailure time.Time) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if attempts, ok := a.attempts[key]; ok && attempts.Failures > 0 {
+		attempts.Failures--
+		if attempts.LastFailure.Equal(failedAt) {
+			attempts.LastFailure = lastFailure
+		}
+		a.attempts[key] = attempts
+	}
+
+	return nil
+}
+
+// Prune drops the counters with no failures since before.
+func (a *Attempts) Prune(before time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for key, attempts := range a.attempts {
+		if attempts.LastFailure.Before(before) {
+			delete(a.attempts, key)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"auth/internal/domain/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SaveTOTP stores a new, not yet enabled secret of the user, replacing an unconfirmed one.
+// It fails with ErrTOTPEnabled if the user has enabled two-factor authentication already.
+func (s *Storage) SaveTOTP(ctx context.Context, uid int, secret []byte) error {
+	const op = "storage.postgres.SaveTOTP"
+
+	query := `INSERT INTO user_totp (user_id, secret) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET secret=EXCLUDED.secret, last_used_step=0
+		WHERE user_totp.enabled_at IS NULL`
+
+	res, err := s.db.ExecContext(ctx, query, uid, secret)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, ErrTOTPEnabled)
+	}
+
+	return nil
+}
+
+func (s *Storage) TOTP(ctx context.Context, uid int) (models.TOTP, error) {
+	const op = "storage.postgres.TOTP"
+
+	query := `SELECT user_id, secret, enabled_at, last_used_step FROM user_totp WHERE user_id=$1`
+
+	var (
+		totp      models.TOTP
+		enabledAt sql.NullTime
+	)
+
+	err := s.db.QueryRowContext(ctx, query, uid).Scan(&totp.UserID, &totp.Secret, &enabledAt, &totp.LastUsedStep)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.TOTP{}, fmt.Errorf("%s: %w", op, ErrTOTPNotFound)
+		}
+
+		return models.TOTP{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	totp.EnabledAt = enabledAt.Time
+
+	return totp, nil
+}
+
+// EnableTOTP enables the saved secret of the user, marking step as used,
+// and replaces the recovery codes of the user with the given hashes.
+// It fails with ErrTOTPNotFound if there is no secret waiting for confirmation.
+func (s *Storage) EnableTOTP(ctx context.Context, uid int, enabledAt time.Time, step int64, recoveryCodes [][]byte) error {
+	const op = "storage.postgres.EnableTOTP"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE user_totp SET enabled_at=$2, last_used_step=$3 WHERE user_id=$1 AND enabled_at IS NULL`,
+		uid, enabledAt, step,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, ErrTOTPNotFound)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id=$1`, uid); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, codeHash := range recoveryCodes {
+		_, err := tx.ExecContext(ctx, `INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1, $2)`, uid, codeHash)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// UseTOTPStep marks the codes of the step and earlier ones as used.
+// It fails with ErrTOTPCodeUsed if a code of the step or a later one has been accepted already.
+func (s *Storage) UseTOTPStep(ctx context.Context, uid int, step int64) error {
+	const op = "storage.postgres.UseTOTPStep"
+
+	query := `UPDATE user_totp SET last_used_step=$2 WHERE user_id=$1 AND last_used_step < $2`
+
+	res, err := s.db.ExecContext(ctx, query, uid, step)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, ErrTOTPCodeUsed)
+	}
+
+	return nil
+}
+
+// UseRecoveryCode marks the recovery code of the user as used.
+// It fails with ErrRecoveryCodeNotFound if the user has no such unused code.
+func (s *Storage) UseRecoveryCode(ctx context.Context, uid int, codeHash []byte) error {
+	const op = "storage.postgres.UseRecoveryCode"
+
+	query := `UPDATE recovery_codes SET used_at=now() WHERE user_id=$1 AND code_hash=$2 AND used_at IS NULL`
+
+	res, err := s.db.ExecContext(ctx, query, uid, codeHash)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, ErrRecoveryCodeNotFound)
+	}
+
+	return nil
+}
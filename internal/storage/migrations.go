@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"auth/internal/canonical"
+	"auth/internal/migrate"
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"strconv"
+)
+
+// canonicalUsernamesVersion is the migration adding username_canonical.
+// The canonical forms are computed in Go, so the column is filled in by fillCanonicalUsernames.
+const canonicalUsernamesVersion = 12
+
+// duplicateCanonicalPrefix starts the placeholders fillCanonicalUsernames stores for usernames
+// that turn out to be the same account as an older one. Canonical forms are case folded,
+// so no username canonicalizes to a placeholder.
+const duplicateCanonicalPrefix = "DUPLICATE "
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migrations returns the schema migrations of the storage.
+func Migrations() fs.FS {
+	migrations, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		panic(err)
+	}
+
+	return migrations
+}
+
+// Migrator returns the migrator bringing the database of the storage to the schema it expects.
+func (s *Storage) Migrator() (*migrate.Migrator, error) {
+	return migrate.New(s.db, Migrations(), map[int64]migrate.Step{
+		canonicalUsernamesVersion: fillCanonicalUsernames,
+	})
+}
+
+// fillCanonicalUsernames stores the canonical form of every username, then makes the column NOT NULL.
+// A username that is the same account as the one of an older user gets a placeholder instead,
+// so the migration goes through: the user is reported and can't log in by the username until renamed.
+func fillCanonicalUsernames(ctx context.Context, tx *sql.Tx) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT id, username FROM users WHERE username_canonical IS NULL ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+
+	type user struct {
+		id       int
+		username string
+		form     string
+	}
+
+	var users []user
+
+	for rows.Next() {
+		var u user
+		if err := rows.Scan(&u.id, &u.username); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		u.form = canonical.Username(u.username)
+		users = append(users, u)
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var notes []string
+
+	owners := make(map[string]user, len(users))
+	for _, u := range users {
+		if owner, ok := owners[u.form]; ok {
+			notes = append(notes, fmt.Sprintf("user %d %q is the same account as user %d %q, it can't log in by username until renamed",
+				u.id, u.username, owner.id, owner.username))
+			u.form = duplicateCanonicalPrefix + strconv.Itoa(u.id)
+		} else {
+			owners[u.form] = u
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE users SET username_canonical=$1 WHERE id=$2`, u.form, u.id); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `ALTER TABLE users ALTER COLUMN username_canonical SET NOT NULL`); err != nil {
+		return nil, err
+	}
+
+	return notes, nil
+}
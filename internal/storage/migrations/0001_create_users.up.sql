@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS users (
+    id            SERIAL PRIMARY KEY,
+    name          TEXT  NOT NULL,
+    username      TEXT  NOT NULL UNIQUE,
+    password_hash BYTEA NOT NULL
+);
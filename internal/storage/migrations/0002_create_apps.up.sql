@@ -0,0 +1,9 @@
+-- A zero token_ttl_seconds means the default lifetime of access tokens,
+-- an empty secret means the tokens of the app are signed with the service keys.
+CREATE TABLE IF NOT EXISTS apps (
+    id                SERIAL PRIMARY KEY,
+    name              TEXT   NOT NULL UNIQUE,
+    secret            BYTEA  NOT NULL DEFAULT '',
+    audience          TEXT[] NOT NULL DEFAULT '{}',
+    token_ttl_seconds BIGINT NOT NULL DEFAULT 0
+);
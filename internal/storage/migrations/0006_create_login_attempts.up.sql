@@ -0,0 +1,8 @@
+-- key is "user:<username>" or "ip:<address>".
+CREATE TABLE IF NOT EXISTS login_attempts (
+    key             TEXT PRIMARY KEY,
+    failures        INTEGER     NOT NULL,
+    last_failure_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS login_attempts_last_failure_at_idx ON login_attempts (last_failure_at);
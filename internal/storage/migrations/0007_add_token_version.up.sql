@@ -0,0 +1,3 @@
+-- Access tokens carry the version of the user they were issued at,
+-- bumping it revokes every token issued before, on all devices.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS token_version INTEGER NOT NULL DEFAULT 0;
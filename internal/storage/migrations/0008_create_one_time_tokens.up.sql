@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS one_time_tokens (
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    INTEGER     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    app_id     INTEGER     NOT NULL DEFAULT 0,
+    purpose    TEXT        NOT NULL,
+    token_hash BYTEA       NOT NULL UNIQUE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS one_time_tokens_user_id_purpose_idx ON one_time_tokens (user_id, purpose);
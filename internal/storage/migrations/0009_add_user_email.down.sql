@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS users_email_key;
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified_at;
+ALTER TABLE users DROP COLUMN IF EXISTS email;
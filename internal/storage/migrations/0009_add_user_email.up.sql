@@ -0,0 +1,6 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMPTZ;
+
+-- Only verified emails are unique, regardless of case. An unverified email is just a claim,
+-- it must not keep the owner of the address from verifying it on their own account.
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_key ON users (lower(email)) WHERE email_verified_at IS NOT NULL;
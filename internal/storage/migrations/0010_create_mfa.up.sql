@@ -0,0 +1,15 @@
+-- secret is encrypted with the MFA encryption key.
+CREATE TABLE IF NOT EXISTS user_totp (
+    user_id        INTEGER PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    secret         BYTEA  NOT NULL,
+    enabled_at     TIMESTAMPTZ,
+    last_used_step BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id        BIGSERIAL PRIMARY KEY,
+    user_id   INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    code_hash BYTEA   NOT NULL,
+    used_at   TIMESTAMPTZ,
+    UNIQUE (user_id, code_hash)
+);
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS webauthn_credentials (
+    id               BIGSERIAL PRIMARY KEY,
+    user_id          INTEGER     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    credential_id    BYTEA       NOT NULL UNIQUE,
+    public_key       BYTEA       NOT NULL,
+    attestation_type TEXT        NOT NULL DEFAULT '',
+    aaguid           BYTEA,
+    sign_count       BIGINT      NOT NULL DEFAULT 0,
+    transports       TEXT[]      NOT NULL DEFAULT '{}',
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_used_at     TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS webauthn_credentials_user_id_idx ON webauthn_credentials (user_id);
+
+CREATE TABLE IF NOT EXISTS webauthn_sessions (
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    INTEGER     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    purpose    TEXT        NOT NULL,
+    token_hash BYTEA       NOT NULL UNIQUE,
+    data       BYTEA       NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
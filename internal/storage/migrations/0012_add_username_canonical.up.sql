@@ -0,0 +1,5 @@
+-- The canonical form of the username, see canonical.Username. It is computed in Go:
+-- the step of this migration fills the column in right after these statements and makes it NOT NULL.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS username_canonical TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_username_canonical_key ON users (username_canonical);
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"auth/internal/migrate"
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMigrations checks that the embedded migrations load and every one of them can be reverted.
+func TestMigrations(t *testing.T) {
+	migrations, err := migrate.Load(Migrations())
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	for i, migration := range migrations {
+		assert.Equal(t, int64(i+1), migration.Version, "versions are consecutive")
+		assert.NotEmpty(t, migration.Down, "%d_%s has no down file", migration.Version, migration.Name)
+	}
+}
+
+// TestMigrations_CanonicalUsernames checks that the canonical usernames are filled in
+// by the migration adding them.
+func TestMigrations_CanonicalUsernames(t *testing.T) {
+	migrations, err := migrate.Load(Migrations())
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(migrations), canonicalUsernamesVersion)
+
+	assert.Equal(t, "add_username_canonical", migrations[canonicalUsernamesVersion-1].Name)
+}
+
+// TestMigrations_RoundTrip applies every migration, reverts them all and applies them again
+// against the database at TEST_DATABASE_URL, a Postgres connection string. Everything the migrations
+// create there is dropped. The test is skipped when the variable is not set.
+func TestMigrations_RoundTrip(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	ctx := context.Background()
+
+	db, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	s := &Storage{db: db}
+
+	migrations, err := migrate.Load(Migrations())
+	require.NoError(t, err)
+
+	migrator, err := s.Migrator()
+	require.NoError(t, err)
+
+	// Start from an empty schema and leave one behind.
+	_, err = migrator.Down(ctx, len(migrations))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, err := migrator.Down(ctx, len(migrations))
+		assert.NoError(t, err)
+	})
+
+	// Users stored before the canonical forms existed get one on the way up,
+	// the later of two usernames of the same account is reported instead of failing the migration.
+	_, err = migrator.UpTo(ctx, canonicalUsernamesVersion-1)
+	require.NoError(t, err)
+
+	_, err = db.ExecContext(ctx, `INSERT INTO users (name, username, password_hash)
+		VALUES ('Matvey', 'MatveyTabby', ''), ('Impostor', 'matveytabby', '')`)
+	require.NoError(t, err)
+
+	applied, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	require.Len(t, applied, len(migrations)-canonicalUsernamesVersion+1)
+	assert.Len(t, applied[0].Notes, 1)
+
+	user, err := s.User(ctx, "matveytabby")
+	require.NoError(t, err)
+	assert.Equal(t, "MatveyTabby", user.Username)
+
+	reverted, err := migrator.Down(ctx, len(migrations))
+	require.NoError(t, err)
+	assert.Len(t, reverted, len(migrations))
+
+	applied, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, applied, len(migrations))
+
+	statuses, err := migrator.Status(ctx)
+	require.NoError(t, err)
+
+	for _, status := range statuses {
+		assert.False(t, status.AppliedAt.IsZero(), "%d_%s is not applied", status.Version, status.Name)
+	}
+}
@@ -0,0 +1,154 @@
+package storage
+
+import (
+	"auth/internal/domain/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+func (s *Storage) SaveOneTimeToken(ctx context.Context, token models.OneTimeToken) error {
+	const op = "storage.postgres.SaveOneTimeToken"
+
+	query := `INSERT INTO one_time_tokens (user_id, app_id, purpose, token_hash, expires_at) VALUES ($1, $2, $3, $4, $5)`
+
+	_, err := s.db.ExecContext(ctx, query, token.UserID, token.AppID, token.Purpose, token.TokenHash, token.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) OneTimeToken(ctx context.Context, tokenHash []byte, purpose string) (models.OneTimeToken, error) {
+	const op = "storage.postgres.OneTimeToken"
+
+	query := `SELECT id, user_id, app_id, purpose, token_hash, expires_at, used_at
+		FROM one_time_tokens WHERE token_hash=$1 AND purpose=$2`
+
+	var (
+		token  models.OneTimeToken
+		usedAt sql.NullTime
+	)
+
+	err := s.db.QueryRowContext(ctx, query, tokenHash, purpose).Scan(
+		&token.ID, &token.UserID, &token.AppID, &token.Purpose, &token.TokenHash, &token.ExpiresAt, &usedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.OneTimeToken{}, fmt.Errorf("%s: %w", op, ErrOneTimeTokenNotFound)
+		}
+
+		return models.OneTimeToken{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	token.UsedAt = usedAt.Time
+
+	return token, nil
+}
+
+// UseOneTimeToken marks the token as used. It fails with ErrOneTimeTokenUsed
+// if another request has used the token first.
+func (s *Storage) UseOneTimeToken(ctx context.Context, id int64) error {
+	const op = "storage.postgres.UseOneTimeToken"
+
+	query := `UPDATE one_time_tokens SET used_at=now() WHERE id=$1 AND used_at IS NULL`
+
+	res, err := s.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, ErrOneTimeTokenUsed)
+	}
+
+	return nil
+}
+
+// UseResetToken marks the password reset token as used and stores the new password hash of the user
+// in one transaction, so the token is used up only together with the change. It fails with
+// ErrOneTimeTokenUsed if another request has used the token first and with ErrUserNotFound
+// if the user is gone.
+func (s *Storage) UseResetToken(ctx context.Context, id int64, uid int, passHash []byte) error {
+	const op = "storage.postgres.UseResetToken"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `UPDATE one_time_tokens SET used_at=now() WHERE id=$1 AND used_at IS NULL`, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, ErrOneTimeTokenUsed)
+	}
+
+	res, err = tx.ExecContext(ctx, `UPDATE users SET password_hash=$1 WHERE id=$2`, passHash, uid)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err = res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, ErrUserNotFound)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteUserOneTimeTokens removes the tokens of the user issued for the purpose.
+func (s *Storage) DeleteUserOneTimeTokens(ctx context.Context, uid int, purpose string) error {
+	const op = "storage.postgres.DeleteUserOneTimeTokens"
+
+	query := `DELETE FROM one_time_tokens WHERE user_id=$1 AND purpose=$2`
+
+	if _, err := s.db.ExecContext(ctx, query, uid, purpose); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteExpiredOneTimeTokens removes one-time tokens that can no longer be used.
+func (s *Storage) DeleteExpiredOneTimeTokens(ctx context.Context, now time.Time) (int64, error) {
+	const op = "storage.postgres.DeleteExpiredOneTimeTokens"
+
+	query := `DELETE FROM one_time_tokens WHERE expires_at < $1`
+
+	res, err := s.db.ExecContext(ctx, query, now)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return deleted, nil
+}
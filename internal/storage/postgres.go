@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"auth/internal/canonical"
 	"auth/internal/config"
 	"auth/internal/domain/models"
 	"context"
@@ -9,6 +10,7 @@ import (
 	"fmt"
 	"github.com/lib/pq"
 	_ "github.com/lib/pq"
+	"time"
 )
 
 type Storage struct {
@@ -34,14 +36,19 @@ func NewStorage(cfg config.Config) (*Storage, error) {
 	return &Storage{db}, nil
 }
 
-func (s *Storage) SaveUser(ctx context.Context, name string, username string, passHash []byte) (int, error) {
+// SaveUser stores a new user with an unverified email, empty if the user gave none.
+// The username is kept as given for display, uniqueness is enforced on its canonical form,
+// so a username differing from a taken one only in case or look-alike characters fails with ErrUserExists.
+func (s *Storage) SaveUser(ctx context.Context, name string, username string, email string, passHash []byte) (int, error) {
 	const op = "storage.postgres.SaveUser"
 
-	query := fmt.Sprintf(`INSERT INTO users (name, username, password_hash) VALUES ($1, $2, $3) RETURNING id`)
+	query := `INSERT INTO users (name, username, username_canonical, email, password_hash) VALUES ($1, $2, $3, $4, $5) RETURNING id`
 
 	var id int
 
-	err := s.db.QueryRow(query, name, username, passHash).Scan(&id)
+	err := s.db.QueryRowContext(ctx, query,
+		name, username, canonical.Username(username), sql.NullString{String: email, Valid: email != ""}, passHash,
+	).Scan(&id)
 	if err != nil {
 		var pqErr *pq.Error
 		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
@@ -52,14 +59,49 @@ func (s *Storage) SaveUser(ctx context.Context, name string, username string, pa
 	return id, nil
 }
 
+// User returns the user whose username has the same canonical form as username.
 func (s *Storage) User(ctx context.Context, username string) (models.User, error) {
 	const op = "storage.postgres.User"
 
-	query := fmt.Sprintf(`SELECT id, name, username, password_hash FROM users WHERE username=$1`)
+	query := `SELECT ` + userColumns + ` FROM users WHERE username_canonical=$1`
 
-	var user models.User
+	user, err := scanUser(s.db.QueryRowContext(ctx, query, canonical.Username(username)))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.User{}, fmt.Errorf("%s: %w", op, ErrUserNotFound)
+		}
+
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
+
+func (s *Storage) UserByID(ctx context.Context, uid int) (models.User, error) {
+	const op = "storage.postgres.UserByID"
+
+	query := `SELECT ` + userColumns + ` FROM users WHERE id=$1`
+
+	user, err := scanUser(s.db.QueryRowContext(ctx, query, uid))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.User{}, fmt.Errorf("%s: %w", op, ErrUserNotFound)
+		}
+
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
 
-	err := s.db.QueryRow(query, username).Scan(&user.ID, &user.Name, &user.Username, &user.PassHash)
+	return user, nil
+}
+
+// UserByEmail returns the user with the verified email, compared case-insensitively.
+// Unverified emails are not unique and don't identify anyone.
+func (s *Storage) UserByEmail(ctx context.Context, email string) (models.User, error) {
+	const op = "storage.postgres.UserByEmail"
+
+	query := `SELECT ` + userColumns + ` FROM users WHERE lower(email)=lower($1) AND email_verified_at IS NOT NULL`
+
+	user, err := scanUser(s.db.QueryRowContext(ctx, query, email))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return models.User{}, fmt.Errorf("%s: %w", op, ErrUserNotFound)
@@ -70,3 +112,110 @@ func (s *Storage) User(ctx context.Context, username string) (models.User, error
 
 	return user, nil
 }
+
+// userColumns are the columns scanUser reads.
+const userColumns = `id, name, username, password_hash, email, email_verified_at, token_version`
+
+func scanUser(row *sql.Row) (models.User, error) {
+	var (
+		user            models.User
+		email           sql.NullString
+		emailVerifiedAt sql.NullTime
+	)
+
+	if err := row.Scan(&user.ID, &user.Name, &user.Username, &user.PassHash, &email, &emailVerifiedAt, &user.TokenVersion); err != nil {
+		return models.User{}, err
+	}
+
+	user.Email = email.String
+	user.EmailVerifiedAt = emailVerifiedAt.Time
+
+	return user, nil
+}
+
+func (s *Storage) UpdatePasswordHash(ctx context.Context, uid int, passHash []byte) error {
+	const op = "storage.postgres.UpdatePasswordHash"
+
+	query := `UPDATE users SET password_hash=$1 WHERE id=$2`
+
+	res, err := s.db.ExecContext(ctx, query, passHash, uid)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if updated == 0 {
+		return fmt.Errorf("%s: %w", op, ErrUserNotFound)
+	}
+
+	return nil
+}
+
+// SetEmail sets the email of the user, which is not verified until VerifyEmail is called.
+// Any number of users may claim an email, one verified by another user fails with ErrEmailExists.
+func (s *Storage) SetEmail(ctx context.Context, uid int, email string) error {
+	const op = "storage.postgres.SetEmail"
+
+	takenQuery := `SELECT EXISTS (SELECT 1 FROM users WHERE lower(email)=lower($1) AND email_verified_at IS NOT NULL AND id<>$2)`
+
+	var taken bool
+	if err := s.db.QueryRowContext(ctx, takenQuery, email, uid).Scan(&taken); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if taken {
+		return fmt.Errorf("%s: %w", op, ErrEmailExists)
+	}
+
+	query := `UPDATE users SET email=$1, email_verified_at=NULL WHERE id=$2`
+
+	res, err := s.db.ExecContext(ctx, query, email, uid)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if updated == 0 {
+		return fmt.Errorf("%s: %w", op, ErrUserNotFound)
+	}
+
+	return nil
+}
+
+// VerifyEmail marks the email of the user verified at the moment,
+// provided the user still has that email. Verified emails are unique regardless of case,
+// one verified by another user first fails with ErrEmailExists.
+func (s *Storage) VerifyEmail(ctx context.Context, uid int, email string, verifiedAt time.Time) error {
+	const op = "storage.postgres.VerifyEmail"
+
+	query := `UPDATE users SET email_verified_at=$1 WHERE id=$2 AND lower(email)=lower($3)`
+
+	res, err := s.db.ExecContext(ctx, query, verifiedAt, uid, email)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return fmt.Errorf("%s: %w", op, ErrEmailExists)
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if updated == 0 {
+		return fmt.Errorf("%s: %w", op, ErrUserNotFound)
+	}
+
+	return nil
+}
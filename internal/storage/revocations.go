@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+func (s *Storage) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	const op = "storage.postgres.RevokeToken"
+
+	query := `INSERT INTO revoked_tokens (jti, expires_at) VALUES ($1, $2) ON CONFLICT (jti) DO NOTHING`
+
+	if _, err := s.db.ExecContext(ctx, query, jti, expiresAt); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	const op = "storage.postgres.IsTokenRevoked"
+
+	query := `SELECT EXISTS(SELECT 1 FROM revoked_tokens WHERE jti=$1)`
+
+	var revoked bool
+
+	if err := s.db.QueryRowContext(ctx, query, jti).Scan(&revoked); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return revoked, nil
+}
+
+// RevokeUserTokens bumps the token version of the user, revoking every token issued before.
+func (s *Storage) RevokeUserTokens(ctx context.Context, uid int) error {
+	const op = "storage.postgres.RevokeUserTokens"
+
+	query := `UPDATE users SET token_version = token_version + 1 WHERE id=$1`
+
+	res, err := s.db.ExecContext(ctx, query, uid)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if updated == 0 {
+		return fmt.Errorf("%s: %w", op, ErrUserNotFound)
+	}
+
+	return nil
+}
+
+// UserTokenVersion returns the token version of the user, valid tokens carry the same.
+func (s *Storage) UserTokenVersion(ctx context.Context, uid int) (int, error) {
+	const op = "storage.postgres.UserTokenVersion"
+
+	query := `SELECT token_version FROM users WHERE id=$1`
+
+	var version int
+
+	err := s.db.QueryRowContext(ctx, query, uid).Scan(&version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("%s: %w", op, ErrUserNotFound)
+		}
+
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return version, nil
+}
+
+// DeleteExpiredRevokedTokens removes revocations of tokens that have expired anyway.
+func (s *Storage) DeleteExpiredRevokedTokens(ctx context.Context, now time.Time) (int64, error) {
+	const op = "storage.postgres.DeleteExpiredRevokedTokens"
+
+	query := `DELETE FROM revoked_tokens WHERE expires_at < $1`
+
+	res, err := s.db.ExecContext(ctx, query, now)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+func (s *Storage) UserRoles(ctx context.Context, uid int) ([]string, error) {
+	const op = "storage.postgres.UserRoles"
+
+	query := `SELECT r.name FROM user_roles ur
+		JOIN roles r ON r.id = ur.role_id
+		WHERE ur.user_id=$1
+		ORDER BY r.name`
+
+	roles, err := s.strings(ctx, query, uid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return roles, nil
+}
+
+func (s *Storage) UserPermissions(ctx context.Context, uid int) ([]string, error) {
+	const op = "storage.postgres.UserPermissions"
+
+	query := `SELECT DISTINCT p.name FROM user_roles ur
+		JOIN role_permissions rp ON rp.role_id = ur.role_id
+		JOIN permissions p ON p.id = rp.permission_id
+		WHERE ur.user_id=$1
+		ORDER BY p.name`
+
+	permissions, err := s.strings(ctx, query, uid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return permissions, nil
+}
+
+// AssignRole grants the role to the user, assigning a role the user already has is not an error.
+func (s *Storage) AssignRole(ctx context.Context, uid int, role string) error {
+	const op = "storage.postgres.AssignRole"
+
+	query := `INSERT INTO user_roles (user_id, role_id)
+		SELECT $1, id FROM roles WHERE name=$2
+		ON CONFLICT DO NOTHING`
+
+	res, err := s.db.ExecContext(ctx, query, uid, role)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return fmt.Errorf("%s: %w", op, ErrUserNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if affected == 0 {
+		exists, err := s.roleExists(ctx, role)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if !exists {
+			return fmt.Errorf("%s: %w", op, ErrRoleNotFound)
+		}
+	}
+
+	return nil
+}
+
+// RevokeRole takes the role away from the user, revoking a role the user doesn't have is not an error.
+func (s *Storage) RevokeRole(ctx context.Context, uid int, role string) error {
+	const op = "storage.postgres.RevokeRole"
+
+	exists, err := s.roleExists(ctx, role)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !exists {
+		return fmt.Errorf("%s: %w", op, ErrRoleNotFound)
+	}
+
+	query := `DELETE FROM user_roles WHERE user_id=$1 AND role_id=(SELECT id FROM roles WHERE name=$2)`
+
+	if _, err := s.db.ExecContext(ctx, query, uid, role); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) roleExists(ctx context.Context, role string) (bool, error) {
+	var exists bool
+
+	err := s.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM roles WHERE name=$1)`, role).Scan(&exists)
+
+	return exists, err
+}
+
+// strings runs a query returning a single text column.
+func (s *Storage) strings(ctx context.Context, query string, args ...interface{}) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		res = append(res, value)
+	}
+
+	return res, rows.Err()
+}
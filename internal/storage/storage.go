@@ -7,4 +7,23 @@ import (
 var (
 	ErrUserExists   = errors.New("user already exists")
 	ErrUserNotFound = errors.New("user not found")
+	ErrEmailExists  = errors.New("email already exists")
+	ErrAppNotFound  = errors.New("app not found")
+	ErrRoleNotFound = errors.New("role not found")
+
+	ErrRefreshTokenNotFound = errors.New("refresh token not found")
+	ErrRefreshTokenUsed     = errors.New("refresh token already used")
+
+	ErrOneTimeTokenNotFound = errors.New("one-time token not found")
+	ErrOneTimeTokenUsed     = errors.New("one-time token already used")
+
+	ErrTOTPNotFound         = errors.New("totp not found")
+	ErrTOTPEnabled          = errors.New("totp already enabled")
+	ErrTOTPCodeUsed         = errors.New("totp code already used")
+	ErrRecoveryCodeNotFound = errors.New("recovery code not found")
+
+	ErrCredentialExists        = errors.New("webauthn credential already exists")
+	ErrCredentialNotFound      = errors.New("webauthn credential not found")
+	ErrSignCountNotIncreased   = errors.New("webauthn signature counter did not increase")
+	ErrWebAuthnSessionNotFound = errors.New("webauthn session not found")
 )
@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"auth/internal/domain/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+func (s *Storage) SaveRefreshToken(ctx context.Context, token models.RefreshToken) error {
+	const op = "storage.postgres.SaveRefreshToken"
+
+	query := `INSERT INTO refresh_tokens (user_id, app_id, family_id, token_hash, expires_at) VALUES ($1, $2, $3, $4, $5)`
+
+	_, err := s.db.ExecContext(ctx, query, token.UserID, token.AppID, token.FamilyID, token.TokenHash, token.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) RefreshToken(ctx context.Context, tokenHash []byte) (models.RefreshToken, error) {
+	const op = "storage.postgres.RefreshToken"
+
+	query := `SELECT id, user_id, app_id, family_id, token_hash, expires_at, used_at, revoked_at IS NOT NULL
+		FROM refresh_tokens WHERE token_hash=$1`
+
+	var (
+		token  models.RefreshToken
+		usedAt sql.NullTime
+	)
+
+	err := s.db.QueryRowContext(ctx, query, tokenHash).Scan(
+		&token.ID, &token.UserID, &token.AppID, &token.FamilyID, &token.TokenHash, &token.ExpiresAt, &usedAt, &token.Revoked,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.RefreshToken{}, fmt.Errorf("%s: %w", op, ErrRefreshTokenNotFound)
+		}
+
+		return models.RefreshToken{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	token.UsedAt = usedAt.Time
+
+	return token, nil
+}
+
+// UseRefreshToken marks the token as exchanged. It fails with ErrRefreshTokenUsed
+// if another request has exchanged the token first.
+func (s *Storage) UseRefreshToken(ctx context.Context, id int64) error {
+	const op = "storage.postgres.UseRefreshToken"
+
+	query := `UPDATE refresh_tokens SET used_at=now() WHERE id=$1 AND used_at IS NULL`
+
+	res, err := s.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, ErrRefreshTokenUsed)
+	}
+
+	return nil
+}
+
+func (s *Storage) RevokeTokenFamily(ctx context.Context, familyID string) error {
+	const op = "storage.postgres.RevokeTokenFamily"
+
+	query := `UPDATE refresh_tokens SET revoked_at=now() WHERE family_id=$1 AND revoked_at IS NULL`
+
+	if _, err := s.db.ExecContext(ctx, query, familyID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) RevokeUserRefreshTokens(ctx context.Context, uid int) error {
+	const op = "storage.postgres.RevokeUserRefreshTokens"
+
+	query := `UPDATE refresh_tokens SET revoked_at=now() WHERE user_id=$1 AND revoked_at IS NULL`
+
+	if _, err := s.db.ExecContext(ctx, query, uid); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteExpiredRefreshTokens removes refresh tokens that can no longer be exchanged.
+func (s *Storage) DeleteExpiredRefreshTokens(ctx context.Context, now time.Time) (int64, error) {
+	const op = "storage.postgres.DeleteExpiredRefreshTokens"
+
+	query := `DELETE FROM refresh_tokens WHERE expires_at < $1`
+
+	res, err := s.db.ExecContext(ctx, query, now)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,174 @@
+package storage
+
+import (
+	"auth/internal/domain/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func (s *Storage) SaveWebAuthnCredential(ctx context.Context, credential models.WebAuthnCredential) error {
+	const op = "storage.postgres.SaveWebAuthnCredential"
+
+	query := `INSERT INTO webauthn_credentials
+		(user_id, credential_id, public_key, attestation_type, aaguid, sign_count, transports)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err := s.db.ExecContext(ctx, query,
+		credential.UserID,
+		credential.CredentialID,
+		credential.PublicKey,
+		credential.AttestationType,
+		credential.AAGUID,
+		int64(credential.SignCount),
+		pq.Array(credential.Transports),
+	)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return fmt.Errorf("%s: %w", op, ErrCredentialExists)
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) UserWebAuthnCredentials(ctx context.Context, uid int) ([]models.WebAuthnCredential, error) {
+	const op = "storage.postgres.UserWebAuthnCredentials"
+
+	query := `SELECT id, user_id, credential_id, public_key, attestation_type, aaguid, sign_count, transports,
+		created_at, last_used_at
+		FROM webauthn_credentials WHERE user_id=$1 ORDER BY id`
+
+	rows, err := s.db.QueryContext(ctx, query, uid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var credentials []models.WebAuthnCredential
+
+	for rows.Next() {
+		var (
+			credential models.WebAuthnCredential
+			signCount  int64
+			lastUsedAt sql.NullTime
+		)
+
+		err := rows.Scan(
+			&credential.ID,
+			&credential.UserID,
+			&credential.CredentialID,
+			&credential.PublicKey,
+			&credential.AttestationType,
+			&credential.AAGUID,
+			&signCount,
+			pq.Array(&credential.Transports),
+			&credential.CreatedAt,
+			&lastUsedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		credential.SignCount = uint32(signCount)
+		credential.LastUsedAt = lastUsedAt.Time
+
+		credentials = append(credentials, credential)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return credentials, nil
+}
+
+// UpdateWebAuthnCredential records a login with the credential and the signature counter it reported.
+// The counter must grow past the stored one in the same statement, so of two logins reporting
+// the same counter only one is recorded, the other fails with ErrSignCountNotIncreased.
+// Authenticators without a counter report zero every time and are let through.
+func (s *Storage) UpdateWebAuthnCredential(ctx context.Context, credentialID []byte, signCount uint32, usedAt time.Time) error {
+	const op = "storage.postgres.UpdateWebAuthnCredential"
+
+	query := `UPDATE webauthn_credentials SET sign_count=$2, last_used_at=$3
+		WHERE credential_id=$1 AND (sign_count < $2 OR (sign_count = 0 AND $2 = 0))`
+
+	res, err := s.db.ExecContext(ctx, query, credentialID, int64(signCount), usedAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, ErrSignCountNotIncreased)
+	}
+
+	return nil
+}
+
+func (s *Storage) SaveWebAuthnSession(ctx context.Context, session models.WebAuthnSession) error {
+	const op = "storage.postgres.SaveWebAuthnSession"
+
+	query := `INSERT INTO webauthn_sessions (user_id, purpose, token_hash, data, expires_at) VALUES ($1, $2, $3, $4, $5)`
+
+	_, err := s.db.ExecContext(ctx, query, session.UserID, session.Purpose, session.TokenHash, session.Data, session.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// TakeWebAuthnSession removes the session and returns it, so every ceremony can be finished only once.
+// Expired sessions are returned as well, the caller checks the expiry.
+func (s *Storage) TakeWebAuthnSession(ctx context.Context, tokenHash []byte, purpose string) (models.WebAuthnSession, error) {
+	const op = "storage.postgres.TakeWebAuthnSession"
+
+	query := `DELETE FROM webauthn_sessions WHERE token_hash=$1 AND purpose=$2
+		RETURNING user_id, purpose, token_hash, data, expires_at`
+
+	var session models.WebAuthnSession
+
+	err := s.db.QueryRowContext(ctx, query, tokenHash, purpose).Scan(
+		&session.UserID, &session.Purpose, &session.TokenHash, &session.Data, &session.ExpiresAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.WebAuthnSession{}, fmt.Errorf("%s: %w", op, ErrWebAuthnSessionNotFound)
+		}
+
+		return models.WebAuthnSession{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return session, nil
+}
+
+// DeleteExpiredWebAuthnSessions removes ceremonies that were started but never finished in time.
+func (s *Storage) DeleteExpiredWebAuthnSessions(ctx context.Context, now time.Time) (int64, error) {
+	const op = "storage.postgres.DeleteExpiredWebAuthnSessions"
+
+	query := `DELETE FROM webauthn_sessions WHERE expires_at < $1`
+
+	res, err := s.db.ExecContext(ctx, query, now)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,103 @@
+package totp
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret is the SHA1 secret of the test vectors in RFC 6238, appendix B.
+var rfcSecret = []byte("12345678901234567890")
+
+func Test_hotp(t *testing.T) {
+	tests := []struct {
+		nameTest string
+		time     int64
+		expected string
+	}{
+		{nameTest: "59", time: 59, expected: "94287082"},
+		{nameTest: "1111111109", time: 1111111109, expected: "07081804"},
+		{nameTest: "1111111111", time: 1111111111, expected: "14050471"},
+		{nameTest: "1234567890", time: 1234567890, expected: "89005924"},
+		{nameTest: "2000000000", time: 2000000000, expected: "69279037"},
+		{nameTest: "20000000000", time: 20000000000, expected: "65353130"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nameTest, func(t *testing.T) {
+			assert.Equal(t, tc.expected, hotp(rfcSecret, Step(time.Unix(tc.time, 0)), 8))
+			assert.Equal(t, tc.expected[2:], Code(rfcSecret, time.Unix(tc.time, 0)))
+		})
+	}
+}
+
+func Test_Validate(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+
+	tests := []struct {
+		nameTest     string
+		code         string
+		expectedOK   bool
+		expectedStep int64
+	}{
+		{
+			nameTest:     "Current code",
+			code:         Code(rfcSecret, now),
+			expectedOK:   true,
+			expectedStep: Step(now),
+		},
+		{
+			nameTest:     "Previous code",
+			code:         Code(rfcSecret, now.Add(-Period)),
+			expectedOK:   true,
+			expectedStep: Step(now) - 1,
+		},
+		{
+			nameTest:     "Next code",
+			code:         Code(rfcSecret, now.Add(Period)),
+			expectedOK:   true,
+			expectedStep: Step(now) + 1,
+		},
+		{
+			nameTest: "Outdated code",
+			code:     Code(rfcSecret, now.Add(-2*Period)),
+		},
+		{
+			nameTest: "Wrong code",
+			code:     "000000",
+		},
+		{
+			nameTest: "Wrong length",
+			code:     "12345",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.nameTest, func(t *testing.T) {
+			step, ok := Validate(rfcSecret, tc.code, now)
+
+			assert.Equal(t, tc.expectedOK, ok)
+			assert.Equal(t, tc.expectedStep, step)
+		})
+	}
+}
+
+func Test_URI(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+	assert.Len(t, secret, SecretLen)
+
+	u, err := url.Parse(URI("Auth", "MatveyTabby", secret))
+	require.NoError(t, err)
+
+	assert.Equal(t, "otpauth", u.Scheme)
+	assert.Equal(t, "totp", u.Host)
+	assert.Equal(t, "/Auth:MatveyTabby", u.Path)
+	assert.Equal(t, EncodeSecret(secret), u.Query().Get("secret"))
+	assert.Equal(t, "Auth", u.Query().Get("issuer"))
+	assert.Equal(t, "6", u.Query().Get("digits"))
+	assert.Equal(t, "30", u.Query().Get("period"))
+}
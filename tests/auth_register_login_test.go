@@ -1,12 +1,13 @@
 package tests
 
 import (
+	authjwt "auth/internal/jwt"
 	"auth/tests/suite"
 	authv1 "github.com/3XBAT/protos/gen/go"
 	"github.com/brianvoe/gofakeit"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"strconv"
 	"testing"
 	"time"
 )
@@ -40,21 +41,20 @@ func TestRegisterLogin_Login_HappyPath(t *testing.T) {
 
 	token := respLogin.GetToken()
 
-	tokenParsed, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
-		return []byte("secret"), nil
-	})
-
+	key, err := authjwt.LoadKey(st.Cfg.JWT.SigningKey)
 	require.NoError(t, err)
 
-	claims, ok := tokenParsed.Claims.(jwt.MapClaims)
-	assert.True(t, ok)
+	claims, err := authjwt.Verify(token, authjwt.NewKeyRing(key), authjwt.VerifyOptions{Issuer: st.Cfg.JWT.Issuer})
+	require.NoError(t, err)
 
-	assert.Equal(t, respReg.GetUserId(), int64(claims["uid"].(float64)))
-	assert.Equal(t, username, claims["username"].(string))
+	assert.Equal(t, respReg.GetUserId(), claims.UID)
+	assert.Equal(t, username, claims.Username)
+	assert.Equal(t, strconv.FormatInt(respReg.GetUserId(), 10), claims.Subject)
+	assert.NotEmpty(t, claims.ID)
 
 	const deltaSeconds = 1
 
-	assert.InDelta(t, loginTime.Add(st.Cfg.TokenTTL).Unix(), claims["exp"].(float64), deltaSeconds)
+	assert.InDelta(t, loginTime.Add(st.Cfg.TokenTTL).Unix(), claims.ExpiresAt.Unix(), deltaSeconds)
 }
 
 func TestRegisterLogin_DuplicateRegistration(t *testing.T) {
@@ -160,7 +160,7 @@ func TestLogin_FailCase(t *testing.T) {
 			nameTest:    "Login with Non-Matching Password",
 			password:    RandomFakePassword(),
 			username:    gofakeit.Username(),
-			expectedErr: "user not found",
+			expectedErr: "invalid credentials",
 		},
 	}
 